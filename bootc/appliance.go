@@ -0,0 +1,435 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	packagingOVA               = "ova"
+	packagingVagrantLibvirt    = "vagrant-libvirt"
+	packagingVagrantVirtualBox = "vagrant-virtualbox"
+
+	firmwareEFI  = "efi"
+	firmwareBIOS = "bios"
+
+	nicE1000   = "e1000"
+	nicE1000e  = "e1000e"
+	nicVMXNet3 = "vmxnet3"
+
+	defaultVirtualCPUs   = 2
+	defaultVirtualMemory = 2048
+
+	// The file names vagrant-libvirt and the VirtualBox box importer look
+	// for.
+	vagrantBoxDisk = "box.img"
+	vagrantBoxOVF  = "box.ovf"
+	vagrantBoxVMDK = "box-disk001.vmdk"
+)
+
+// VirtualHardwareModel maps the virtual_hardware block.
+type VirtualHardwareModel struct {
+	CPUs           types.Int64  `tfsdk:"cpus"`
+	Memory         types.Int64  `tfsdk:"memory"`
+	NetworkAdapter types.String `tfsdk:"network_adapter"`
+	Firmware       types.String `tfsdk:"firmware"`
+}
+
+// virtualHardware is the resolved virtual_hardware of the OVA and Vagrant
+// box descriptors.
+type virtualHardware struct {
+	NetworkAdapter string
+	Firmware       string
+	CPUs           int64
+	// MemoryMiB is the memory size in MiB.
+	MemoryMiB int64
+}
+
+// ovfNICSubtypes are the OVF ResourceSubType values of network_adapter.
+var ovfNICSubtypes = map[string]string{
+	nicE1000:   "E1000",
+	nicE1000e:  "E1000e",
+	nicVMXNet3: "VmxNet3",
+}
+
+// virtualHardware resolves the virtual_hardware block: 2 vCPUs, 2 GiB of
+// memory, an e1000 NIC, which every hypervisor emulates, and UEFI.
+func (m *ImageResourceModel) virtualHardware() virtualHardware {
+	hw := virtualHardware{
+		CPUs:           defaultVirtualCPUs,
+		MemoryMiB:      defaultVirtualMemory,
+		NetworkAdapter: nicE1000,
+		Firmware:       firmwareEFI,
+	}
+
+	model := m.VirtualHardware
+	if model == nil {
+		return hw
+	}
+
+	if !model.CPUs.IsNull() {
+		hw.CPUs = model.CPUs.ValueInt64()
+	}
+
+	if !model.Memory.IsNull() {
+		hw.MemoryMiB = model.Memory.ValueInt64()
+	}
+
+	if !model.NetworkAdapter.IsNull() {
+		hw.NetworkAdapter = model.NetworkAdapter.ValueString()
+	}
+
+	if !model.Firmware.IsNull() {
+		hw.Firmware = model.Firmware.ValueString()
+	}
+
+	return hw
+}
+
+// ovfDescriptor renders an OVF 1.0 descriptor of a virtual machine with one
+// stream-optimized VMDK on a SATA controller and one NAT NIC. VirtualBox
+// and VMware spell the AHCI controller and the hardware family
+// differently; the firmware is a VMware extension VirtualBox ignores.
+func ovfDescriptor(name, diskFile string, diskFileSize, capacity int64, hw virtualHardware, virtualBox bool) string {
+	systemType, sataSubtype := "vmx-14", "vmware.sata.ahci"
+	if virtualBox {
+		systemType, sataSubtype = "virtualbox-2.2", "AHCI"
+	}
+
+	var out strings.Builder
+
+	out.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<Envelope xmlns="http://schemas.dmtf.org/ovf/envelope/1" xmlns:ovf="http://schemas.dmtf.org/ovf/envelope/1" ` +
+		`xmlns:rasd="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_ResourceAllocationSettingData" ` +
+		`xmlns:vssd="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_VirtualSystemSettingData" ` +
+		`xmlns:vmw="http://www.vmware.com/schema/ovf">
+`)
+	fmt.Fprintf(&out, `  <References>
+    <File ovf:id="file1" ovf:href="%s" ovf:size="%d"/>
+  </References>
+  <DiskSection>
+    <Info>Virtual disk information</Info>
+    <Disk ovf:diskId="vmdisk1" ovf:fileRef="file1" ovf:capacity="%d" ovf:capacityAllocationUnits="byte" `+
+		`ovf:format="http://www.vmware.com/interfaces/specifications/vmdk.html#streamOptimized"/>
+  </DiskSection>
+  <NetworkSection>
+    <Info>Logical networks</Info>
+    <Network ovf:name="nat">
+      <Description>NAT network</Description>
+    </Network>
+  </NetworkSection>
+`, xmlEscape(diskFile), diskFileSize, capacity)
+	fmt.Fprintf(&out, `  <VirtualSystem ovf:id="%[1]s">
+    <Info>A bootc virtual machine</Info>
+    <Name>%[1]s</Name>
+    <OperatingSystemSection ovf:id="101" vmw:osType="otherLinux64Guest">
+      <Info>Guest operating system</Info>
+      <Description>Linux 64-Bit</Description>
+    </OperatingSystemSection>
+    <VirtualHardwareSection>
+      <Info>Virtual hardware requirements</Info>
+      <System>
+        <vssd:ElementName>Virtual Hardware Family</vssd:ElementName>
+        <vssd:InstanceID>0</vssd:InstanceID>
+        <vssd:VirtualSystemIdentifier>%[1]s</vssd:VirtualSystemIdentifier>
+        <vssd:VirtualSystemType>%[2]s</vssd:VirtualSystemType>
+      </System>
+`, xmlEscape(name), systemType)
+	fmt.Fprintf(&out, `      <Item>
+        <rasd:AllocationUnits>hertz * 10^6</rasd:AllocationUnits>
+        <rasd:ElementName>%[1]d virtual CPU(s)</rasd:ElementName>
+        <rasd:InstanceID>1</rasd:InstanceID>
+        <rasd:ResourceType>3</rasd:ResourceType>
+        <rasd:VirtualQuantity>%[1]d</rasd:VirtualQuantity>
+      </Item>
+      <Item>
+        <rasd:AllocationUnits>byte * 2^20</rasd:AllocationUnits>
+        <rasd:ElementName>%[2]d MB of memory</rasd:ElementName>
+        <rasd:InstanceID>2</rasd:InstanceID>
+        <rasd:ResourceType>4</rasd:ResourceType>
+        <rasd:VirtualQuantity>%[2]d</rasd:VirtualQuantity>
+      </Item>
+      <Item>
+        <rasd:Address>0</rasd:Address>
+        <rasd:ElementName>SATA controller 0</rasd:ElementName>
+        <rasd:InstanceID>3</rasd:InstanceID>
+        <rasd:ResourceSubType>%[3]s</rasd:ResourceSubType>
+        <rasd:ResourceType>20</rasd:ResourceType>
+      </Item>
+      <Item>
+        <rasd:AddressOnParent>0</rasd:AddressOnParent>
+        <rasd:ElementName>Hard disk 1</rasd:ElementName>
+        <rasd:HostResource>ovf:/disk/vmdisk1</rasd:HostResource>
+        <rasd:InstanceID>4</rasd:InstanceID>
+        <rasd:Parent>3</rasd:Parent>
+        <rasd:ResourceType>17</rasd:ResourceType>
+      </Item>
+      <Item>
+        <rasd:AutomaticAllocation>true</rasd:AutomaticAllocation>
+        <rasd:Connection>nat</rasd:Connection>
+        <rasd:ElementName>Network adapter 1</rasd:ElementName>
+        <rasd:InstanceID>5</rasd:InstanceID>
+        <rasd:ResourceSubType>%[4]s</rasd:ResourceSubType>
+        <rasd:ResourceType>10</rasd:ResourceType>
+      </Item>
+      <vmw:Config ovf:required="false" vmw:key="firmware" vmw:value="%[5]s"/>
+    </VirtualHardwareSection>
+  </VirtualSystem>
+</Envelope>
+`, hw.CPUs, hw.MemoryMiB, sataSubtype, ovfNICSubtypes[hw.NetworkAdapter], hw.Firmware)
+
+	return out.String()
+}
+
+func xmlEscape(value string) string {
+	var out strings.Builder
+
+	_ = xml.EscapeText(&out, []byte(value))
+
+	return out.String()
+}
+
+// ovfManifest renders the manifest listing the SHA-256 digest of each file
+// of an OVA, in the form ovftool and VirtualBox verify.
+func ovfManifest(files []tarEntry) (string, error) {
+	var out strings.Builder
+
+	for _, file := range files {
+		sum := sha256.Sum256(file.Content)
+		digest := hex.EncodeToString(sum[:])
+
+		if file.Path != "" {
+			var err error
+
+			digest, err = fileSHA256(file.Path)
+			if err != nil {
+				return "", err
+			}
+		}
+
+		fmt.Fprintf(&out, "SHA256(%s)= %s\n", file.Name, digest)
+	}
+
+	return out.String(), nil
+}
+
+// vagrantMetadata is the metadata.json of a Vagrant box. virtual_size is
+// in GiB, as vagrant-libvirt reads it.
+type vagrantMetadata struct {
+	Provider    string `json:"provider"`
+	Format      string `json:"format,omitempty"`
+	VirtualSize int64  `json:"virtual_size,omitempty"`
+}
+
+// vagrantfile renders the Vagrantfile embedded in a box, which sets the
+// provider's CPU, memory, NIC and firmware defaults. vagrant-libvirt boots
+// with the host's default firmware.
+func vagrantfile(provider string, hw virtualHardware) string {
+	var out strings.Builder
+
+	out.WriteString("Vagrant.configure(\"2\") do |config|\n")
+
+	if provider == packagingVagrantVirtualBox {
+		out.WriteString("  config.vm.provider :virtualbox do |vb|\n")
+		fmt.Fprintf(&out, "    vb.cpus = %d\n    vb.memory = %d\n", hw.CPUs, hw.MemoryMiB)
+		fmt.Fprintf(&out, "    vb.customize [\"modifyvm\", :id, \"--firmware\", %q]\n", hw.Firmware)
+	} else {
+		out.WriteString("  config.vm.provider :libvirt do |libvirt|\n")
+		fmt.Fprintf(&out, "    libvirt.cpus = %d\n    libvirt.memory = %d\n", hw.CPUs, hw.MemoryMiB)
+		fmt.Fprintf(&out, "    libvirt.nic_model_type = %q\n", hw.NetworkAdapter)
+	}
+
+	out.WriteString("  end\nend\n")
+
+	return out.String()
+}
+
+// convertDisk converts the raw disk to format at output with qemu-img. In
+// a reproducible build, the identifiers qemu-img generates are pinned.
+func convertDisk(ctx context.Context, format, rawPath, output string) error {
+	_, err := runCommand(ctx, "qemu-img", convertArgs(format, rawPath, output)...)
+	if err != nil {
+		return err
+	}
+
+	if build, ok := ctx.Value(reproducibleBuildKey{}).(reproducibleBuild); ok {
+		return pinImageIdentifiers(output, format, build)
+	}
+
+	return nil
+}
+
+// writeOVA writes an OVA: the OVF descriptor, its manifest and a
+// stream-optimized VMDK, in the order the OVF specification requires, in an
+// uncompressed tarball.
+func writeOVA(ctx context.Context, rawPath, output, name string, hw virtualHardware, mtime *time.Time) error {
+	return withPackagingDir(output, func(dir string) error {
+		vmdk := tarEntry{Name: name + "-disk1.vmdk", Path: filepath.Join(dir, name+"-disk1.vmdk")}
+
+		descriptor, err := vmdkDescriptor(ctx, rawPath, vmdk, name, hw, false)
+		if err != nil {
+			return err
+		}
+
+		ovf := tarEntry{Name: name + ".ovf", Content: []byte(descriptor)}
+
+		manifest, err := ovfManifest([]tarEntry{ovf, vmdk})
+		if err != nil {
+			return err
+		}
+
+		mf := tarEntry{Name: name + ".mf", Content: []byte(manifest)}
+
+		return writeTarball(output, []tarEntry{ovf, mf, vmdk}, tar.FormatUnknown, false, mtime)
+	})
+}
+
+// writeVagrantBox writes a gzipped Vagrant box for vagrant-libvirt, with a
+// qcow2 box.img, or for VirtualBox, with box.ovf and a stream-optimized
+// VMDK.
+func writeVagrantBox(ctx context.Context, rawPath, output, name, provider string, hw virtualHardware, mtime *time.Time) error {
+	info, err := os.Stat(rawPath)
+	if err != nil {
+		return err
+	}
+
+	return withPackagingDir(output, func(dir string) error {
+		metadata := vagrantMetadata{Provider: "virtualbox"}
+
+		var disks []tarEntry
+
+		if provider == packagingVagrantLibvirt {
+			metadata = vagrantMetadata{
+				Provider:    "libvirt",
+				Format:      formatQCOW2,
+				VirtualSize: (info.Size() + gibibyte - 1) / gibibyte,
+			}
+
+			img := tarEntry{Name: vagrantBoxDisk, Path: filepath.Join(dir, vagrantBoxDisk)}
+			if err := convertDisk(ctx, formatQCOW2, rawPath, img.Path); err != nil {
+				return err
+			}
+
+			disks = append(disks, img)
+		} else {
+			vmdk := tarEntry{Name: vagrantBoxVMDK, Path: filepath.Join(dir, vagrantBoxVMDK)}
+
+			descriptor, err := vmdkDescriptor(ctx, rawPath, vmdk, name, hw, true)
+			if err != nil {
+				return err
+			}
+
+			disks = append(disks, tarEntry{Name: vagrantBoxOVF, Content: []byte(descriptor)}, vmdk)
+		}
+
+		encoded, err := json.Marshal(metadata)
+		if err != nil {
+			return err
+		}
+
+		entries := append([]tarEntry{
+			{Name: "metadata.json", Content: append(encoded, '\n')},
+			{Name: "Vagrantfile", Content: []byte(vagrantfile(provider, hw))},
+		}, disks...)
+
+		return writeTarball(output, entries, tar.FormatUnknown, true, mtime)
+	})
+}
+
+// vmdkDescriptor converts the raw disk to the stream-optimized vmdk entry
+// and returns the OVF descriptor referencing it.
+func vmdkDescriptor(ctx context.Context, rawPath string, vmdk tarEntry, name string, hw virtualHardware, virtualBox bool) (string, error) {
+	if err := convertDisk(ctx, formatVMDK, rawPath, vmdk.Path); err != nil {
+		return "", err
+	}
+
+	rawInfo, err := os.Stat(rawPath)
+	if err != nil {
+		return "", err
+	}
+
+	vmdkInfo, err := os.Stat(vmdk.Path)
+	if err != nil {
+		return "", err
+	}
+
+	return ovfDescriptor(name, vmdk.Name, vmdkInfo.Size(), rawInfo.Size(), hw, virtualBox), nil
+}
+
+// withPackagingDir runs fn with a scratch directory next to output, so the
+// intermediate disks are written to the same filesystem as the artifact.
+func withPackagingDir(output string, fn func(dir string) error) error {
+	dir, err := os.MkdirTemp(filepath.Dir(output), ".bootc-package-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	return fn(dir)
+}
+
+// validateVirtualHardware checks the virtual_hardware block against the
+// hypervisors of the packagings.
+func validateVirtualHardware(data *ImageResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	model := data.VirtualHardware
+	if model == nil {
+		return diags
+	}
+
+	blockPath := path.Root("virtual_hardware")
+
+	if !model.CPUs.IsNull() && !model.CPUs.IsUnknown() && model.CPUs.ValueInt64() < 1 {
+		diags.AddAttributeError(blockPath.AtName("cpus"), "Invalid CPU count",
+			fmt.Sprintf("Expected at least 1 vCPU, got: %d", model.CPUs.ValueInt64()))
+	}
+
+	if !model.Memory.IsNull() && !model.Memory.IsUnknown() && model.Memory.ValueInt64() < 1 {
+		diags.AddAttributeError(blockPath.AtName("memory"), "Invalid memory size",
+			fmt.Sprintf("Expected a memory size in MiB of at least 1, got: %d", model.Memory.ValueInt64()))
+	}
+
+	// VirtualBox emulates Intel PRO/1000 MT NICs, not the e1000e or vmxnet3.
+	if adapter := model.NetworkAdapter.ValueString(); adapter != "" && adapter != nicE1000 &&
+		data.hasPackaging(packagingVagrantVirtualBox) {
+		diags.AddAttributeError(blockPath.AtName("network_adapter"), "Conflicting virtual hardware options",
+			"VirtualBox does not emulate "+adapter+". Use network_adapter = \"e1000\" with vagrant-virtualbox.")
+	}
+
+	if model.Firmware.ValueString() == firmwareBIOS && !data.BIOSBoot.IsUnknown() && !data.diskLabel().BIOSBoot {
+		diags.AddAttributeError(blockPath.AtName("firmware"), "Conflicting virtual hardware options",
+			"firmware = \"bios\" requires a disk with bios_boot.")
+	}
+
+	return diags
+}
@@ -0,0 +1,194 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestImageResourceModel_VirtualHardware(t *testing.T) {
+	hw := (&ImageResourceModel{}).virtualHardware()
+	if hw != (virtualHardware{CPUs: 2, MemoryMiB: 2048, NetworkAdapter: nicE1000, Firmware: firmwareEFI}) {
+		t.Errorf("default virtualHardware() = %+v", hw)
+	}
+
+	data := ImageResourceModel{VirtualHardware: &VirtualHardwareModel{
+		CPUs:           types.Int64Value(4),
+		Memory:         types.Int64Value(8192),
+		NetworkAdapter: types.StringValue(nicVMXNet3),
+		Firmware:       types.StringValue(firmwareBIOS),
+	}}
+
+	hw = data.virtualHardware()
+	if hw != (virtualHardware{CPUs: 4, MemoryMiB: 8192, NetworkAdapter: nicVMXNet3, Firmware: firmwareBIOS}) {
+		t.Errorf("virtualHardware() = %+v", hw)
+	}
+}
+
+func TestOVFDescriptor(t *testing.T) {
+	hw := virtualHardware{CPUs: 4, MemoryMiB: 4096, NetworkAdapter: nicVMXNet3, Firmware: firmwareEFI}
+
+	descriptor := ovfDescriptor("dev & test", "disk1.vmdk", 1234, 10<<30, hw, false)
+
+	var envelope struct {
+		References struct {
+			File struct {
+				Href string `xml:"href,attr"`
+				Size int64  `xml:"size,attr"`
+			}
+		}
+		DiskSection struct {
+			Disk struct {
+				Capacity int64 `xml:"capacity,attr"`
+			}
+		}
+		VirtualSystem struct {
+			Name string
+		}
+	}
+
+	err := xml.Unmarshal([]byte(descriptor), &envelope)
+	if err != nil {
+		t.Fatalf("descriptor is not valid XML: %v\n%s", err, descriptor)
+	}
+
+	if envelope.References.File.Href != "disk1.vmdk" || envelope.References.File.Size != 1234 ||
+		envelope.DiskSection.Disk.Capacity != 10<<30 || envelope.VirtualSystem.Name != "dev & test" {
+		t.Errorf("envelope = %+v", envelope)
+	}
+
+	for _, want := range []string{
+		"<rasd:VirtualQuantity>4</rasd:VirtualQuantity>",
+		"<rasd:VirtualQuantity>4096</rasd:VirtualQuantity>",
+		"<rasd:ResourceSubType>VmxNet3</rasd:ResourceSubType>",
+		"<rasd:ResourceSubType>vmware.sata.ahci</rasd:ResourceSubType>",
+		"<vssd:VirtualSystemType>vmx-14</vssd:VirtualSystemType>",
+		`vmw:key="firmware" vmw:value="efi"`,
+	} {
+		if !strings.Contains(descriptor, want) {
+			t.Errorf("descriptor missing %s", want)
+		}
+	}
+
+	descriptor = ovfDescriptor("box", "box-disk001.vmdk", 1, 1, hw, true)
+	if !strings.Contains(descriptor, "<rasd:ResourceSubType>AHCI</rasd:ResourceSubType>") ||
+		!strings.Contains(descriptor, "virtualbox-2.2") {
+		t.Error("VirtualBox descriptor should use the AHCI controller and virtualbox-2.2 hardware family")
+	}
+}
+
+func TestOVFManifest(t *testing.T) {
+	diskPath := filepath.Join(t.TempDir(), "disk.vmdk")
+
+	err := os.WriteFile(diskPath, []byte("abc"), testSecureFilePerms)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err := ovfManifest([]tarEntry{
+		{Name: "vm.ovf", Content: []byte("")},
+		{Name: "vm-disk1.vmdk", Path: diskPath},
+	})
+	if err != nil {
+		t.Fatalf("ovfManifest() error = %v", err)
+	}
+
+	want := "SHA256(vm.ovf)= e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855\n" +
+		"SHA256(vm-disk1.vmdk)= ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad\n"
+	if manifest != want {
+		t.Errorf("ovfManifest() = %q, want %q", manifest, want)
+	}
+}
+
+func TestVagrantfile(t *testing.T) {
+	hw := virtualHardware{CPUs: 2, MemoryMiB: 1024, NetworkAdapter: nicE1000e, Firmware: firmwareEFI}
+
+	libvirt := vagrantfile(packagingVagrantLibvirt, hw)
+	for _, want := range []string{"provider :libvirt", "libvirt.cpus = 2", "libvirt.memory = 1024", `libvirt.nic_model_type = "e1000e"`} {
+		if !strings.Contains(libvirt, want) {
+			t.Errorf("libvirt Vagrantfile missing %s:\n%s", want, libvirt)
+		}
+	}
+
+	virtualBox := vagrantfile(packagingVagrantVirtualBox, hw)
+	for _, want := range []string{"provider :virtualbox", "vb.memory = 1024", `"--firmware", "efi"`} {
+		if !strings.Contains(virtualBox, want) {
+			t.Errorf("VirtualBox Vagrantfile missing %s:\n%s", want, virtualBox)
+		}
+	}
+
+	if !strings.HasPrefix(libvirt, `Vagrant.configure("2") do |config|`) || !strings.HasSuffix(virtualBox, "  end\nend\n") {
+		t.Error("Vagrantfile should wrap the provider block in Vagrant.configure")
+	}
+}
+
+func TestValidateVirtualHardware(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    ImageResourceModel
+		wantErr string
+		x86Only bool
+	}{
+		{"none", ImageResourceModel{}, "", false},
+		{"cpus", ImageResourceModel{VirtualHardware: &VirtualHardwareModel{CPUs: types.Int64Value(0)}}, "Invalid CPU count", false},
+		{"memory", ImageResourceModel{VirtualHardware: &VirtualHardwareModel{Memory: types.Int64Value(-1)}}, "Invalid memory size", false},
+		{"virtualbox_nic", ImageResourceModel{
+			Packaging:       packagingList(packagingVagrantVirtualBox),
+			VirtualHardware: &VirtualHardwareModel{NetworkAdapter: types.StringValue(nicVMXNet3)},
+		}, "Conflicting virtual hardware options", false},
+		{"ova_nic", ImageResourceModel{
+			Packaging:       packagingList(packagingOVA),
+			VirtualHardware: &VirtualHardwareModel{NetworkAdapter: types.StringValue(nicVMXNet3)},
+		}, "", false},
+		{"bios_uefi_only", ImageResourceModel{
+			BIOSBoot:        types.BoolValue(false),
+			VirtualHardware: &VirtualHardwareModel{Firmware: types.StringValue(firmwareBIOS)},
+		}, "Conflicting virtual hardware options", false},
+		{"bios", ImageResourceModel{
+			VirtualHardware: &VirtualHardwareModel{Firmware: types.StringValue(firmwareBIOS)},
+		}, "", true},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			if testCase.x86Only && hostArchitecture() != "x86_64" {
+				t.Skip("legacy BIOS boot requires an x86_64 build host")
+			}
+
+			diags := validateVirtualHardware(&testCase.data)
+
+			if testCase.wantErr == "" {
+				if diags.HasError() {
+					t.Errorf("unexpected diagnostics: %v", diags)
+				}
+
+				return
+			}
+
+			if !diags.HasError() || diags.Errors()[0].Summary() != testCase.wantErr {
+				t.Errorf("diagnostics = %v, want %q", diags, testCase.wantErr)
+			}
+		})
+	}
+}
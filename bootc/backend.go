@@ -0,0 +1,146 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Storage backend flags of bootc install in the vendored bootc-lib.
+// --insecure is only accepted together with --composefs-backend.
+const (
+	staterootFlag        = "--stateroot"
+	composefsBackendFlag = "--composefs-backend"
+	composefsNoVerity    = "--insecure"
+)
+
+var (
+	// staterootPattern matches the ostree os names bootc accepts as a
+	// stateroot.
+	staterootPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+	// verityFilesystems are the root filesystems with fs-verity support, as
+	// required by a composefs repository unless verity is optional.
+	verityFilesystems = []string{"ext4", "btrfs"}
+)
+
+// backendArgs returns the bootc install arguments selecting the stateroot
+// and the storage backend.
+func (m *ImageResourceModel) backendArgs() []string {
+	var args []string
+
+	if !m.Stateroot.IsNull() {
+		args = append(args, staterootFlag, m.Stateroot.ValueString())
+	}
+
+	if m.ComposefsBackend.ValueBool() {
+		args = append(args, composefsBackendFlag)
+
+		if !m.EnforceFSVerity.IsNull() && !m.EnforceFSVerity.ValueBool() {
+			args = append(args, composefsNoVerity)
+		}
+	}
+
+	return args
+}
+
+// deploymentOptions returns the configured options that modify the ostree
+// deployment after bootc install.
+func (m *ImageResourceModel) deploymentOptions() []string {
+	var options []string
+
+	for _, option := range []struct {
+		name string
+		set  bool
+	}{
+		{"files", len(m.Files) > 0},
+		{"systemd_units", len(m.SystemdUnits) > 0},
+		{"network", len(m.Network) > 0},
+		{"host_registry_auth", !m.HostRegistryAuth.IsNull()},
+		{"auto_update", m.AutoUpdate != nil},
+		{"bound_images", len(m.BoundImages) > 0},
+		{"kargs_remove", len(m.KargsRemove.Elements()) > 0},
+		{"partition_layout", m.PartitionLayout != nil},
+		{"filesystem_options", m.FilesystemOptions != nil},
+		{"block_setup", m.BlockSetup.ValueString() == blockSetupTPM2LUKS || m.BlockSetup.ValueString() == blockSetupLUKSPassphrase},
+		{"reproducible", m.Reproducible != nil},
+	} {
+		if option.set {
+			options = append(options, option.name)
+		}
+	}
+
+	return options
+}
+
+// validateInstallBackend checks stateroot, composefs_backend and
+// enforce_fs_verity against the flags bootc-lib accepts and the options
+// that depend on an ostree deployment.
+func validateInstallBackend(data *ImageResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if knownString(data.Stateroot) && !staterootPattern.MatchString(data.Stateroot.ValueString()) {
+		diags.AddAttributeError(path.Root("stateroot"), "Invalid stateroot",
+			"Expected an os name of letters, digits, _, . or -, got: "+data.Stateroot.ValueString())
+	}
+
+	if !data.ComposefsBackend.ValueBool() {
+		if !data.EnforceFSVerity.IsNull() {
+			diags.AddAttributeError(path.Root("enforce_fs_verity"), "Invalid composefs option",
+				"enforce_fs_verity requires composefs_backend = true.")
+		}
+
+		return diags
+	}
+
+	if options := data.deploymentOptions(); len(options) > 0 {
+		diags.AddAttributeError(path.Root("composefs_backend"), "Conflicting composefs options",
+			"The composefs backend has no ostree deployment for the provider to modify after the install. Remove: "+
+				strings.Join(options, ", "))
+	}
+
+	if !data.EnforceFSVerity.IsNull() && !data.EnforceFSVerity.ValueBool() {
+		return diags
+	}
+
+	// Without filesystem or root_fs_type the image's install configuration
+	// picks the filesystem, which is only known to bootc.
+	for _, value := range []types.String{data.Filesystem, installConfigRootFSType(data.InstallConfig)} {
+		if knownString(value) && !slices.Contains(verityFilesystems, value.ValueString()) {
+			diags.AddAttributeError(path.Root("composefs_backend"), "Invalid composefs option",
+				value.ValueString()+" does not support fs-verity. Use ext4 or btrfs, or set enforce_fs_verity = false.")
+		}
+	}
+
+	return diags
+}
+
+// installConfigRootFSType returns install_config.root_fs_type, or null
+// without an install_config block.
+func installConfigRootFSType(model *InstallConfigModel) types.String {
+	if model == nil {
+		return types.StringNull()
+	}
+
+	return model.RootFSType
+}
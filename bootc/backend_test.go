@@ -0,0 +1,115 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestImageResourceModel_BackendArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		data ImageResourceModel
+		want []string
+	}{
+		{"none", ImageResourceModel{}, nil},
+		{"stateroot", ImageResourceModel{Stateroot: types.StringValue("fedora")}, []string{"--stateroot", "fedora"}},
+		{"composefs", ImageResourceModel{ComposefsBackend: types.BoolValue(true)}, []string{"--composefs-backend"}},
+		{"composefs_verity", ImageResourceModel{
+			ComposefsBackend: types.BoolValue(true),
+			EnforceFSVerity:  types.BoolValue(true),
+		}, []string{"--composefs-backend"}},
+		{"composefs_no_verity", ImageResourceModel{
+			ComposefsBackend: types.BoolValue(true),
+			EnforceFSVerity:  types.BoolValue(false),
+		}, []string{"--composefs-backend", "--insecure"}},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := testCase.data.backendArgs(); !slices.Equal(got, testCase.want) {
+				t.Errorf("backendArgs = %q, want %q", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestValidateInstallBackend(t *testing.T) {
+	composefs := types.BoolValue(true)
+
+	tests := []struct {
+		name    string
+		data    ImageResourceModel
+		wantErr string
+	}{
+		{"none", ImageResourceModel{}, ""},
+		{"stateroot", ImageResourceModel{Stateroot: types.StringValue("fedora-iot")}, ""},
+		{"invalid_stateroot", ImageResourceModel{Stateroot: types.StringValue("os/1")}, "Invalid stateroot"},
+		{"composefs", ImageResourceModel{ComposefsBackend: composefs, Filesystem: types.StringValue("btrfs")}, ""},
+		{"composefs_image_filesystem", ImageResourceModel{ComposefsBackend: composefs}, ""},
+		{"verity_without_composefs", ImageResourceModel{EnforceFSVerity: types.BoolValue(false)}, "Invalid composefs option"},
+		{"verity_on_xfs", ImageResourceModel{
+			ComposefsBackend: composefs,
+			InstallConfig:    &InstallConfigModel{RootFSType: types.StringValue("xfs")},
+		}, "Invalid composefs option"},
+		{"no_verity_on_xfs", ImageResourceModel{
+			ComposefsBackend: composefs,
+			EnforceFSVerity:  types.BoolValue(false),
+			Filesystem:       types.StringValue("xfs"),
+		}, ""},
+		{"deployment_options", ImageResourceModel{
+			ComposefsBackend: composefs,
+			Files:            []FileModel{{Path: types.StringValue("/etc/motd")}},
+		}, "Conflicting composefs options"},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			diags := validateInstallBackend(&testCase.data)
+
+			if testCase.wantErr == "" {
+				if diags.HasError() {
+					t.Errorf("unexpected diagnostics: %v", diags)
+				}
+
+				return
+			}
+
+			if !diags.HasError() || diags.Errors()[0].Summary() != testCase.wantErr {
+				t.Errorf("diagnostics = %v, want %q", diags, testCase.wantErr)
+			}
+		})
+	}
+}
+
+func TestImageResourceModel_DeploymentOptions(t *testing.T) {
+	data := ImageResourceModel{
+		BoundImages:  []BoundImageModel{{Image: types.StringValue("quay.io/example/app:1.4")}},
+		BlockSetup:   types.StringValue(blockSetupDirect),
+		Reproducible: &ReproducibleModel{},
+	}
+
+	if got := strings.Join(data.deploymentOptions(), ","); got != "bound_images,reproducible" {
+		t.Errorf("deploymentOptions = %q", got)
+	}
+}
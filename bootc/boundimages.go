@@ -0,0 +1,243 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	pullPolicyAlways  = "always"
+	pullPolicyMissing = "missing"
+	pullPolicyNewer   = "newer"
+	pullPolicyNever   = "never"
+
+	// containerStoragePath is the system container storage bootc install
+	// pulls logically bound images into.
+	containerStoragePath   = "/var/lib/containers/storage"
+	containerStorageDriver = "overlay"
+	selinuxConfigPath      = "/etc/selinux/config"
+)
+
+var ErrPodmanOutput = errors.New("unexpected podman output")
+
+// BoundImageModel is a bound_images entry of bootc_image.
+type BoundImageModel struct {
+	Image      types.String `tfsdk:"image"`
+	PullPolicy types.String `tfsdk:"pull_policy"`
+	AuthFile   types.String `tfsdk:"auth_file"`
+	Digest     types.String `tfsdk:"digest"`
+}
+
+// boundImage is a container image preloaded into the installed system's
+// container storage.
+type boundImage struct {
+	Image      string
+	PullPolicy string
+	AuthFile   string
+}
+
+// boundImages converts the bound_images blocks, defaulting the pull policy
+// to missing.
+func boundImages(models []BoundImageModel) []boundImage {
+	images := make([]boundImage, 0, len(models))
+
+	for _, model := range models {
+		image := boundImage{
+			Image:      model.Image.ValueString(),
+			PullPolicy: model.PullPolicy.ValueString(),
+			AuthFile:   model.AuthFile.ValueString(),
+		}
+
+		if image.PullPolicy == "" {
+			image.PullPolicy = pullPolicyMissing
+		}
+
+		images = append(images, image)
+	}
+
+	return images
+}
+
+// PullArgs returns the podman arguments that pull the image into the build
+// host's storage.
+func (i boundImage) PullArgs() []string {
+	args := []string{"pull", "--quiet", "--policy", i.PullPolicy}
+	if i.AuthFile != "" {
+		args = append(args, "--authfile", i.AuthFile)
+	}
+
+	return append(args, i.Image)
+}
+
+// storageReference returns the containers-storage transport reference of
+// image in the storage rooted at root, with a private run root.
+func storageReference(root, runRoot, image string) string {
+	return fmt.Sprintf("containers-storage:[%s@%s+%s]%s", containerStorageDriver, root, runRoot, image)
+}
+
+// pulledImage is a bound image in the build host's storage.
+type pulledImage struct {
+	ID     string
+	Digest string
+}
+
+// pullBoundImage pulls image on the build host according to its pull
+// policy and returns its storage ID and manifest digest.
+func pullBoundImage(ctx context.Context, image boundImage) (pulledImage, error) {
+	_, err := runCommand(ctx, "podman", image.PullArgs()...)
+	if err != nil {
+		return pulledImage{}, err
+	}
+
+	out, err := runCommand(ctx, "podman", "image", "inspect", "--format", "{{.Id}} {{.Digest}}", image.Image)
+	if err != nil {
+		return pulledImage{}, err
+	}
+
+	id, digest, ok := strings.Cut(strings.TrimSpace(string(out)), " ")
+	if !ok || id == "" || digest == "" {
+		return pulledImage{}, fmt.Errorf("%w: image inspect %s: %q", ErrPodmanOutput, image.Image, out)
+	}
+
+	return pulledImage{ID: id, Digest: digest}, nil
+}
+
+// preloadBoundImages pulls images on the build host and copies them into
+// the container storage of the deployment on rawPath, so they run without
+// network on first boot. It returns the manifest digest of each image.
+func preloadBoundImages(ctx context.Context, rawPath string, images []boundImage) ([]string, error) {
+	pulled := make([]pulledImage, 0, len(images))
+
+	for _, image := range images {
+		result, err := pullBoundImage(ctx, image)
+		if err != nil {
+			return nil, err
+		}
+
+		pulled = append(pulled, result)
+	}
+
+	err := customizeDeployment(ctx, rawPath, func(deployment installedDeployment) error {
+		storage := deploymentVarPath(deployment, containerStoragePath)
+
+		err := mkdirAllLabeled(storage)
+		if err != nil {
+			return err
+		}
+
+		runRoot, err := os.MkdirTemp("", "bootc-storage-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(runRoot)
+
+		for idx, image := range images {
+			_, err = runCommand(ctx, "skopeo", "copy", "--quiet", "--preserve-digests",
+				"containers-storage:"+pulled[idx].ID, storageReference(storage, runRoot, image.Image))
+			if err != nil {
+				return fmt.Errorf("bound image %s: %w", image.Image, err)
+			}
+		}
+
+		return relabelVar(ctx, deployment, containerStoragePath)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	digests := make([]string, 0, len(pulled))
+	for _, result := range pulled {
+		digests = append(digests, result.Digest)
+	}
+
+	return digests, nil
+}
+
+// relabelVar applies the deployment's SELinux file contexts to target
+// below /var. Deployments without an SELinux policy are left unlabeled.
+func relabelVar(ctx context.Context, deployment installedDeployment, target string) error {
+	config, err := os.ReadFile(deployment.DeployPath(selinuxConfigPath))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	policy := parseShellVars(config)["SELINUXTYPE"]
+	if policy == "" {
+		return nil
+	}
+
+	fileContexts := deployment.DeployPath(filepath.Join("/etc/selinux", policy, "contexts", "files", "file_contexts"))
+
+	// The stateroot directory holds var, so it is the alternate root
+	// setfiles strips before looking up /var paths.
+	stateroot := filepath.Dir(deployment.Var)
+
+	_, err = runCommand(ctx, "setfiles", "-F", "-r", stateroot, fileContexts, deploymentVarPath(deployment, target))
+
+	return err
+}
+
+// validateBoundImages checks the bound_images blocks for invalid or
+// duplicate references.
+func validateBoundImages(data *ImageResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if len(data.BoundImages) > 0 && data.Reproducible != nil {
+		diags.AddAttributeError(path.Root("bound_images"), "Conflicting image options",
+			"bound_images cannot be reproducible: container storage records pull times and random layer mount IDs.")
+	}
+
+	seen := map[string]bool{}
+
+	for idx, model := range data.BoundImages {
+		if !knownString(model.Image) {
+			continue
+		}
+
+		imagePath := path.Root("bound_images").AtListIndex(idx).AtName("image")
+		image := model.Image.ValueString()
+
+		_, err := parseImageReference(image, nil)
+		if err != nil {
+			diags.AddAttributeError(imagePath, "Invalid image reference", err.Error())
+
+			continue
+		}
+
+		if seen[image] {
+			diags.AddAttributeError(imagePath, "Duplicate bound image", "Image is already listed: "+image)
+		}
+
+		seen[image] = true
+	}
+
+	return diags
+}
@@ -0,0 +1,127 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestBoundImages(t *testing.T) {
+	images := boundImages([]BoundImageModel{
+		{Image: types.StringValue("quay.io/example/app:1.4"), PullPolicy: types.StringNull(), AuthFile: types.StringNull()},
+		{
+			Image:      types.StringValue("registry.example.com/edge/agent:2"),
+			PullPolicy: types.StringValue(pullPolicyAlways),
+			AuthFile:   types.StringValue("/etc/containers/auth.json"),
+		},
+	})
+
+	want := []boundImage{
+		{Image: "quay.io/example/app:1.4", PullPolicy: pullPolicyMissing},
+		{Image: "registry.example.com/edge/agent:2", PullPolicy: pullPolicyAlways, AuthFile: "/etc/containers/auth.json"},
+	}
+	if !slices.Equal(images, want) {
+		t.Fatalf("boundImages = %+v, want %+v", images, want)
+	}
+
+	if got := images[0].PullArgs(); !slices.Equal(got, []string{"pull", "--quiet", "--policy", "missing", "quay.io/example/app:1.4"}) {
+		t.Errorf("PullArgs = %q", got)
+	}
+
+	wantArgs := []string{
+		"pull", "--quiet", "--policy", "always", "--authfile", "/etc/containers/auth.json",
+		"registry.example.com/edge/agent:2",
+	}
+	if got := images[1].PullArgs(); !slices.Equal(got, wantArgs) {
+		t.Errorf("PullArgs = %q, want %q", got, wantArgs)
+	}
+}
+
+func TestStorageReference(t *testing.T) {
+	got := storageReference("/mnt/var/lib/containers/storage", "/tmp/run", "quay.io/example/app:1.4")
+
+	want := "containers-storage:[overlay@/mnt/var/lib/containers/storage+/tmp/run]quay.io/example/app:1.4"
+	if got != want {
+		t.Errorf("storageReference = %q, want %q", got, want)
+	}
+}
+
+func TestRelabelVarWithoutPolicy(t *testing.T) {
+	dir := t.TempDir()
+	deployment := installedDeployment{
+		Dir: filepath.Join(dir, "deploy", "0123.0"),
+		Var: filepath.Join(dir, "var"),
+	}
+
+	// Without /etc/selinux/config nothing is relabeled and setfiles is
+	// never run.
+	err := relabelVar(t.Context(), deployment, containerStoragePath)
+	if err != nil {
+		t.Errorf("relabelVar: %v", err)
+	}
+}
+
+func TestValidateBoundImages(t *testing.T) {
+	image := func(ref string) BoundImageModel {
+		return BoundImageModel{Image: types.StringValue(ref)}
+	}
+
+	tests := []struct {
+		name    string
+		data    ImageResourceModel
+		wantErr string
+	}{
+		{"none", ImageResourceModel{}, ""},
+		{"valid", ImageResourceModel{BoundImages: []BoundImageModel{
+			image("quay.io/example/app:1.4"),
+			image("quay.io/example/app@sha256:1111111111111111111111111111111111111111111111111111111111111111"),
+		}}, ""},
+		{"unknown", ImageResourceModel{BoundImages: []BoundImageModel{{Image: types.StringUnknown()}}}, ""},
+		{"invalid", ImageResourceModel{BoundImages: []BoundImageModel{image("quay.io/example/")}}, "Invalid image reference"},
+		{"duplicate", ImageResourceModel{BoundImages: []BoundImageModel{
+			image("quay.io/example/app:1.4"),
+			image("quay.io/example/app:1.4"),
+		}}, "Duplicate bound image"},
+		{"reproducible", ImageResourceModel{
+			BoundImages:  []BoundImageModel{image("quay.io/example/app:1.4")},
+			Reproducible: &ReproducibleModel{Seed: types.StringValue("seed")},
+		}, "Conflicting image options"},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			diags := validateBoundImages(&testCase.data)
+
+			if testCase.wantErr == "" {
+				if diags.HasError() {
+					t.Errorf("unexpected diagnostics: %v", diags)
+				}
+
+				return
+			}
+
+			if !diags.HasError() || diags.Errors()[0].Summary() != testCase.wantErr {
+				t.Errorf("diagnostics = %v, want %q", diags, testCase.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,503 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+const (
+	// cidataLabel is the volume label cloud-init's NoCloud datasource
+	// looks for.
+	cidataLabel = "cidata"
+
+	isoSectorSize      = 2048
+	isoSystemAreaSize  = 16
+	isoDirRecordLen    = 33
+	isoPathTableRecLen = 10
+
+	fatSectorSize        = 512
+	fatReservedSectors   = 1
+	fatCount             = 2
+	fatRootEntries       = 16
+	fatDirEntrySize      = 32
+	fatLFNChars          = 13
+	fatSeedClusters      = 4000
+	fatMediaDescriptor   = 0xf8
+	fatAttrVolumeID      = 0x08
+	fatAttrArchive       = 0x20
+	fatAttrLongName      = 0x0f
+	fatLastLFNEntry      = 0x40
+	fat12EndOfChain      = 0xfff
+	fatBootSignatureByte = 0x29
+)
+
+var ErrSeedTooLarge = errors.New("seed content does not fit the image format")
+
+// cidataEpoch is the timestamp recorded in seed images so that identical
+// content always yields an identical image.
+var cidataEpoch = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// seedFile is a file in the root directory of a NoCloud seed image.
+type seedFile struct {
+	Name    string
+	Content []byte
+}
+
+// sortedSeedFiles returns files ordered by name, as both ISO 9660 and the
+// tests expect.
+func sortedSeedFiles(files []seedFile) []seedFile {
+	sorted := slices.Clone(files)
+	slices.SortFunc(sorted, func(a, b seedFile) int { return strings.Compare(a.Name, b.Name) })
+
+	return sorted
+}
+
+// writeISO9660 writes an ISO 9660 image with Joliet extensions holding
+// files in its root directory. Joliet keeps the lowercase, hyphenated
+// names cloud-init expects; the primary volume carries mangled 8.3 names.
+//
+// Layout: system area, primary and Joliet volume descriptors, terminator,
+// four path tables, the two root directories, then file data.
+func writeISO9660(w io.Writer, label string, files []seedFile) error {
+	files = sortedSeedFiles(files)
+
+	const (
+		pvdSector        = isoSystemAreaSize
+		jolietSector     = pvdSector + 1
+		terminatorSector = jolietSector + 1
+		pathTableSector  = terminatorSector + 1
+		rootSector       = pathTableSector + 4
+		jolietRootSector = rootSector + 1
+		dataSector       = jolietRootSector + 1
+	)
+
+	extents := make([]uint32, len(files))
+	next := uint32(dataSector)
+
+	for idx, file := range files {
+		extents[idx] = next
+		next += uint32(sectorsFor(len(file.Content), isoSectorSize))
+	}
+
+	primaryNames := make([][]byte, len(files))
+	jolietNames := make([][]byte, len(files))
+
+	for idx, file := range files {
+		primaryNames[idx] = []byte(isoPrimaryName(file.Name))
+		jolietNames[idx] = ucs2(file.Name + ";1")
+	}
+
+	primaryRoot, err := isoDirectory(rootSector, files, extents, primaryNames)
+	if err != nil {
+		return err
+	}
+
+	jolietRoot, err := isoDirectory(jolietRootSector, files, extents, jolietNames)
+	if err != nil {
+		return err
+	}
+
+	image := make([]byte, int(next)*isoSectorSize)
+
+	pvd := image[pvdSector*isoSectorSize:]
+	isoVolumeDescriptor(pvd, 1, next, pathTableSector, rootSector, []byte(strings.ToUpper(label)), []byte(" "))
+
+	svd := image[jolietSector*isoSectorSize:]
+	isoVolumeDescriptor(svd, 2, next, pathTableSector+2, jolietRootSector, ucs2(label), ucs2(" "))
+	// UCS-2 Level 3 escape sequence marks the Joliet descriptor.
+	copy(svd[88:], "%/E")
+
+	terminator := image[terminatorSector*isoSectorSize:]
+	terminator[0] = 0xff
+	copy(terminator[1:], "CD001")
+	terminator[6] = 1
+
+	for table, root := range []uint32{rootSector, jolietRootSector} {
+		isoPathTable(image[(pathTableSector+2*table)*isoSectorSize:], root, binary.LittleEndian)
+		isoPathTable(image[(pathTableSector+2*table+1)*isoSectorSize:], root, binary.BigEndian)
+	}
+
+	copy(image[rootSector*isoSectorSize:], primaryRoot)
+	copy(image[jolietRootSector*isoSectorSize:], jolietRoot)
+
+	for idx, file := range files {
+		copy(image[int(extents[idx])*isoSectorSize:], file.Content)
+	}
+
+	_, err = w.Write(image)
+
+	return err
+}
+
+// isoVolumeDescriptor fills a primary (type 1) or supplementary (type 2)
+// volume descriptor. Identifier fields are padded with pad.
+func isoVolumeDescriptor(desc []byte, kind byte, sectors, pathTable, rootSector uint32, volumeID, pad []byte) {
+	desc[0] = kind
+	copy(desc[1:], "CD001")
+	desc[6] = 1
+
+	padField := func(offset, length int, value []byte) {
+		field := desc[offset : offset+length]
+		for idx := 0; idx+len(pad) <= length; idx += len(pad) {
+			copy(field[idx:], pad)
+		}
+
+		copy(field, value)
+	}
+
+	padField(8, 32, nil)
+	padField(40, 32, volumeID)
+	putBothEndian32(desc[80:], sectors)
+	putBothEndian16(desc[120:], 1)
+	putBothEndian16(desc[124:], 1)
+	putBothEndian16(desc[128:], isoSectorSize)
+	putBothEndian32(desc[132:], isoPathTableRecLen)
+	binary.LittleEndian.PutUint32(desc[140:], pathTable)
+	binary.BigEndian.PutUint32(desc[148:], pathTable+1)
+	isoDirRecord(desc[156:], rootSector, isoSectorSize, true, []byte{0})
+
+	for _, field := range [][2]int{{190, 128}, {318, 128}, {446, 128}, {574, 128}, {702, 37}, {739, 37}, {776, 37}} {
+		padField(field[0], field[1], nil)
+	}
+
+	stamp := []byte(cidataEpoch.Format("20060102150405") + "00\x00")
+	copy(desc[813:], stamp)
+	copy(desc[830:], stamp)
+	copy(desc[847:], "0000000000000000\x00")
+	copy(desc[864:], stamp)
+	desc[881] = 1
+}
+
+// isoPathTable writes a path table holding only the root directory.
+func isoPathTable(table []byte, rootSector uint32, order binary.ByteOrder) {
+	table[0] = 1
+	order.PutUint32(table[2:], rootSector)
+	order.PutUint16(table[6:], 1)
+}
+
+// isoDirectory renders a single-sector root directory.
+func isoDirectory(sector uint32, files []seedFile, extents []uint32, names [][]byte) ([]byte, error) {
+	dir := make([]byte, isoSectorSize)
+
+	offset := isoDirRecord(dir, sector, isoSectorSize, true, []byte{0})
+	offset += isoDirRecord(dir[offset:], sector, isoSectorSize, true, []byte{1})
+
+	for idx, file := range files {
+		if offset+isoDirRecordLen+len(names[idx])+1 > len(dir) {
+			return nil, fmt.Errorf("%w: too many files for an ISO 9660 directory sector", ErrSeedTooLarge)
+		}
+
+		offset += isoDirRecord(dir[offset:], extents[idx], uint32(len(file.Content)), false, names[idx])
+	}
+
+	return dir, nil
+}
+
+// isoDirRecord writes a directory record and returns its length.
+func isoDirRecord(rec []byte, extent, size uint32, isDir bool, name []byte) int {
+	length := isoDirRecordLen + len(name)
+	if length%2 == 1 {
+		length++
+	}
+
+	rec[0] = byte(length)
+	putBothEndian32(rec[2:], extent)
+	putBothEndian32(rec[10:], size)
+	rec[18] = byte(cidataEpoch.Year() - 1900)
+	rec[19] = byte(cidataEpoch.Month())
+	rec[20] = byte(cidataEpoch.Day())
+
+	if isDir {
+		rec[25] = 0x02
+	}
+
+	putBothEndian16(rec[28:], 1)
+	rec[32] = byte(len(name))
+	copy(rec[33:], name)
+
+	return length
+}
+
+// isoPrimaryName maps a file name to ISO 9660 d-characters with a
+// version suffix (user-data → USER_DATA.;1).
+func isoPrimaryName(name string) string {
+	mapped := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+
+	if !strings.Contains(mapped, ".") {
+		mapped += "."
+	}
+
+	return mapped + ";1"
+}
+
+// writeVFAT writes a FAT12 image labelled label holding files in its root
+// directory under VFAT long names. serial becomes the volume serial.
+func writeVFAT(w io.Writer, label string, serial uint32, files []seedFile) error {
+	files = sortedSeedFiles(files)
+
+	sectorsPerCluster := 1
+
+	for ; ; sectorsPerCluster *= 2 {
+		if sectorsPerCluster > 64 {
+			return fmt.Errorf("%w: more than %d FAT12 clusters", ErrSeedTooLarge, fatSeedClusters)
+		}
+
+		if fatClustersFor(files, sectorsPerCluster*fatSectorSize) <= fatSeedClusters {
+			break
+		}
+	}
+
+	clusterSize := sectorsPerCluster * fatSectorSize
+	clusters := fatSeedClusters
+	fatSectors := sectorsFor((clusters+2)*3/2+1, fatSectorSize)
+	rootSectors := fatRootEntries * fatDirEntrySize / fatSectorSize
+	dataStart := fatReservedSectors + fatCount*fatSectors + rootSectors
+	totalSectors := dataStart + clusters*sectorsPerCluster
+
+	image := make([]byte, totalSectors*fatSectorSize)
+
+	boot := image[:fatSectorSize]
+	copy(boot, []byte{0xeb, 0x3c, 0x90})
+	copy(boot[3:], "mkfs.fat")
+	binary.LittleEndian.PutUint16(boot[11:], fatSectorSize)
+	boot[13] = byte(sectorsPerCluster)
+	binary.LittleEndian.PutUint16(boot[14:], fatReservedSectors)
+	boot[16] = fatCount
+	binary.LittleEndian.PutUint16(boot[17:], fatRootEntries)
+
+	if totalSectors < 1<<16 {
+		binary.LittleEndian.PutUint16(boot[19:], uint16(totalSectors))
+	} else {
+		binary.LittleEndian.PutUint32(boot[32:], uint32(totalSectors))
+	}
+
+	boot[21] = fatMediaDescriptor
+	binary.LittleEndian.PutUint16(boot[22:], uint16(fatSectors))
+	binary.LittleEndian.PutUint16(boot[24:], 32)
+	binary.LittleEndian.PutUint16(boot[26:], 64)
+	boot[36] = 0x80
+	boot[38] = fatBootSignatureByte
+	binary.LittleEndian.PutUint32(boot[fat16SerialOffset:], serial)
+	copy(boot[43:54], fatName(strings.ToUpper(label)))
+	copy(boot[54:62], "FAT12   ")
+	boot[510] = 0x55
+	boot[511] = 0xaa
+
+	fat := make([]byte, fatSectors*fatSectorSize)
+	setFAT12(fat, 0, 0xf00|fatMediaDescriptor)
+	setFAT12(fat, 1, fat12EndOfChain)
+
+	root := image[(fatReservedSectors+fatCount*fatSectors)*fatSectorSize:]
+	copy(root, fatName(strings.ToUpper(label)))
+	root[11] = fatAttrVolumeID
+	putFATTimestamp(root[22:])
+
+	entry := 1
+	cluster := 2
+
+	for idx, file := range files {
+		shortName := fatName(fmt.Sprintf("%.6s~%d", strings.ToUpper(strings.ReplaceAll(file.Name, ".", "")), idx+1))
+		lfn := fatLongNameEntries(file.Name, shortName)
+
+		if entry+len(lfn)+1 > fatRootEntries {
+			return fmt.Errorf("%w: too many files for the FAT12 root directory", ErrSeedTooLarge)
+		}
+
+		for _, rec := range lfn {
+			copy(root[entry*fatDirEntrySize:], rec)
+			entry++
+		}
+
+		rec := root[entry*fatDirEntrySize : (entry+1)*fatDirEntrySize]
+		entry++
+
+		copy(rec, shortName)
+		rec[11] = fatAttrArchive
+		putFATTimestamp(rec[14:])
+		copy(rec[18:20], rec[16:18])
+		putFATTimestamp(rec[22:])
+		binary.LittleEndian.PutUint32(rec[28:], uint32(len(file.Content)))
+
+		count := sectorsFor(len(file.Content), clusterSize)
+		if count == 0 {
+			continue
+		}
+
+		binary.LittleEndian.PutUint16(rec[26:], uint16(cluster))
+		copy(image[(dataStart+(cluster-2)*sectorsPerCluster)*fatSectorSize:], file.Content)
+
+		for n := range count {
+			value := cluster + n + 1
+			if n == count-1 {
+				value = fat12EndOfChain
+			}
+
+			setFAT12(fat, cluster+n, value)
+		}
+
+		cluster += count
+	}
+
+	for copyIdx := range fatCount {
+		copy(image[(fatReservedSectors+copyIdx*fatSectors)*fatSectorSize:], fat)
+	}
+
+	_, err := w.Write(image)
+
+	return err
+}
+
+// fatClustersFor returns the clusters needed to store files.
+func fatClustersFor(files []seedFile, clusterSize int) int {
+	total := 0
+	for _, file := range files {
+		total += sectorsFor(len(file.Content), clusterSize)
+	}
+
+	return total
+}
+
+// setFAT12 stores a 12-bit FAT entry.
+func setFAT12(fat []byte, cluster, value int) {
+	offset := cluster * 3 / 2
+
+	if cluster%2 == 0 {
+		fat[offset] = byte(value)
+		fat[offset+1] = fat[offset+1]&0xf0 | byte(value>>8)&0x0f
+	} else {
+		fat[offset] = fat[offset]&0x0f | byte(value<<4)
+		fat[offset+1] = byte(value >> 4)
+	}
+}
+
+// fatName pads a name to the 11-byte directory entry form.
+func fatName(name string) []byte {
+	field := []byte("           ")
+	copy(field, name)
+
+	return field
+}
+
+// fatLongNameEntries returns the VFAT long name entries for name in the
+// order they precede the short entry on disk.
+func fatLongNameEntries(name string, shortName []byte) [][]byte {
+	var checksum byte
+	for _, c := range shortName {
+		checksum = (checksum&1)<<7 + checksum>>1 + c
+	}
+
+	chars := utf16.Encode([]rune(name))
+	count := sectorsFor(len(chars), fatLFNChars)
+
+	padded := make([]uint16, count*fatLFNChars)
+	for idx := range padded {
+		switch {
+		case idx < len(chars):
+			padded[idx] = chars[idx]
+		case idx == len(chars):
+			padded[idx] = 0
+		default:
+			padded[idx] = 0xffff
+		}
+	}
+
+	entries := make([][]byte, 0, count)
+
+	for seq := count; seq >= 1; seq-- {
+		rec := make([]byte, fatDirEntrySize)
+
+		rec[0] = byte(seq)
+		if seq == count {
+			rec[0] |= fatLastLFNEntry
+		}
+
+		rec[11] = fatAttrLongName
+		rec[13] = checksum
+
+		part := padded[(seq-1)*fatLFNChars : seq*fatLFNChars]
+		for idx, char := range part {
+			var offset int
+
+			switch {
+			case idx < 5:
+				offset = 1 + idx*2
+			case idx < 11:
+				offset = 14 + (idx-5)*2
+			default:
+				offset = 28 + (idx-11)*2
+			}
+
+			binary.LittleEndian.PutUint16(rec[offset:], char)
+		}
+
+		entries = append(entries, rec)
+	}
+
+	return entries
+}
+
+// putFATTimestamp writes cidataEpoch as a FAT time and date pair.
+func putFATTimestamp(field []byte) {
+	hour, minute, sec := cidataEpoch.Clock()
+	binary.LittleEndian.PutUint16(field, uint16(hour<<11|minute<<5|sec/2))
+	binary.LittleEndian.PutUint16(field[2:],
+		uint16((cidataEpoch.Year()-1980)<<9|int(cidataEpoch.Month())<<5|cidataEpoch.Day()))
+}
+
+// sectorsFor returns the number of size-byte units needed for n bytes.
+func sectorsFor(n, size int) int {
+	return (n + size - 1) / size
+}
+
+func putBothEndian16(field []byte, value uint16) {
+	binary.LittleEndian.PutUint16(field, value)
+	binary.BigEndian.PutUint16(field[2:], value)
+}
+
+func putBothEndian32(field []byte, value uint32) {
+	binary.LittleEndian.PutUint32(field, value)
+	binary.BigEndian.PutUint32(field[4:], value)
+}
+
+// ucs2 encodes s as big-endian UCS-2 for Joliet.
+func ucs2(s string) []byte {
+	chars := utf16.Encode([]rune(s))
+	out := make([]byte, 2*len(chars))
+
+	for idx, char := range chars {
+		binary.BigEndian.PutUint16(out[2*idx:], char)
+	}
+
+	return out
+}
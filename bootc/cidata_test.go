@@ -0,0 +1,261 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+var testSeedFiles = []seedFile{
+	{Name: "user-data", Content: []byte("#cloud-config\nhostname: node1\n")},
+	{Name: "meta-data", Content: []byte("instance-id: node1\n")},
+	{Name: "network-config", Content: bytes.Repeat([]byte("# padding\n"), 500)},
+	{Name: "vendor-data", Content: nil},
+}
+
+func testSeedContents() map[string]string {
+	want := map[string]string{}
+	for _, file := range testSeedFiles {
+		want[file.Name] = string(file.Content)
+	}
+
+	return want
+}
+
+// readJolietRoot returns the files of the Joliet root directory.
+func readJolietRoot(t *testing.T, image []byte) map[string]string {
+	t.Helper()
+
+	svd := image[17*isoSectorSize:]
+	if svd[0] != 2 || string(svd[1:6]) != "CD001" || string(svd[88:91]) != "%/E" {
+		t.Fatal("sector 17 is not a Joliet descriptor")
+	}
+
+	rootExtent := binary.LittleEndian.Uint32(svd[156+2:])
+	dir := image[int(rootExtent)*isoSectorSize:][:isoSectorSize]
+
+	files := map[string]string{}
+
+	for offset := 0; dir[offset] != 0; offset += int(dir[offset]) {
+		rec := dir[offset:]
+		if rec[25]&0x02 != 0 {
+			continue
+		}
+
+		nameBytes := rec[33 : 33+int(rec[32])]
+		chars := make([]uint16, len(nameBytes)/2)
+
+		for idx := range chars {
+			chars[idx] = binary.BigEndian.Uint16(nameBytes[2*idx:])
+		}
+
+		extent := binary.LittleEndian.Uint32(rec[2:])
+		size := binary.LittleEndian.Uint32(rec[10:])
+		name := strings.TrimSuffix(string(utf16.Decode(chars)), ";1")
+		files[name] = string(image[int(extent)*isoSectorSize:][:size])
+	}
+
+	return files
+}
+
+// readFATRoot returns the long-named files of a FAT12 root directory.
+func readFATRoot(t *testing.T, image []byte) map[string]string {
+	t.Helper()
+
+	boot := image[:fatSectorSize]
+	if boot[510] != 0x55 || boot[511] != 0xaa {
+		t.Fatal("missing boot signature")
+	}
+
+	sectorsPerCluster := int(boot[13])
+	fatSectors := int(binary.LittleEndian.Uint16(boot[22:]))
+	rootEntries := int(binary.LittleEndian.Uint16(boot[17:]))
+	fat := image[fatReservedSectors*fatSectorSize:]
+	rootStart := (fatReservedSectors + fatCount*fatSectors) * fatSectorSize
+	dataStart := rootStart + rootEntries*fatDirEntrySize
+
+	getFAT12 := func(cluster int) int {
+		value := int(binary.LittleEndian.Uint16(fat[cluster*3/2:]))
+		if cluster%2 == 1 {
+			return value >> 4
+		}
+
+		return value & 0xfff
+	}
+
+	files := map[string]string{}
+
+	var longName []uint16
+
+	for entry := range rootEntries {
+		rec := image[rootStart+entry*fatDirEntrySize:][:fatDirEntrySize]
+
+		switch {
+		case rec[0] == 0:
+			return files
+		case rec[11] == fatAttrLongName:
+			var part []uint16
+			for _, offset := range []int{1, 3, 5, 7, 9, 14, 16, 18, 20, 22, 24, 28, 30} {
+				char := binary.LittleEndian.Uint16(rec[offset:])
+				if char == 0 || char == 0xffff {
+					break
+				}
+
+				part = append(part, char)
+			}
+
+			longName = append(part, longName...)
+		case rec[11]&fatAttrVolumeID != 0:
+			continue
+		default:
+			size := int(binary.LittleEndian.Uint32(rec[28:]))
+
+			var content []byte
+			for cluster := int(binary.LittleEndian.Uint16(rec[26:])); cluster >= 2 && cluster < 0xff8; cluster = getFAT12(cluster) {
+				start := dataStart + (cluster-2)*sectorsPerCluster*fatSectorSize
+				content = append(content, image[start:start+sectorsPerCluster*fatSectorSize]...)
+			}
+
+			files[string(utf16.Decode(longName))] = string(content[:size])
+			longName = nil
+		}
+	}
+
+	return files
+}
+
+func TestWriteISO9660(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeISO9660(&buf, cidataLabel, testSeedFiles); err != nil {
+		t.Fatal(err)
+	}
+
+	image := buf.Bytes()
+
+	if got := strings.TrimRight(string(image[16*isoSectorSize+40:][:32]), " "); got != "CIDATA" {
+		t.Errorf("primary volume ID = %q", got)
+	}
+
+	if got := readJolietRoot(t, image); !maps.Equal(got, testSeedContents()) {
+		t.Errorf("Joliet files = %v", got)
+	}
+
+	var again bytes.Buffer
+	if err := writeISO9660(&again, cidataLabel, testSeedFiles); err != nil || !bytes.Equal(image, again.Bytes()) {
+		t.Error("ISO 9660 output is not deterministic")
+	}
+}
+
+func TestWriteVFAT(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeVFAT(&buf, cidataLabel, 0x12345678, testSeedFiles); err != nil {
+		t.Fatal(err)
+	}
+
+	image := buf.Bytes()
+
+	if got := string(image[43:54]); got != "CIDATA     " {
+		t.Errorf("boot sector label = %q", got)
+	}
+
+	if got := binary.LittleEndian.Uint32(image[fat16SerialOffset:]); got != 0x12345678 {
+		t.Errorf("serial = %08x", got)
+	}
+
+	if got := readFATRoot(t, image); !maps.Equal(got, testSeedContents()) {
+		t.Errorf("FAT files = %v", got)
+	}
+
+	large := []seedFile{{Name: "user-data", Content: make([]byte, 4*1024*1024)}}
+
+	buf.Reset()
+
+	if err := writeVFAT(&buf, cidataLabel, 0, large); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readFATRoot(t, buf.Bytes()); len(got["user-data"]) != len(large[0].Content) {
+		t.Errorf("large user-data = %d bytes", len(got["user-data"]))
+	}
+}
+
+func TestFATLongNameEntries(t *testing.T) {
+	entries := fatLongNameEntries("network-config", fatName("NETWOR~1"))
+
+	if len(entries) != 2 {
+		t.Fatalf("entries = %d, want 2", len(entries))
+	}
+
+	if entries[0][0] != fatLastLFNEntry|2 || entries[1][0] != 1 {
+		t.Errorf("sequence = %#x %#x", entries[0][0], entries[1][0])
+	}
+
+	if entries[0][13] != entries[1][13] {
+		t.Error("checksum differs between entries")
+	}
+}
+
+func TestSeedImage_Blkid(t *testing.T) {
+	requireCmd(t, "blkid")
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{seedFormatISO9660, "TYPE=iso9660 LABEL=cidata"},
+		{seedFormatVFAT, "TYPE=vfat LABEL=CIDATA"},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.format, func(t *testing.T) {
+			target := filepath.Join(t.TempDir(), defaultSeedFilename(testCase.format))
+			if err := writeSeedImage(target, testCase.format, testSeedFiles); err != nil {
+				t.Fatal(err)
+			}
+
+			out, err := exec.CommandContext(t.Context(), "blkid", "-p", "-o", "export", target).Output()
+			if err != nil {
+				t.Fatalf("blkid: %v", err)
+			}
+
+			fields := map[string]string{}
+
+			for line := range strings.Lines(string(out)) {
+				key, value, _ := strings.Cut(strings.TrimSpace(line), "=")
+				fields[key] = value
+			}
+
+			got := "TYPE=" + fields["TYPE"] + " LABEL=" + fields["LABEL"]
+			if got != testCase.want {
+				t.Errorf("blkid = %q, want %q", got, testCase.want)
+			}
+
+			if _, err := os.Stat(target); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,317 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ datasource.DataSource = &ContainerImageDataSource{}
+
+const (
+	transportRegistry         = "registry"
+	transportContainerStorage = "containers-storage"
+	transportOCI              = "oci"
+
+	labelBootc       = "containers.bootc"
+	labelOstreeLinux = "ostree.linux"
+)
+
+// ContainerImageDataSource implements the bootc_container_image data source.
+type ContainerImageDataSource struct{}
+
+type ContainerImageDataSourceModel struct {
+	Labels        types.Map    `tfsdk:"labels"`
+	OSRelease     types.Map    `tfsdk:"os_release"`
+	Image         types.String `tfsdk:"image"`
+	Transport     types.String `tfsdk:"transport"`
+	Digest        types.String `tfsdk:"digest"`
+	Architecture  types.String `tfsdk:"architecture"`
+	OS            types.String `tfsdk:"os"`
+	KernelVersion types.String `tfsdk:"kernel_version"`
+	Created       types.String `tfsdk:"created"`
+	Size          types.Int64  `tfsdk:"size"`
+	RequireBootc  types.Bool   `tfsdk:"require_bootc"`
+	Bootc         types.Bool   `tfsdk:"bootc"`
+}
+
+// imageInspect is the subset of `skopeo inspect` output used by the data source.
+//
+//nolint:tagliatelle // field names follow skopeo's JSON output
+type imageInspect struct {
+	Created      *time.Time        `json:"Created"`
+	Labels       map[string]string `json:"Labels"`
+	Digest       string            `json:"Digest"`
+	Architecture string            `json:"Architecture"`
+	Os           string            `json:"Os"`
+	LayersData   []struct {
+		Size int64 `json:"Size"`
+	} `json:"LayersData"`
+}
+
+func NewContainerImageDataSource() datasource.DataSource {
+	return &ContainerImageDataSource{}
+}
+
+func (*ContainerImageDataSource) Metadata(
+	_ context.Context,
+	req datasource.MetadataRequest,
+	resp *datasource.MetadataResponse,
+) {
+	resp.TypeName = req.ProviderTypeName + "_container_image"
+}
+
+func (*ContainerImageDataSource) Schema(
+	_ context.Context,
+	_ datasource.SchemaRequest,
+	resp *datasource.SchemaResponse,
+) {
+	resp.Schema = schema.Schema{
+		Description: "Inspects a bootc container image in a registry, local container storage or an OCI layout.",
+		Attributes: map[string]schema.Attribute{
+			"image": schema.StringAttribute{
+				Description: "Image reference (e.g. quay.io/fedora/fedora-bootc:42), or a path[:tag] for the oci transport.",
+				Required:    true,
+			},
+			"transport": schema.StringAttribute{
+				Description: "Where to look for the image: registry, containers-storage, or oci. Defaults to registry.",
+				Optional:    true,
+				Validators: []validator.String{
+					stringOneOf(transportRegistry, transportContainerStorage, transportOCI),
+				},
+			},
+			"require_bootc": schema.BoolAttribute{
+				Description: "Fail when the image does not carry the containers.bootc=1 label. Defaults to true.",
+				Optional:    true,
+			},
+			"digest": schema.StringAttribute{
+				Description: "Manifest digest of the image.",
+				Computed:    true,
+			},
+			"architecture": schema.StringAttribute{
+				Description: "CPU architecture of the image (e.g. amd64, arm64).",
+				Computed:    true,
+			},
+			"os": schema.StringAttribute{
+				Description: "Operating system of the image (e.g. linux).",
+				Computed:    true,
+			},
+			"labels": schema.MapAttribute{
+				Description: "Image labels.",
+				Computed:    true,
+				ElementType: types.StringType,
+			},
+			"bootc": schema.BoolAttribute{
+				Description: "Whether the image carries the containers.bootc=1 label.",
+				Computed:    true,
+			},
+			"kernel_version": schema.StringAttribute{
+				Description: "Kernel version from the ostree.linux label.",
+				Computed:    true,
+			},
+			"os_release": schema.MapAttribute{
+				Description: "Fields of /usr/lib/os-release in the image (e.g. ID, VERSION_ID).",
+				Computed:    true,
+				ElementType: types.StringType,
+			},
+			"size": schema.Int64Attribute{
+				Description: "Total size of the image layers in bytes.",
+				Computed:    true,
+			},
+			"created": schema.StringAttribute{
+				Description: "Image creation time in RFC 3339 format.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (*ContainerImageDataSource) Read(
+	ctx context.Context,
+	req datasource.ReadRequest,
+	resp *datasource.ReadResponse,
+) {
+	var data ContainerImageDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	transport := transportRegistry
+	if !data.Transport.IsNull() {
+		transport = data.Transport.ValueString()
+	}
+
+	image := data.Image.ValueString()
+
+	inspectOut, inspectErr := runCommand(ctx, "skopeo", "inspect", skopeoImageRef(transport, image))
+	if inspectErr != nil {
+		resp.Diagnostics.AddError("Failed to inspect container image", inspectErr.Error())
+
+		return
+	}
+
+	info, parseErr := parseImageInspect(inspectOut)
+	if parseErr != nil {
+		resp.Diagnostics.AddError("Failed to parse image metadata", parseErr.Error())
+
+		return
+	}
+
+	isBootc := info.Labels[labelBootc] == "1"
+	if !isBootc && (data.RequireBootc.IsNull() || data.RequireBootc.ValueBool()) {
+		resp.Diagnostics.AddAttributeError(path.Root("image"), "Not a bootc image",
+			fmt.Sprintf("%s does not carry the %s=1 label.", image, labelBootc))
+
+		return
+	}
+
+	osReleaseOut, osReleaseErr := runCommand(ctx, "podman", "run", "--rm", "--pull=missing",
+		"--network=none", "--entrypoint", "cat", podmanImageRef(transport, image),
+		"/usr/lib/os-release")
+	if osReleaseErr != nil {
+		resp.Diagnostics.AddError("Failed to read os-release from image", osReleaseErr.Error())
+
+		return
+	}
+
+	var size int64
+	for _, layer := range info.LayersData {
+		size += layer.Size
+	}
+
+	data.Digest = types.StringValue(info.Digest)
+	data.Architecture = types.StringValue(info.Architecture)
+	data.OS = types.StringValue(info.Os)
+	data.Bootc = types.BoolValue(isBootc)
+	data.KernelVersion = types.StringValue(info.Labels[labelOstreeLinux])
+	data.Size = types.Int64Value(size)
+
+	data.Created = types.StringNull()
+	if info.Created != nil {
+		data.Created = types.StringValue(info.Created.UTC().Format(time.RFC3339))
+	}
+
+	labels, labelsDiags := types.MapValueFrom(ctx, types.StringType, info.Labels)
+	resp.Diagnostics.Append(labelsDiags...)
+
+	osRelease, osReleaseDiags := types.MapValueFrom(ctx, types.StringType,
+		parseShellVars(osReleaseOut))
+	resp.Diagnostics.Append(osReleaseDiags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.Labels = labels
+	data.OSRelease = osRelease
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// skopeoImageRef prefixes image with the skopeo transport for the given source.
+func skopeoImageRef(transport, image string) string {
+	switch transport {
+	case transportContainerStorage:
+		return "containers-storage:" + image
+	case transportOCI:
+		return "oci:" + image
+	default:
+		return "docker://" + image
+	}
+}
+
+// splitImageRef splits a bootc source image reference into its transport
+// and image. References without a transport prefix are registry images, as
+// for bootc install --source-imgref.
+func splitImageRef(ref string) (string, string) {
+	for prefix, transport := range map[string]string{
+		"docker://":                     transportRegistry,
+		transportRegistry + ":":         transportRegistry,
+		transportContainerStorage + ":": transportContainerStorage,
+		transportOCI + ":":              transportOCI,
+	} {
+		if image, ok := strings.CutPrefix(ref, prefix); ok {
+			return transport, image
+		}
+	}
+
+	return transportRegistry, ref
+}
+
+// podmanImageRef returns the reference podman run expects for the given source.
+func podmanImageRef(transport, image string) string {
+	if transport == transportOCI {
+		return "oci:" + image
+	}
+
+	return image
+}
+
+func parseImageInspect(raw []byte) (*imageInspect, error) {
+	var info imageInspect
+
+	err := json.Unmarshal(raw, &info)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Labels == nil {
+		info.Labels = map[string]string{}
+	}
+
+	return &info, nil
+}
+
+// parseShellVars parses KEY=value lines as written by os-release(5) and
+// blkid -o export, unquoting values.
+func parseShellVars(raw []byte) map[string]string {
+	fields := map[string]string{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+
+		fields[key] = value
+	}
+
+	return fields
+}
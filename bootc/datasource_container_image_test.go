@@ -0,0 +1,223 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+)
+
+const testSkopeoInspect = `{
+    "Name": "quay.io/fedora/fedora-bootc",
+    "Digest": "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+    "Created": "2026-03-01T12:34:56.789Z",
+    "Labels": {
+        "containers.bootc": "1",
+        "ostree.linux": "6.13.5-200.fc41.x86_64",
+        "org.opencontainers.image.version": "41.20260301.0"
+    },
+    "Architecture": "amd64",
+    "Os": "linux",
+    "LayersData": [
+        {"MIMEType": "application/vnd.oci.image.layer.v1.tar+gzip", "Size": 1000},
+        {"MIMEType": "application/vnd.oci.image.layer.v1.tar+gzip", "Size": 234}
+    ]
+}`
+
+const testOSRelease = `NAME="Fedora Linux"
+VERSION="41 (Forty One)"
+# comment
+ID=fedora
+VERSION_ID=41
+PRETTY_NAME='Fedora Linux 41'
+
+`
+
+func TestContainerImageDataSource_Metadata(t *testing.T) {
+	ds := NewContainerImageDataSource()
+	resp := &datasource.MetadataResponse{}
+	ds.Metadata(t.Context(), datasource.MetadataRequest{ProviderTypeName: providerTypeName}, resp)
+
+	if resp.TypeName != "bootc_container_image" {
+		t.Errorf("TypeName = %q, want %q", resp.TypeName, "bootc_container_image")
+	}
+}
+
+func TestContainerImageDataSource_Schema(t *testing.T) {
+	ds := &ContainerImageDataSource{}
+	resp := &datasource.SchemaResponse{}
+	ds.Schema(t.Context(), datasource.SchemaRequest{}, resp)
+
+	image, ok := resp.Schema.Attributes["image"].(schema.StringAttribute)
+	if !ok || !image.Required {
+		t.Error("image should be a required string attribute")
+	}
+
+	for _, name := range []string{"transport", "require_bootc"} {
+		attr, ok := resp.Schema.Attributes[name]
+		if !ok {
+			t.Errorf("missing attribute %q", name)
+
+			continue
+		}
+
+		if !attr.IsOptional() {
+			t.Errorf("attribute %q should be optional", name)
+		}
+	}
+
+	computed := []string{
+		"digest", "architecture", "os", "labels", "bootc",
+		"kernel_version", "os_release", "size", "created",
+	}
+	for _, name := range computed {
+		attr, ok := resp.Schema.Attributes[name]
+		if !ok {
+			t.Errorf("missing attribute %q", name)
+
+			continue
+		}
+
+		if !attr.IsComputed() {
+			t.Errorf("attribute %q should be computed", name)
+		}
+	}
+}
+
+func TestParseImageInspect(t *testing.T) {
+	info, err := parseImageInspect([]byte(testSkopeoInspect))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Labels[labelBootc] != "1" {
+		t.Errorf("bootc label = %q, want %q", info.Labels[labelBootc], "1")
+	}
+
+	if info.Labels[labelOstreeLinux] != "6.13.5-200.fc41.x86_64" {
+		t.Errorf("kernel = %q", info.Labels[labelOstreeLinux])
+	}
+
+	if info.Architecture != "amd64" || info.Os != "linux" {
+		t.Errorf("platform = %s/%s, want linux/amd64", info.Os, info.Architecture)
+	}
+
+	if len(info.LayersData) != 2 {
+		t.Errorf("layers = %d, want 2", len(info.LayersData))
+	}
+
+	if info.Created == nil || info.Created.Year() != 2026 {
+		t.Errorf("created = %v", info.Created)
+	}
+
+	empty, err := parseImageInspect([]byte(`{"Digest": "sha256:00"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if empty.Labels == nil {
+		t.Error("expected non-nil labels for image without labels")
+	}
+
+	if _, err := parseImageInspect([]byte("not json")); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestParseShellVars(t *testing.T) {
+	fields := parseShellVars([]byte(testOSRelease))
+
+	want := map[string]string{
+		"NAME":        "Fedora Linux",
+		"VERSION":     "41 (Forty One)",
+		"ID":          "fedora",
+		"VERSION_ID":  "41",
+		"PRETTY_NAME": "Fedora Linux 41",
+	}
+
+	if len(fields) != len(want) {
+		t.Errorf("got %d fields, want %d: %v", len(fields), len(want), fields)
+	}
+
+	for key, value := range want {
+		if fields[key] != value {
+			t.Errorf("%s = %q, want %q", key, fields[key], value)
+		}
+	}
+}
+
+func TestImageRefs(t *testing.T) {
+	tests := []struct {
+		name       string
+		transport  string
+		image      string
+		wantSkopeo string
+		wantPodman string
+	}{
+		{
+			"registry", transportRegistry, testSourceImage,
+			"docker://" + testSourceImage, testSourceImage,
+		},
+		{
+			"containers_storage", transportContainerStorage, "localhost/os:latest",
+			"containers-storage:localhost/os:latest", "localhost/os:latest",
+		},
+		{
+			"oci", transportOCI, "/srv/oci/os:latest",
+			"oci:/srv/oci/os:latest", "oci:/srv/oci/os:latest",
+		},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := skopeoImageRef(testCase.transport, testCase.image); got != testCase.wantSkopeo {
+				t.Errorf("skopeoImageRef = %q, want %q", got, testCase.wantSkopeo)
+			}
+
+			if got := podmanImageRef(testCase.transport, testCase.image); got != testCase.wantPodman {
+				t.Errorf("podmanImageRef = %q, want %q", got, testCase.wantPodman)
+			}
+		})
+	}
+}
+
+func TestSplitImageRef(t *testing.T) {
+	tests := []struct {
+		ref           string
+		wantTransport string
+		wantImage     string
+	}{
+		{"quay.io/fedora/fedora-bootc:41", transportRegistry, "quay.io/fedora/fedora-bootc:41"},
+		{"docker://quay.io/fedora/fedora-bootc:41", transportRegistry, "quay.io/fedora/fedora-bootc:41"},
+		{"registry:quay.io/fedora/fedora-bootc:41", transportRegistry, "quay.io/fedora/fedora-bootc:41"},
+		{"containers-storage:localhost/os:dev", transportContainerStorage, "localhost/os:dev"},
+		{"oci:/var/lib/layouts/os", transportOCI, "/var/lib/layouts/os"},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.ref, func(t *testing.T) {
+			transport, image := splitImageRef(testCase.ref)
+			if transport != testCase.wantTransport || image != testCase.wantImage {
+				t.Errorf("splitImageRef() = %q, %q, want %q, %q", transport, image, testCase.wantTransport, testCase.wantImage)
+			}
+		})
+	}
+}
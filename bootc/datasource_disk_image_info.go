@@ -0,0 +1,432 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ datasource.DataSource = &DiskImageInfoDataSource{}
+
+const (
+	// diskHeadBytes is how much of a non-raw image is extracted to read its
+	// partition table; it covers the GPT header and entries for both sector sizes.
+	diskHeadBytes = "1M"
+
+	qemuImgCheckCorruptions  = 2
+	qemuImgCheckLeaks        = 3
+	qemuImgCheckNotSupported = 63
+)
+
+var ErrQemuImgOutput = errors.New("unexpected qemu-img output")
+
+// DiskImageInfoDataSource implements the bootc_disk_image_info data source.
+type DiskImageInfoDataSource struct{}
+
+type DiskImageInfoDataSourceModel struct {
+	BackingChain   types.List   `tfsdk:"backing_chain"`
+	Partitions     types.List   `tfsdk:"partitions"`
+	Check          types.Object `tfsdk:"check"`
+	Path           types.String `tfsdk:"path"`
+	Format         types.String `tfsdk:"format"`
+	PartitionTable types.String `tfsdk:"partition_table"`
+	DiskGUID       types.String `tfsdk:"disk_guid"`
+	VirtualSize    types.Int64  `tfsdk:"virtual_size"`
+	ActualSize     types.Int64  `tfsdk:"actual_size"`
+	Dirty          types.Bool   `tfsdk:"dirty"`
+}
+
+// qemuImgInfo is one element of `qemu-img info --backing-chain --output=json`.
+//
+//nolint:tagliatelle // field names follow qemu-img's JSON output
+type qemuImgInfo struct {
+	Filename        string `json:"filename"`
+	Format          string `json:"format"`
+	BackingFilename string `json:"full-backing-filename"`
+	VirtualSize     int64  `json:"virtual-size"`
+	ActualSize      int64  `json:"actual-size"`
+	DirtyFlag       bool   `json:"dirty-flag"`
+}
+
+// qemuImgCheck is the output of `qemu-img check --output=json`.
+//
+//nolint:tagliatelle // field names follow qemu-img's JSON output
+type qemuImgCheck struct {
+	CheckErrors    int64 `json:"check-errors"`
+	Corruptions    int64 `json:"corruptions"`
+	Leaks          int64 `json:"leaks"`
+	ImageEndOffset int64 `json:"image-end-offset"`
+}
+
+var diskCheckAttrTypes = map[string]attr.Type{
+	"supported":        types.BoolType,
+	"passed":           types.BoolType,
+	"check_errors":     types.Int64Type,
+	"corruptions":      types.Int64Type,
+	"leaks":            types.Int64Type,
+	"image_end_offset": types.Int64Type,
+}
+
+var diskPartitionAttrTypes = map[string]attr.Type{
+	"number":    types.Int64Type,
+	"label":     types.StringType,
+	"type_guid": types.StringType,
+	"uuid":      types.StringType,
+	"start":     types.Int64Type,
+	"size":      types.Int64Type,
+}
+
+func NewDiskImageInfoDataSource() datasource.DataSource {
+	return &DiskImageInfoDataSource{}
+}
+
+func (*DiskImageInfoDataSource) Metadata(
+	_ context.Context,
+	req datasource.MetadataRequest,
+	resp *datasource.MetadataResponse,
+) {
+	resp.TypeName = req.ProviderTypeName + "_disk_image_info"
+}
+
+func (*DiskImageInfoDataSource) Schema(
+	_ context.Context,
+	_ datasource.SchemaRequest,
+	resp *datasource.SchemaResponse,
+) {
+	resp.Schema = schema.Schema{
+		Description: "Reads format, sizes, backing chain, qemu-img check results and the GPT of an existing disk image.",
+		Attributes: map[string]schema.Attribute{
+			"path": schema.StringAttribute{
+				Description: "Path to the disk image.",
+				Required:    true,
+			},
+			"format": schema.StringAttribute{
+				Description: "Image format (e.g. qcow2, raw). Probed by qemu-img when unset.",
+				Optional:    true,
+				Computed:    true,
+			},
+			"virtual_size": schema.Int64Attribute{
+				Description: "Virtual disk size in bytes.",
+				Computed:    true,
+			},
+			"actual_size": schema.Int64Attribute{
+				Description: "Space the image occupies on the host filesystem in bytes.",
+				Computed:    true,
+			},
+			"dirty": schema.BoolAttribute{
+				Description: "Whether the image's dirty flag is set (qcow2 lazy refcounts).",
+				Computed:    true,
+			},
+			"backing_chain": schema.ListAttribute{
+				Description: "Backing files of the image, nearest first.",
+				Computed:    true,
+				ElementType: types.StringType,
+			},
+			"check": schema.SingleNestedAttribute{
+				Description: "Result of qemu-img check.",
+				Computed:    true,
+				Attributes: map[string]schema.Attribute{
+					"supported": schema.BoolAttribute{
+						Description: "Whether the image format supports consistency checks.",
+						Computed:    true,
+					},
+					"passed": schema.BoolAttribute{
+						Description: "Whether the check found no errors, corruptions or leaks.",
+						Computed:    true,
+					},
+					"check_errors": schema.Int64Attribute{
+						Description: "Errors that prevented the check from completing.",
+						Computed:    true,
+					},
+					"corruptions": schema.Int64Attribute{
+						Description: "Corrupted clusters.",
+						Computed:    true,
+					},
+					"leaks": schema.Int64Attribute{
+						Description: "Leaked clusters.",
+						Computed:    true,
+					},
+					"image_end_offset": schema.Int64Attribute{
+						Description: "Offset of the end of the image data in bytes.",
+						Computed:    true,
+					},
+				},
+			},
+			"partition_table": schema.StringAttribute{
+				Description: "Partition table type: gpt, mbr, or none.",
+				Computed:    true,
+			},
+			"disk_guid": schema.StringAttribute{
+				Description: "GPT disk GUID.",
+				Computed:    true,
+			},
+			"partitions": schema.ListNestedAttribute{
+				Description: "GPT partitions.",
+				Computed:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"number": schema.Int64Attribute{
+							Description: "Partition number.",
+							Computed:    true,
+						},
+						"label": schema.StringAttribute{
+							Description: "GPT partition label.",
+							Computed:    true,
+						},
+						"type_guid": schema.StringAttribute{
+							Description: "Partition type GUID.",
+							Computed:    true,
+						},
+						"uuid": schema.StringAttribute{
+							Description: "Unique partition GUID (PARTUUID).",
+							Computed:    true,
+						},
+						"start": schema.Int64Attribute{
+							Description: "Partition offset in bytes.",
+							Computed:    true,
+						},
+						"size": schema.Int64Attribute{
+							Description: "Partition size in bytes.",
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (*DiskImageInfoDataSource) Read(
+	ctx context.Context,
+	req datasource.ReadRequest,
+	resp *datasource.ReadResponse,
+) {
+	var data DiskImageInfoDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	imagePath := data.Path.ValueString()
+
+	infoArgs := []string{"info", "--output=json", "--backing-chain"}
+	if !data.Format.IsNull() && !data.Format.IsUnknown() {
+		infoArgs = append(infoArgs, "-f", data.Format.ValueString())
+	}
+
+	infoOut, infoErr := runCommand(ctx, "qemu-img", append(infoArgs, imagePath)...)
+	if infoErr != nil {
+		resp.Diagnostics.AddError("qemu-img info failed", infoErr.Error())
+
+		return
+	}
+
+	chain, parseErr := parseQemuImgInfo(infoOut)
+	if parseErr != nil {
+		resp.Diagnostics.AddError("Failed to parse qemu-img info output", parseErr.Error())
+
+		return
+	}
+
+	top := chain[0]
+
+	backing := make([]string, 0, len(chain)-1)
+	for _, layer := range chain[1:] {
+		backing = append(backing, layer.Filename)
+	}
+
+	check, checkErr := qemuImgCheckImage(ctx, imagePath, top.Format)
+	if checkErr != nil {
+		resp.Diagnostics.AddError("qemu-img check failed", checkErr.Error())
+
+		return
+	}
+
+	tableType, table, tableErr := readDiskPartitionTable(ctx, imagePath, top.Format)
+	if tableErr != nil {
+		resp.Diagnostics.AddError("Failed to read partition table", tableErr.Error())
+
+		return
+	}
+
+	data.Format = types.StringValue(top.Format)
+	data.VirtualSize = types.Int64Value(top.VirtualSize)
+	data.ActualSize = types.Int64Value(top.ActualSize)
+	data.Dirty = types.BoolValue(top.DirtyFlag)
+	data.PartitionTable = types.StringValue(tableType)
+	data.DiskGUID = types.StringNull()
+
+	backingList, backingDiags := types.ListValueFrom(ctx, types.StringType, backing)
+	resp.Diagnostics.Append(backingDiags...)
+
+	checkObj, checkDiags := types.ObjectValue(diskCheckAttrTypes, check)
+	resp.Diagnostics.Append(checkDiags...)
+
+	partitions := []attr.Value{}
+
+	if table != nil {
+		data.DiskGUID = types.StringValue(table.DiskGUID)
+
+		for _, part := range table.Partitions {
+			obj, objDiags := types.ObjectValue(diskPartitionAttrTypes, map[string]attr.Value{
+				"number":    types.Int64Value(int64(part.Number)),
+				"label":     types.StringValue(part.Name),
+				"type_guid": types.StringValue(part.TypeGUID),
+				"uuid":      types.StringValue(part.GUID),
+				"start":     types.Int64Value(table.Start(part)),
+				"size":      types.Int64Value(table.Size(part)),
+			})
+			resp.Diagnostics.Append(objDiags...)
+
+			partitions = append(partitions, obj)
+		}
+	}
+
+	partitionList, partitionDiags := types.ListValue(
+		types.ObjectType{AttrTypes: diskPartitionAttrTypes},
+		partitions,
+	)
+	resp.Diagnostics.Append(partitionDiags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.BackingChain = backingList
+	data.Check = checkObj
+	data.Partitions = partitionList
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func parseQemuImgInfo(raw []byte) ([]qemuImgInfo, error) {
+	var chain []qemuImgInfo
+
+	err := json.Unmarshal(raw, &chain)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("%w: empty backing chain", ErrQemuImgOutput)
+	}
+
+	return chain, nil
+}
+
+// qemuImgCheckImage runs qemu-img check and maps its exit status: 2 and 3
+// still produce a report, 63 means the format has no consistency checks.
+func qemuImgCheckImage(ctx context.Context, imagePath, format string) (map[string]attr.Value, error) {
+	result := map[string]attr.Value{
+		"supported":        types.BoolValue(false),
+		"passed":           types.BoolNull(),
+		"check_errors":     types.Int64Null(),
+		"corruptions":      types.Int64Null(),
+		"leaks":            types.Int64Null(),
+		"image_end_offset": types.Int64Null(),
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	//nolint:gosec // G204: qemu-img is a trusted system command with validated inputs
+	cmd := exec.CommandContext(ctx, "qemu-img", "check", "--output=json", "-f", format, imagePath)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	runErr := cmd.Run()
+
+	var exitErr *exec.ExitError
+	if errors.As(runErr, &exitErr) {
+		switch exitErr.ExitCode() {
+		case qemuImgCheckNotSupported:
+			return result, nil
+		case qemuImgCheckCorruptions, qemuImgCheckLeaks:
+		default:
+			return nil, fmt.Errorf("%w: %s", runErr, bytes.TrimSpace(stderr.Bytes()))
+		}
+	} else if runErr != nil {
+		return nil, runErr
+	}
+
+	var check qemuImgCheck
+
+	err := json.Unmarshal(stdout.Bytes(), &check)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQemuImgOutput, err)
+	}
+
+	result["supported"] = types.BoolValue(true)
+	result["passed"] = types.BoolValue(check.CheckErrors == 0 && check.Corruptions == 0 && check.Leaks == 0)
+	result["check_errors"] = types.Int64Value(check.CheckErrors)
+	result["corruptions"] = types.Int64Value(check.Corruptions)
+	result["leaks"] = types.Int64Value(check.Leaks)
+	result["image_end_offset"] = types.Int64Value(check.ImageEndOffset)
+
+	return result, nil
+}
+
+// readDiskPartitionTable reads the partition table of imagePath. Non-raw
+// images have their first megabyte extracted with qemu-img dd first.
+func readDiskPartitionTable(ctx context.Context, imagePath, format string) (string, *gptTable, error) {
+	rawPath := imagePath
+
+	if format != "raw" {
+		tmpDir, err := os.MkdirTemp("", "bootc-disk-info-")
+		if err != nil {
+			return "", nil, err
+		}
+		defer os.RemoveAll(tmpDir)
+
+		rawPath = filepath.Join(tmpDir, "head.raw")
+
+		_, err = runCommand(ctx, "qemu-img", "dd", "-f", format, "-O", "raw",
+			"bs="+diskHeadBytes, "count=1", "if="+imagePath, "of="+rawPath)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	disk, err := os.Open(rawPath)
+	if err != nil {
+		return "", nil, err
+	}
+	defer disk.Close()
+
+	tableType, err := detectPartitionTable(disk)
+	if err != nil || tableType != partitionTableGPT {
+		return tableType, nil, err
+	}
+
+	table, err := readGPT(disk)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return tableType, table, nil
+}
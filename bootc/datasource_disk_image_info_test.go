@@ -0,0 +1,186 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const testQemuImgInfoChain = `[
+    {
+        "virtual-size": 10737418240,
+        "filename": "/var/lib/images/overlay.qcow2",
+        "format": "qcow2",
+        "actual-size": 200704,
+        "dirty-flag": false,
+        "full-backing-filename": "/var/lib/images/base.qcow2",
+        "backing-filename": "base.qcow2"
+    },
+    {
+        "virtual-size": 10737418240,
+        "filename": "/var/lib/images/base.qcow2",
+        "format": "qcow2",
+        "actual-size": 1073741824,
+        "dirty-flag": true
+    }
+]`
+
+func TestDiskImageInfoDataSource_Metadata(t *testing.T) {
+	ds := NewDiskImageInfoDataSource()
+	resp := &datasource.MetadataResponse{}
+	ds.Metadata(t.Context(), datasource.MetadataRequest{ProviderTypeName: providerTypeName}, resp)
+
+	if resp.TypeName != "bootc_disk_image_info" {
+		t.Errorf("TypeName = %q, want %q", resp.TypeName, "bootc_disk_image_info")
+	}
+}
+
+func TestDiskImageInfoDataSource_Schema(t *testing.T) {
+	ds := &DiskImageInfoDataSource{}
+	resp := &datasource.SchemaResponse{}
+	ds.Schema(t.Context(), datasource.SchemaRequest{}, resp)
+
+	pathAttr, ok := resp.Schema.Attributes["path"].(schema.StringAttribute)
+	if !ok || !pathAttr.Required {
+		t.Error("path should be a required string attribute")
+	}
+
+	format, ok := resp.Schema.Attributes["format"].(schema.StringAttribute)
+	if !ok || !format.Optional || !format.Computed {
+		t.Error("format should be optional and computed")
+	}
+
+	computed := []string{
+		"virtual_size", "actual_size", "dirty", "backing_chain", "check",
+		"partition_table", "disk_guid", "partitions",
+	}
+	for _, name := range computed {
+		if attr, ok := resp.Schema.Attributes[name]; !ok || !attr.IsComputed() {
+			t.Errorf("attribute %q should be computed", name)
+		}
+	}
+
+	check, ok := resp.Schema.Attributes["check"].(schema.SingleNestedAttribute)
+	if !ok {
+		t.Fatal("check should be a single nested attribute")
+	}
+
+	for name := range diskCheckAttrTypes {
+		if _, ok := check.Attributes[name]; !ok {
+			t.Errorf("check missing nested attribute %q", name)
+		}
+	}
+
+	partitions, ok := resp.Schema.Attributes["partitions"].(schema.ListNestedAttribute)
+	if !ok {
+		t.Fatal("partitions should be a list nested attribute")
+	}
+
+	for name := range diskPartitionAttrTypes {
+		if _, ok := partitions.NestedObject.Attributes[name]; !ok {
+			t.Errorf("partitions missing nested attribute %q", name)
+		}
+	}
+}
+
+func TestParseQemuImgInfo(t *testing.T) {
+	chain, err := parseQemuImgInfo([]byte(testQemuImgInfoChain))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(chain) != 2 {
+		t.Fatalf("chain length = %d, want 2", len(chain))
+	}
+
+	top := chain[0]
+	if top.Format != "qcow2" || top.VirtualSize != 10737418240 || top.ActualSize != 200704 {
+		t.Errorf("top = %+v", top)
+	}
+
+	if top.BackingFilename != "/var/lib/images/base.qcow2" {
+		t.Errorf("backing = %q", top.BackingFilename)
+	}
+
+	if !chain[1].DirtyFlag {
+		t.Error("expected dirty flag on base image")
+	}
+
+	if _, err := parseQemuImgInfo([]byte("[]")); !errors.Is(err, ErrQemuImgOutput) {
+		t.Errorf("expected ErrQemuImgOutput, got %v", err)
+	}
+}
+
+func TestReadDiskPartitionTable_Raw(t *testing.T) {
+	rawPath := filepath.Join(t.TempDir(), "disk.raw")
+	disk := buildTestGPT(t, 512, []testGPTPartition{
+		{testESPTypeGUID, "11111111-2222-3333-4444-555555555555", "EFI-SYSTEM", 2048, 206847},
+	})
+
+	if err := os.WriteFile(rawPath, disk, testSecureFilePerms); err != nil {
+		t.Fatal(err)
+	}
+
+	tableType, table, err := readDiskPartitionTable(t.Context(), rawPath, "raw")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tableType != partitionTableGPT || table == nil || len(table.Partitions) != 1 {
+		t.Fatalf("tableType = %q, table = %+v", tableType, table)
+	}
+
+	if table.Partitions[0].Name != "EFI-SYSTEM" {
+		t.Errorf("label = %q", table.Partitions[0].Name)
+	}
+}
+
+func TestIntegration_DiskImageInfoCheck(t *testing.T) {
+	skipUnlessAcc(t)
+	requireCmd(t, testQemuImgCmd)
+
+	qcow2Path := filepath.Join(t.TempDir(), testDiskFilename)
+
+	if _, err := runCommand(t.Context(), testQemuImgCmd, "create", "-f", "qcow2", qcow2Path, "64M"); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := qemuImgCheckImage(t.Context(), qcow2Path, "qcow2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result["supported"] != types.BoolValue(true) || result["passed"] != types.BoolValue(true) {
+		t.Errorf("check = %v", result)
+	}
+
+	tableType, _, err := readDiskPartitionTable(t.Context(), qcow2Path, "qcow2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tableType != partitionTableNone {
+		t.Errorf("tableType = %q, want none", tableType)
+	}
+}
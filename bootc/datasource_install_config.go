@@ -0,0 +1,222 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ datasource.DataSource = &InstallConfigDataSource{}
+
+// InstallConfigDataSource implements the bootc_install_config data source.
+type InstallConfigDataSource struct{}
+
+type InstallConfigDataSourceModel struct {
+	InstallConfig *InstallConfigModel `tfsdk:"install_config"`
+	Block         types.List          `tfsdk:"block"`
+	Kargs         types.List          `tfsdk:"kargs"`
+	Sources       types.Map           `tfsdk:"sources"`
+	Image         types.String        `tfsdk:"image"`
+	Transport     types.String        `tfsdk:"transport"`
+	RootFSType    types.String        `tfsdk:"root_fs_type"`
+	ImageTOML     types.String        `tfsdk:"image_toml"`
+	TerraformTOML types.String        `tfsdk:"terraform_toml"`
+	TOML          types.String        `tfsdk:"toml"`
+}
+
+func NewInstallConfigDataSource() datasource.DataSource {
+	return &InstallConfigDataSource{}
+}
+
+func (*InstallConfigDataSource) Metadata(
+	_ context.Context,
+	req datasource.MetadataRequest,
+	resp *datasource.MetadataResponse,
+) {
+	resp.TypeName = req.ProviderTypeName + "_install_config"
+}
+
+func (*InstallConfigDataSource) Schema(
+	_ context.Context,
+	_ datasource.SchemaRequest,
+	resp *datasource.SchemaResponse,
+) {
+	resp.Schema = schema.Schema{
+		Description: "Shows the bootc install configuration of an image merged with an install_config override, as bootc install print-configuration does.",
+		Attributes: map[string]schema.Attribute{
+			"image": schema.StringAttribute{
+				Description: "Image reference (e.g. quay.io/fedora/fedora-bootc:42), or a path[:tag] for the oci transport.",
+				Required:    true,
+			},
+			"transport": schema.StringAttribute{
+				Description: "Where to look for the image: registry, containers-storage, or oci. Defaults to registry.",
+				Optional:    true,
+				Validators: []validator.String{
+					stringOneOf(transportRegistry, transportContainerStorage, transportOCI),
+				},
+			},
+			"image_toml": schema.StringAttribute{
+				Description: "Configuration shipped in the image's /usr/lib/bootc/install/*.toml, merged.",
+				Computed:    true,
+			},
+			"terraform_toml": schema.StringAttribute{
+				Description: "The install_config block rendered as a configuration file.",
+				Computed:    true,
+			},
+			"toml": schema.StringAttribute{
+				Description: "Effective configuration of an install with the install_config block.",
+				Computed:    true,
+			},
+			"root_fs_type": schema.StringAttribute{
+				Description: "Effective root filesystem type.",
+				Computed:    true,
+			},
+			"block": schema.ListAttribute{
+				Description: "Effective allowed block setups.",
+				Computed:    true,
+				ElementType: types.StringType,
+			},
+			"kargs": schema.ListAttribute{
+				Description: "Effective install kernel arguments.",
+				Computed:    true,
+				ElementType: types.StringType,
+			},
+			"sources": schema.MapAttribute{
+				Description: "Origin of each set key (root_fs_type, block, kargs): image, terraform, or image+terraform.",
+				Computed:    true,
+				ElementType: types.StringType,
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"install_config": schema.SingleNestedBlock{
+				Description: "Override to merge, with the same arguments as the install_config block of bootc_image.",
+				Attributes: map[string]schema.Attribute{
+					"root_fs_type": schema.StringAttribute{
+						Description: "Root filesystem type: xfs, ext4, or btrfs.",
+						Optional:    true,
+						Validators: []validator.String{
+							stringOneOf("xfs", "ext4", "btrfs"),
+						},
+					},
+					"block": schema.ListAttribute{
+						Description: "Allowed block setups (direct, tpm2-luks).",
+						Optional:    true,
+						ElementType: types.StringType,
+					},
+					"kargs": schema.ListAttribute{
+						Description: "Kernel arguments appended to those of the image configuration.",
+						Optional:    true,
+						ElementType: types.StringType,
+					},
+					"match_architectures": schema.ListAttribute{
+						Description: "Apply the override only on these architectures (e.g. x86_64, aarch64).",
+						Optional:    true,
+						ElementType: types.StringType,
+					},
+				},
+			},
+		},
+	}
+}
+
+func (*InstallConfigDataSource) Read(
+	ctx context.Context,
+	req datasource.ReadRequest,
+	resp *datasource.ReadResponse,
+) {
+	var data InstallConfigDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(validateInstallConfig(data.InstallConfig)...)
+
+	override, overrideDiags := installConfigFromModel(ctx, data.InstallConfig)
+	resp.Diagnostics.Append(overrideDiags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	transport := transportRegistry
+	if !data.Transport.IsNull() {
+		transport = data.Transport.ValueString()
+	}
+
+	printOut, printErr := runCommand(ctx, "podman", "run", "--rm", "--pull=missing",
+		"--network=none", "--entrypoint", "bootc", podmanImageRef(transport, data.Image.ValueString()),
+		"install", "print-configuration")
+	if printErr != nil {
+		resp.Diagnostics.AddError("Failed to read install configuration from image", printErr.Error())
+
+		return
+	}
+
+	imageCfg, parseErr := parsePrintedInstallConfig(printOut)
+	if parseErr != nil {
+		resp.Diagnostics.AddError("Failed to parse install configuration", parseErr.Error())
+
+		return
+	}
+
+	merged, sources := mergeInstallConfig(imageCfg, override, hostArchitecture())
+
+	for _, rendered := range []struct {
+		config installConfig
+		target *types.String
+	}{
+		{imageCfg, &data.ImageTOML},
+		{override, &data.TerraformTOML},
+		{merged, &data.TOML},
+	} {
+		out, err := rendered.config.TOML()
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to render install configuration", err.Error())
+
+			return
+		}
+
+		*rendered.target = types.StringValue(out)
+	}
+
+	data.RootFSType = types.StringValue(merged.RootFSType)
+
+	block, blockDiags := types.ListValueFrom(ctx, types.StringType, merged.Block)
+	resp.Diagnostics.Append(blockDiags...)
+
+	kargs, kargsDiags := types.ListValueFrom(ctx, types.StringType, merged.Kargs)
+	resp.Diagnostics.Append(kargsDiags...)
+
+	sourceMap, sourcesDiags := types.MapValueFrom(ctx, types.StringType, sources)
+	resp.Diagnostics.Append(sourcesDiags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.Block = block
+	data.Kargs = kargs
+	data.Sources = sourceMap
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,62 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+)
+
+func TestInstallConfigDataSource_Metadata(t *testing.T) {
+	ds := NewInstallConfigDataSource()
+	resp := &datasource.MetadataResponse{}
+	ds.Metadata(t.Context(), datasource.MetadataRequest{ProviderTypeName: providerTypeName}, resp)
+
+	if resp.TypeName != "bootc_install_config" {
+		t.Errorf("TypeName = %q, want %q", resp.TypeName, "bootc_install_config")
+	}
+}
+
+func TestInstallConfigDataSource_Schema(t *testing.T) {
+	ds := &InstallConfigDataSource{}
+	resp := &datasource.SchemaResponse{}
+	ds.Schema(t.Context(), datasource.SchemaRequest{}, resp)
+
+	image, ok := resp.Schema.Attributes["image"].(schema.StringAttribute)
+	if !ok || !image.Required {
+		t.Error("image should be a required string attribute")
+	}
+
+	for _, name := range []string{"image_toml", "terraform_toml", "toml", "root_fs_type", "block", "kargs", "sources"} {
+		if attr, ok := resp.Schema.Attributes[name]; !ok || !attr.IsComputed() {
+			t.Errorf("attribute %q should be computed", name)
+		}
+	}
+
+	block, ok := resp.Schema.Blocks["install_config"].(schema.SingleNestedBlock)
+	if !ok {
+		t.Fatal("install_config should be a single nested block")
+	}
+
+	for _, name := range []string{"root_fs_type", "block", "kargs", "match_architectures"} {
+		if _, ok := block.Attributes[name]; !ok {
+			t.Errorf("install_config missing attribute %q", name)
+		}
+	}
+}
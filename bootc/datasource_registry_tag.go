@@ -0,0 +1,245 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ datasource.DataSource = &RegistryTagDataSource{}
+
+const registryRequestTimeout = 60 * time.Second
+
+// RegistryTagDataSource implements the bootc_registry_tag data source.
+type RegistryTagDataSource struct{}
+
+type RegistryTagDataSourceModel struct {
+	Annotations     types.Map    `tfsdk:"annotations"`
+	PlatformDigests types.Map    `tfsdk:"platform_digests"`
+	Manifests       types.List   `tfsdk:"manifests"`
+	Image           types.String `tfsdk:"image"`
+	RegistriesConf  types.String `tfsdk:"registries_conf"`
+	AuthFile        types.String `tfsdk:"auth_file"`
+	Digest          types.String `tfsdk:"digest"`
+	MediaType       types.String `tfsdk:"media_type"`
+	PinnedImage     types.String `tfsdk:"pinned_image"`
+}
+
+// registryManifestAttrTypes describes the elements of the manifests attribute.
+var registryManifestAttrTypes = map[string]attr.Type{
+	"digest":       types.StringType,
+	"media_type":   types.StringType,
+	"platform":     types.StringType,
+	"os":           types.StringType,
+	"architecture": types.StringType,
+	"variant":      types.StringType,
+	"size":         types.Int64Type,
+}
+
+func NewRegistryTagDataSource() datasource.DataSource {
+	return &RegistryTagDataSource{}
+}
+
+func (*RegistryTagDataSource) Metadata(
+	_ context.Context,
+	req datasource.MetadataRequest,
+	resp *datasource.MetadataResponse,
+) {
+	resp.TypeName = req.ProviderTypeName + "_registry_tag"
+}
+
+func (*RegistryTagDataSource) Schema(
+	_ context.Context,
+	_ datasource.SchemaRequest,
+	resp *datasource.SchemaResponse,
+) {
+	resp.Schema = schema.Schema{
+		Description: "Resolves an image tag to its manifest digest over the OCI distribution API without pulling layers.",
+		Attributes: map[string]schema.Attribute{
+			"image": schema.StringAttribute{
+				Description: "Image reference to resolve (e.g. quay.io/fedora/fedora-bootc:42).",
+				Required:    true,
+			},
+			"registries_conf": schema.StringAttribute{
+				Description: "Path to a registries.conf file. By default the user and system registries.conf and registries.conf.d drop-ins are used.",
+				Optional:    true,
+			},
+			"auth_file": schema.StringAttribute{
+				Description: "Path to a containers auth.json file. By default REGISTRY_AUTH_FILE, the podman auth files and ~/.docker/config.json are consulted.",
+				Optional:    true,
+			},
+			"digest": schema.StringAttribute{
+				Description: "Digest of the manifest (or manifest list) the tag points to.",
+				Computed:    true,
+			},
+			"media_type": schema.StringAttribute{
+				Description: "Media type of the manifest the tag points to.",
+				Computed:    true,
+			},
+			"pinned_image": schema.StringAttribute{
+				Description: "The image reference pinned by digest (repository@digest), suitable for bootc_image.source_image.",
+				Computed:    true,
+			},
+			"annotations": schema.MapAttribute{
+				Description: "Annotations of the manifest.",
+				Computed:    true,
+				ElementType: types.StringType,
+			},
+			"platform_digests": schema.MapAttribute{
+				Description: "Per-platform manifest digests of a manifest list, keyed by os/architecture[/variant].",
+				Computed:    true,
+				ElementType: types.StringType,
+			},
+			"manifests": schema.ListNestedAttribute{
+				Description: "Entries of a manifest list. Empty for single-platform manifests.",
+				Computed:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"digest": schema.StringAttribute{
+							Description: "Manifest digest.",
+							Computed:    true,
+						},
+						"media_type": schema.StringAttribute{
+							Description: "Manifest media type.",
+							Computed:    true,
+						},
+						"platform": schema.StringAttribute{
+							Description: "Platform as os/architecture[/variant].",
+							Computed:    true,
+						},
+						"os": schema.StringAttribute{
+							Description: "Platform operating system.",
+							Computed:    true,
+						},
+						"architecture": schema.StringAttribute{
+							Description: "Platform CPU architecture.",
+							Computed:    true,
+						},
+						"variant": schema.StringAttribute{
+							Description: "Platform CPU variant (e.g. v8).",
+							Computed:    true,
+						},
+						"size": schema.Int64Attribute{
+							Description: "Manifest size in bytes.",
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (*RegistryTagDataSource) Read(
+	ctx context.Context,
+	req datasource.ReadRequest,
+	resp *datasource.ReadResponse,
+) {
+	var data RegistryTagDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	conf, confErr := loadRegistriesConf(data.RegistriesConf.ValueString())
+	if confErr != nil {
+		resp.Diagnostics.AddError("Failed to read registries.conf", confErr.Error())
+
+		return
+	}
+
+	ref, refErr := parseImageReference(data.Image.ValueString(), conf.UnqualifiedSearchRegistries)
+	if refErr != nil {
+		resp.Diagnostics.AddError("Invalid image reference", refErr.Error())
+
+		return
+	}
+
+	client := &registryClient{
+		HTTP:      &http.Client{Timeout: registryRequestTimeout},
+		Conf:      conf,
+		AuthFiles: authFilePaths(data.AuthFile.ValueString()),
+	}
+
+	resolved, resolveErr := client.Resolve(ctx, ref)
+	if resolveErr != nil {
+		resp.Diagnostics.AddError("Failed to resolve image tag", resolveErr.Error())
+
+		return
+	}
+
+	manifests := make([]attr.Value, 0, len(resolved.Manifest.Manifests))
+	platformDigests := map[string]string{}
+
+	for _, entry := range resolved.Manifest.Manifests {
+		platform := ociPlatform{}
+		if entry.Platform != nil {
+			platform = *entry.Platform
+			platformDigests[platform.String()] = entry.Digest
+		}
+
+		obj, objDiags := types.ObjectValue(registryManifestAttrTypes, map[string]attr.Value{
+			"digest":       types.StringValue(entry.Digest),
+			"media_type":   types.StringValue(entry.MediaType),
+			"platform":     types.StringValue(platform.String()),
+			"os":           types.StringValue(platform.OS),
+			"architecture": types.StringValue(platform.Architecture),
+			"variant":      types.StringValue(platform.Variant),
+			"size":         types.Int64Value(entry.Size),
+		})
+		resp.Diagnostics.Append(objDiags...)
+
+		manifests = append(manifests, obj)
+	}
+
+	manifestList, listDiags := types.ListValue(
+		types.ObjectType{AttrTypes: registryManifestAttrTypes},
+		manifests,
+	)
+	resp.Diagnostics.Append(listDiags...)
+
+	if resolved.Manifest.Annotations == nil {
+		resolved.Manifest.Annotations = map[string]string{}
+	}
+
+	annotations, annotationsDiags := types.MapValueFrom(ctx, types.StringType,
+		resolved.Manifest.Annotations)
+	resp.Diagnostics.Append(annotationsDiags...)
+
+	digests, digestsDiags := types.MapValueFrom(ctx, types.StringType, platformDigests)
+	resp.Diagnostics.Append(digestsDiags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.Digest = types.StringValue(resolved.Digest)
+	data.MediaType = types.StringValue(resolved.MediaType)
+	data.PinnedImage = types.StringValue(ref.Name() + "@" + resolved.Digest)
+	data.Annotations = annotations
+	data.PlatformDigests = digests
+	data.Manifests = manifestList
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,71 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+)
+
+func TestRegistryTagDataSource_Metadata(t *testing.T) {
+	ds := NewRegistryTagDataSource()
+	resp := &datasource.MetadataResponse{}
+	ds.Metadata(t.Context(), datasource.MetadataRequest{ProviderTypeName: providerTypeName}, resp)
+
+	if resp.TypeName != "bootc_registry_tag" {
+		t.Errorf("TypeName = %q, want %q", resp.TypeName, "bootc_registry_tag")
+	}
+}
+
+func TestRegistryTagDataSource_Schema(t *testing.T) {
+	ds := &RegistryTagDataSource{}
+	resp := &datasource.SchemaResponse{}
+	ds.Schema(t.Context(), datasource.SchemaRequest{}, resp)
+
+	image, ok := resp.Schema.Attributes["image"].(schema.StringAttribute)
+	if !ok || !image.Required {
+		t.Error("image should be a required string attribute")
+	}
+
+	for _, name := range []string{"registries_conf", "auth_file"} {
+		if attr, ok := resp.Schema.Attributes[name]; !ok || !attr.IsOptional() {
+			t.Errorf("attribute %q should be optional", name)
+		}
+	}
+
+	computed := []string{
+		"digest", "media_type", "pinned_image", "annotations", "platform_digests", "manifests",
+	}
+	for _, name := range computed {
+		if attr, ok := resp.Schema.Attributes[name]; !ok || !attr.IsComputed() {
+			t.Errorf("attribute %q should be computed", name)
+		}
+	}
+
+	manifests, ok := resp.Schema.Attributes["manifests"].(schema.ListNestedAttribute)
+	if !ok {
+		t.Fatal("manifests should be a list nested attribute")
+	}
+
+	for name := range registryManifestAttrTypes {
+		if _, ok := manifests.NestedObject.Attributes[name]; !ok {
+			t.Errorf("manifests missing nested attribute %q", name)
+		}
+	}
+}
@@ -0,0 +1,497 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	defaultFileMode = 0o644
+	defaultDirMode  = 0o755
+
+	selinuxXattr = "security.selinux"
+
+	// maxSymlinkHops bounds symlink resolution, as MAXSYMLINKS does in the
+	// kernel.
+	maxSymlinkHops = 40
+)
+
+var (
+	ErrDeploymentNotFound = errors.New("installed deployment not found")
+	ErrUnknownAccount     = errors.New("unknown account")
+	ErrInvalidFilePath    = errors.New("path must be below /etc or /var")
+)
+
+// FileModel is an entry of the files block of bootc_image.
+type FileModel struct {
+	Path    types.String `tfsdk:"path"`
+	Content types.String `tfsdk:"content"`
+	Source  types.String `tfsdk:"source"`
+	Mode    types.String `tfsdk:"mode"`
+	Owner   types.String `tfsdk:"owner"`
+	Group   types.String `tfsdk:"group"`
+}
+
+// injectedFile is a file written into the installed deployment. A
+// non-empty Symlink creates a symbolic link to that target instead.
+type injectedFile struct {
+	Path    string
+	Owner   string
+	Group   string
+	Symlink string
+	Content []byte
+	Mode    os.FileMode
+}
+
+// injectedFiles resolves the files block, reading source files from the
+// host.
+func injectedFiles(models []FileModel) ([]injectedFile, error) {
+	files := make([]injectedFile, 0, len(models))
+
+	for _, model := range models {
+		file := injectedFile{
+			Path:  model.Path.ValueString(),
+			Owner: model.Owner.ValueString(),
+			Group: model.Group.ValueString(),
+			Mode:  defaultFileMode,
+		}
+
+		if !model.Mode.IsNull() {
+			mode, err := strconv.ParseUint(model.Mode.ValueString(), 8, 32)
+			if err != nil {
+				return nil, fmt.Errorf("%s: mode: %w", file.Path, err)
+			}
+
+			file.Mode = octalFileMode(mode)
+		}
+
+		if model.Source.IsNull() {
+			file.Content = []byte(model.Content.ValueString())
+		} else {
+			content, err := os.ReadFile(model.Source.ValueString())
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", file.Path, err)
+			}
+
+			file.Content = content
+		}
+
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// installedDeployment locates the deployment bootc install wrote to a
+// mounted root filesystem.
+type installedDeployment struct {
+	// Dir is the deployment checkout, ostree/deploy/<stateroot>/deploy/<checksum>.<serial>.
+	Dir string
+	// Var is the stateroot's /var, shared by all deployments.
+	Var string
+	// Sysroot is the mounted root filesystem.
+	Sysroot string
+}
+
+// findDeployment returns the single deployment below sysroot.
+func findDeployment(sysroot string) (installedDeployment, error) {
+	candidates, err := filepath.Glob(filepath.Join(sysroot, "ostree", "deploy", "*", "deploy", "*"))
+	if err != nil {
+		return installedDeployment{}, err
+	}
+
+	var dirs []string
+
+	for _, candidate := range candidates {
+		if info, statErr := os.Stat(candidate); statErr == nil && info.IsDir() {
+			dirs = append(dirs, candidate)
+		}
+	}
+
+	if len(dirs) != 1 {
+		return installedDeployment{}, fmt.Errorf("%w: found %d deployments below %s", ErrDeploymentNotFound, len(dirs), sysroot)
+	}
+
+	return installedDeployment{
+		Dir:     dirs[0],
+		Var:     filepath.Join(filepath.Dir(filepath.Dir(dirs[0])), "var"),
+		Sysroot: sysroot,
+	}, nil
+}
+
+// HostPath maps an absolute path of the booted system below /etc or /var
+// to its location in the deployment. Symlinks in the parent directories are
+// resolved the way the booted system would, inside the deployment, so an
+// absolute link in the image cannot lead the build host outside of it, and
+// the resolved path must still be below /etc or /var. The last component is
+// not followed; writers replace it.
+func (d installedDeployment) HostPath(target string) (string, error) {
+	clean := filepath.Clean(target)
+	if !persistentPath(clean) {
+		return "", fmt.Errorf("%w: %s", ErrInvalidFilePath, target)
+	}
+
+	parent, err := d.resolveBooted(path.Dir(clean))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", target, err)
+	}
+
+	resolved := path.Join(parent, path.Base(clean))
+	if !persistentPath(resolved) {
+		return "", fmt.Errorf("%w: %s resolves to %s", ErrInvalidFilePath, target, resolved)
+	}
+
+	return d.bootedToHost(resolved), nil
+}
+
+// persistentPath reports whether a clean path of the booted system is below
+// /etc or /var.
+func persistentPath(bootedPath string) bool {
+	return strings.HasPrefix(bootedPath, "/etc/") || strings.HasPrefix(bootedPath, "/var/")
+}
+
+// bootedToHost maps a clean absolute path of the booted system to the
+// deployment without resolving symlinks. /var is the stateroot's.
+func (d installedDeployment) bootedToHost(bootedPath string) string {
+	if bootedPath == "/var" || strings.HasPrefix(bootedPath, "/var/") {
+		return filepath.Join(d.Var, strings.TrimPrefix(bootedPath, "/var"))
+	}
+
+	return filepath.Join(d.Dir, bootedPath)
+}
+
+// resolveBooted resolves the symlinks in dir, an absolute path of the booted
+// system, against the deployment and returns it without symlinks. Absolute
+// link targets restart at the deployment root and .. stops there. Missing
+// components are kept, as they are created later.
+func (d installedDeployment) resolveBooted(dir string) (string, error) {
+	resolved := "/"
+	remaining := strings.Split(dir, "/")
+
+	for hops := 0; len(remaining) > 0; {
+		name := remaining[0]
+		remaining = remaining[1:]
+
+		switch name {
+		case "", ".":
+			continue
+		case "..":
+			resolved = path.Dir(resolved)
+
+			continue
+		}
+
+		next := path.Join(resolved, name)
+
+		info, err := os.Lstat(d.bootedToHost(next))
+		if errors.Is(err, os.ErrNotExist) || (err == nil && info.Mode()&os.ModeSymlink == 0) {
+			resolved = next
+
+			continue
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		hops++
+		if hops > maxSymlinkHops {
+			return "", fmt.Errorf("%s: %w", next, unix.ELOOP)
+		}
+
+		link, err := os.Readlink(d.bootedToHost(next))
+		if err != nil {
+			return "", err
+		}
+
+		if path.IsAbs(link) {
+			resolved = "/"
+		}
+
+		remaining = append(strings.Split(link, "/"), remaining...)
+	}
+
+	return resolved, nil
+}
+
+// DeployPath maps an absolute path of the booted system to the deployment
+// checkout, without the /etc and /var restrictions of HostPath.
+func (d installedDeployment) DeployPath(target string) string {
+	return filepath.Join(d.Dir, filepath.Clean("/"+target))
+}
+
+// WriteFile writes a file into the deployment. Ownership is resolved
+// against the deployment's account databases, and new files and
+// directories inherit the SELinux label of their parent directory.
+func (d installedDeployment) WriteFile(file injectedFile, mtime *time.Time) error {
+	target, err := d.HostPath(file.Path)
+	if err != nil {
+		return err
+	}
+
+	uid, err := d.lookupID(file.Owner, "passwd")
+	if err != nil {
+		return fmt.Errorf("%s: owner: %w", file.Path, err)
+	}
+
+	gid, err := d.lookupID(file.Group, "group")
+	if err != nil {
+		return fmt.Errorf("%s: group: %w", file.Path, err)
+	}
+
+	err = mkdirAllLabeled(filepath.Dir(target))
+	if err != nil {
+		return err
+	}
+
+	if file.Symlink != "" {
+		err = replaceSymlink(file.Symlink, target)
+	} else {
+		err = writeFileMode(target, file.Content, file.Mode)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = os.Lchown(target, uid, gid)
+	if err != nil {
+		return err
+	}
+
+	// chown clears the setuid and setgid bits, so the mode is set again.
+	if file.Symlink == "" && file.Mode&(os.ModeSetuid|os.ModeSetgid) != 0 {
+		err = os.Chmod(target, file.Mode)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = copySELinuxLabel(filepath.Dir(target), target)
+	if err != nil {
+		return err
+	}
+
+	if mtime == nil {
+		return nil
+	}
+
+	return lchtimes(target, *mtime)
+}
+
+// octalFileMode converts an octal mode such as 4755 to an os.FileMode,
+// whose setuid, setgid and sticky bits are not the octal ones.
+func octalFileMode(octal uint64) os.FileMode {
+	mode := os.FileMode(octal) & os.ModePerm
+
+	for bit, flag := range map[uint64]os.FileMode{0o4000: os.ModeSetuid, 0o2000: os.ModeSetgid, 0o1000: os.ModeSticky} {
+		if octal&bit != 0 {
+			mode |= flag
+		}
+	}
+
+	return mode
+}
+
+// lchtimes sets the access and modification times of target without
+// following symlinks, which may point into the installed system (such as
+// masks to /dev/null) and must not touch the build host.
+func lchtimes(target string, mtime time.Time) error {
+	times := []unix.Timespec{unix.NsecToTimespec(mtime.UnixNano()), unix.NsecToTimespec(mtime.UnixNano())}
+
+	return unix.UtimesNanoAt(unix.AT_FDCWD, target, times, unix.AT_SYMLINK_NOFOLLOW)
+}
+
+// writeFileMode writes content with exactly mode; os.WriteFile applies the
+// umask and keeps the mode of existing files.
+func writeFileMode(target string, content []byte, mode os.FileMode) error {
+	if info, err := os.Lstat(target); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if err := os.Remove(target); err != nil {
+			return err
+		}
+	}
+
+	err := os.WriteFile(target, content, mode)
+	if err != nil {
+		return err
+	}
+
+	return os.Chmod(target, mode)
+}
+
+// replaceSymlink points target at linkTarget, replacing any existing file.
+func replaceSymlink(linkTarget, target string) error {
+	err := os.Remove(target)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return os.Symlink(linkTarget, target)
+}
+
+// lookupID resolves a user or group name to its numeric ID using the
+// deployment's /etc and /usr/lib databases. Empty names mean root.
+func (d installedDeployment) lookupID(name, database string) (int, error) {
+	if name == "" {
+		return 0, nil
+	}
+
+	if id, err := strconv.Atoi(name); err == nil {
+		return id, nil
+	}
+
+	for _, dbPath := range []string{
+		filepath.Join(d.Dir, "etc", database),
+		filepath.Join(d.Dir, "usr", "lib", database),
+	} {
+		id, found, err := lookupIDInFile(dbPath, name)
+		if err != nil || found {
+			return id, err
+		}
+	}
+
+	return 0, fmt.Errorf("%w: %s", ErrUnknownAccount, name)
+}
+
+// lookupIDInFile scans a passwd(5) or group(5) file for name; the ID is
+// the third field in both formats.
+func lookupIDInFile(dbPath, name string) (int, bool, error) {
+	db, err := os.Open(dbPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, false, nil
+	}
+
+	if err != nil {
+		return 0, false, err
+	}
+	defer db.Close()
+
+	scanner := bufio.NewScanner(db)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ":")
+		if len(fields) < 3 || fields[0] != name {
+			continue
+		}
+
+		id, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return 0, false, fmt.Errorf("%s: %w", dbPath, err)
+		}
+
+		return id, true, nil
+	}
+
+	return 0, false, scanner.Err()
+}
+
+// mkdirAllLabeled creates dir and any missing parents, copying the SELinux
+// label of the nearest existing ancestor onto each new directory.
+func mkdirAllLabeled(dir string) error {
+	if _, err := os.Stat(dir); err == nil {
+		return nil
+	}
+
+	parent := filepath.Dir(dir)
+	if parent != dir {
+		if err := mkdirAllLabeled(parent); err != nil {
+			return err
+		}
+	}
+
+	err := os.Mkdir(dir, defaultDirMode)
+	if err != nil && !errors.Is(err, os.ErrExist) {
+		return err
+	}
+
+	return copySELinuxLabel(parent, dir)
+}
+
+// copySELinuxLabel copies the security.selinux attribute from one path to
+// another without following symlinks. Filesystems or hosts without labels
+// are silently skipped.
+func copySELinuxLabel(from, to string) error {
+	label := make([]byte, 256)
+
+	size, err := unix.Lgetxattr(from, selinuxXattr, label)
+	if errors.Is(err, unix.ENODATA) || errors.Is(err, unix.ENOTSUP) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("getxattr %s: %w", from, err)
+	}
+
+	err = unix.Lsetxattr(to, selinuxXattr, label[:size], 0)
+	if err != nil && !errors.Is(err, unix.ENOTSUP) {
+		return fmt.Errorf("setxattr %s: %w", to, err)
+	}
+
+	return nil
+}
+
+// customizeDeployment mounts the root filesystem of a freshly installed raw
+// disk and calls customize with its deployment.
+func customizeDeployment(ctx context.Context, rawPath string, customize func(installedDeployment) error) error {
+	partitions, err := readInstalledPartitions(ctx, rawPath)
+	if err != nil {
+		return err
+	}
+
+	var root *installedPartition
+
+	for idx := range partitions {
+		if partitions[idx].IsRoot() {
+			root = &partitions[idx]
+
+			break
+		}
+	}
+
+	if root == nil {
+		return fmt.Errorf("%w: no root partition", ErrDeploymentNotFound)
+	}
+
+	mounts, err := newDiskMounts(rawPath)
+	if err != nil {
+		return err
+	}
+
+	sysroot, err := mounts.Mount(ctx, *root)
+	if err == nil {
+		var deployment installedDeployment
+
+		deployment, err = findDeployment(sysroot)
+		if err == nil {
+			err = customize(deployment)
+		}
+	}
+
+	return errors.Join(err, mounts.Close(ctx))
+}
@@ -0,0 +1,315 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const testDeploymentSuffix = "ostree/deploy/default/deploy/3f2a9c.0"
+
+// buildTestDeployment lays out an ostree sysroot with one deployment and
+// returns the sysroot path.
+func buildTestDeployment(t *testing.T) string {
+	t.Helper()
+
+	sysroot := t.TempDir()
+	deployDir := filepath.Join(sysroot, testDeploymentSuffix)
+
+	for _, dir := range []string{
+		filepath.Join(deployDir, "etc"),
+		filepath.Join(deployDir, "usr", "lib"),
+		filepath.Join(sysroot, "ostree", "deploy", "default", "var"),
+	} {
+		if err := os.MkdirAll(dir, testDefaultDirPerms); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files := map[string]string{
+		deployDir + ".origin":                       "[origin]\n",
+		filepath.Join(deployDir, "etc", "passwd"):   "root:x:0:0:root:/root:/bin/bash\nadmin:x:1000:1000::/home/admin:/bin/bash\n",
+		filepath.Join(deployDir, "usr/lib/passwd"):  "chrony:x:994:992::/var/lib/chrony:/sbin/nologin\n",
+		filepath.Join(deployDir, "usr/lib/group"):   "root:x:0:\nchrony:x:992:\n",
+		filepath.Join(deployDir, "etc", "hostname"): "localhost\n",
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(name, []byte(content), testSecureFilePerms); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return sysroot
+}
+
+func TestFindDeployment(t *testing.T) {
+	sysroot := buildTestDeployment(t)
+
+	deployment, err := findDeployment(sysroot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if deployment.Dir != filepath.Join(sysroot, testDeploymentSuffix) {
+		t.Errorf("Dir = %q", deployment.Dir)
+	}
+
+	if deployment.Var != filepath.Join(sysroot, "ostree", "deploy", "default", "var") {
+		t.Errorf("Var = %q", deployment.Var)
+	}
+
+	if _, err := findDeployment(t.TempDir()); !errors.Is(err, ErrDeploymentNotFound) {
+		t.Errorf("expected ErrDeploymentNotFound, got %v", err)
+	}
+}
+
+func TestInstalledDeployment_HostPath(t *testing.T) {
+	deployment := installedDeployment{Dir: "/sysroot/deploy/abc.0", Var: "/sysroot/var"}
+
+	tests := []struct {
+		name    string
+		target  string
+		want    string
+		wantErr bool
+	}{
+		{"etc", "/etc/chrony.conf", "/sysroot/deploy/abc.0/etc/chrony.conf", false},
+		{"var", "/var/lib/app/config", "/sysroot/var/lib/app/config", false},
+		{"usr", "/usr/bin/tool", "", true},
+		{"escape", "/etc/../usr/bin/tool", "", true},
+		{"etc_itself", "/etc", "", true},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := deployment.HostPath(testCase.target)
+			if testCase.wantErr {
+				if !errors.Is(err, ErrInvalidFilePath) {
+					t.Errorf("expected ErrInvalidFilePath, got %q, %v", got, err)
+				}
+
+				return
+			}
+
+			if err != nil || got != testCase.want {
+				t.Errorf("HostPath = %q, %v, want %q", got, err, testCase.want)
+			}
+		})
+	}
+}
+
+func TestInstalledDeployment_LookupID(t *testing.T) {
+	deployment, err := findDeployment(buildTestDeployment(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		account  string
+		database string
+		want     int
+		wantErr  bool
+	}{
+		{"default_root", "", "passwd", 0, false},
+		{"numeric", "1234", "passwd", 1234, false},
+		{"etc_user", "admin", "passwd", 1000, false},
+		{"usr_lib_user", "chrony", "passwd", 994, false},
+		{"usr_lib_group", "chrony", "group", 992, false},
+		{"unknown", "nobody-here", "passwd", 0, true},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := deployment.lookupID(testCase.account, testCase.database)
+			if testCase.wantErr {
+				if !errors.Is(err, ErrUnknownAccount) {
+					t.Errorf("expected ErrUnknownAccount, got %d, %v", got, err)
+				}
+
+				return
+			}
+
+			if err != nil || got != testCase.want {
+				t.Errorf("lookupID = %d, %v, want %d", got, err, testCase.want)
+			}
+		})
+	}
+}
+
+func TestInstalledDeployment_WriteFile(t *testing.T) {
+	deployment, err := findDeployment(buildTestDeployment(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mtime := time.Unix(1700000000, 0)
+	uid, gid := strconv.Itoa(os.Getuid()), strconv.Itoa(os.Getgid())
+
+	files := []injectedFile{
+		{Path: "/etc/ssh/sshd_config.d/50-local.conf", Content: []byte("PermitRootLogin no\n"), Mode: 0o600, Owner: uid, Group: gid},
+		{Path: "/var/lib/app/seed", Content: []byte("seed"), Mode: 0o640, Owner: uid, Group: gid},
+		{Path: "/etc/hostname", Content: []byte("node1\n"), Mode: 0o644, Owner: uid, Group: gid},
+		{Path: "/var/lib/app/helper", Content: []byte("#!/bin/sh\n"), Mode: octalFileMode(0o4755), Owner: uid, Group: gid},
+		{Path: "/var/lib/app/shared", Content: []byte("shared"), Mode: octalFileMode(0o3775), Owner: uid, Group: gid},
+	}
+
+	for _, file := range files {
+		if err := deployment.WriteFile(file, &mtime); err != nil {
+			t.Fatal(err)
+		}
+
+		target, err := deployment.HostPath(file.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		info, err := os.Stat(target)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := info.Mode() &^ os.ModeType; got != file.Mode {
+			t.Errorf("%s: mode = %v, want %v", file.Path, got, file.Mode)
+		}
+
+		if !info.ModTime().Equal(mtime) {
+			t.Errorf("%s: mtime = %v", file.Path, info.ModTime())
+		}
+
+		if got, _ := os.ReadFile(target); string(got) != string(file.Content) {
+			t.Errorf("%s: content = %q", file.Path, got)
+		}
+	}
+
+	err = deployment.WriteFile(injectedFile{Path: "/etc/x", Owner: "ghost"}, nil)
+	if !errors.Is(err, ErrUnknownAccount) {
+		t.Errorf("expected ErrUnknownAccount, got %v", err)
+	}
+}
+
+func TestInstalledDeployment_WriteFileSymlinkedParent(t *testing.T) {
+	deployment, err := findDeployment(buildTestDeployment(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outside := t.TempDir()
+	uid, gid := strconv.Itoa(os.Getuid()), strconv.Itoa(os.Getgid())
+
+	for link, linkTarget := range map[string]string{
+		filepath.Join(deployment.Dir, "etc", "app"):     outside,
+		filepath.Join(deployment.Dir, "etc", "climb"):   "../../../../../../../..",
+		filepath.Join(deployment.Dir, "etc", "conf.d"):  "/etc/real",
+		filepath.Join(deployment.Var, "cache"):          "/var/lib/cache",
+		filepath.Join(deployment.Dir, "etc", "loop"):    "/etc/loop",
+		filepath.Join(deployment.Dir, "etc", "usrlink"): "/usr/lib",
+	} {
+		if err := os.Symlink(linkTarget, link); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, target := range []string{"/etc/app/config.conf", "/etc/climb" + outside + "/config.conf", "/etc/usrlink/passwd"} {
+		err = deployment.WriteFile(injectedFile{Path: target, Content: []byte("x"), Mode: 0o644, Owner: uid, Group: gid}, nil)
+		if !errors.Is(err, ErrInvalidFilePath) {
+			t.Errorf("%s: expected ErrInvalidFilePath, got %v", target, err)
+		}
+	}
+
+	if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+		t.Errorf("WriteFile wrote %d entries outside the deployment", len(entries))
+	}
+
+	if _, err := deployment.HostPath("/etc/loop/config.conf"); err == nil {
+		t.Error("expected an error for a symlink loop")
+	}
+
+	for target, want := range map[string]string{
+		"/etc/conf.d/app.conf": filepath.Join(deployment.Dir, "etc", "real", "app.conf"),
+		"/var/cache/app/data":  filepath.Join(deployment.Var, "lib", "cache", "app", "data"),
+	} {
+		err = deployment.WriteFile(injectedFile{Path: target, Content: []byte("x"), Mode: 0o644, Owner: uid, Group: gid}, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", target, err)
+		}
+
+		if _, err := os.Stat(want); err != nil {
+			t.Errorf("%s: %v", target, err)
+		}
+	}
+}
+
+func TestInjectedFiles(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(source, []byte("-----BEGIN CERTIFICATE-----\n"), testSecureFilePerms); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := injectedFiles([]FileModel{
+		{
+			Path:    types.StringValue("/etc/chrony.conf"),
+			Content: types.StringValue("server ntp.example.com iburst\n"),
+			Source:  types.StringNull(),
+			Mode:    types.StringNull(),
+		},
+		{
+			Path:    types.StringValue("/etc/pki/ca-trust/source/anchors/corp.pem"),
+			Content: types.StringNull(),
+			Source:  types.StringValue(source),
+			Mode:    types.StringValue("0444"),
+		},
+		{
+			Path:    types.StringValue("/etc/sudo-helper"),
+			Content: types.StringValue("#!/bin/sh\n"),
+			Source:  types.StringNull(),
+			Mode:    types.StringValue("4755"),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if files[0].Mode != defaultFileMode || string(files[0].Content) != "server ntp.example.com iburst\n" {
+		t.Errorf("files[0] = %+v", files[0])
+	}
+
+	if files[1].Mode != 0o444 || string(files[1].Content) != "-----BEGIN CERTIFICATE-----\n" {
+		t.Errorf("files[1] = %+v", files[1])
+	}
+
+	if files[2].Mode != os.ModeSetuid|0o755 {
+		t.Errorf("files[2].Mode = %v, want setuid 0755", files[2].Mode)
+	}
+
+	_, err = injectedFiles([]FileModel{{
+		Path:   types.StringValue("/etc/missing"),
+		Source: types.StringValue(filepath.Join(t.TempDir(), "missing")),
+	}})
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected os.ErrNotExist, got %v", err)
+	}
+}
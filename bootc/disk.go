@@ -0,0 +1,421 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// blkidNothingFound is blkid's exit status when no signature was detected.
+const blkidNothingFound = 2
+
+// rootPartitionTypes are the Discoverable Partitions Specification root
+// partition type GUIDs for the architectures bootc supports.
+var rootPartitionTypes = []string{
+	"4f68bce3-e8cd-4db1-96e7-fbcaf984b709", // x86-64
+	"b921b045-1df0-41c3-af44-4c6f280d3fae", // aarch64
+	"c31c45e6-3f39-412e-80fb-4809c4980599", // ppc64le
+	"5eead9a9-fe09-4a1e-a1d7-520d00531306", // s390x
+	"72ec70a6-cf74-40e6-bd49-4bda08e8f224", // riscv64
+}
+
+// installedPartition describes a partition of a freshly installed disk.
+type installedPartition struct {
+	Label      string
+	TypeGUID   string
+	PartUUID   string
+	Filesystem string
+	UUID       string
+	FSLabel    string
+	Start      int64
+	Size       int64
+	Number     int
+}
+
+// IsRoot reports whether the partition holds the root filesystem.
+func (p installedPartition) IsRoot() bool {
+	return slices.Contains(rootPartitionTypes, p.TypeGUID) || p.Label == "root" || p.FSLabel == "root"
+}
+
+// readInstalledPartitions reads the GPT or MBR of a raw disk image and
+// probes each partition's filesystem with blkid. MBR partitions are read
+// with the sector size of ctx.
+func readInstalledPartitions(ctx context.Context, rawPath string) ([]installedPartition, error) {
+	disk, err := os.Open(rawPath)
+	if err != nil {
+		return nil, err
+	}
+	defer disk.Close()
+
+	tableType, err := detectPartitionTable(disk)
+	if err != nil {
+		return nil, err
+	}
+
+	if tableType == partitionTableMBR {
+		return readInstalledMBR(ctx, rawPath, disk)
+	}
+
+	table, err := readGPT(disk)
+	if err != nil {
+		return nil, err
+	}
+
+	partitions := make([]installedPartition, 0, len(table.Partitions))
+
+	for _, part := range table.Partitions {
+		start, size := table.Start(part), table.Size(part)
+
+		fs, probeErr := probeFilesystem(ctx, rawPath, start, size)
+		if probeErr != nil {
+			return nil, fmt.Errorf("partition %d: %w", part.Number, probeErr)
+		}
+
+		partitions = append(partitions, installedPartition{
+			Number:     part.Number,
+			Label:      part.Name,
+			TypeGUID:   part.TypeGUID,
+			PartUUID:   part.GUID,
+			Filesystem: fs["TYPE"],
+			UUID:       fs["UUID"],
+			FSLabel:    fs["LABEL"],
+			Start:      start,
+			Size:       size,
+		})
+	}
+
+	return partitions, nil
+}
+
+// readInstalledMBR reads the primary MBR partitions of a raw disk image.
+// MBR partitions have no label, and their type is the MBR type in hex.
+func readInstalledMBR(ctx context.Context, rawPath string, disk io.ReaderAt) ([]installedPartition, error) {
+	table, err := readMBR(disk, diskSectorSize(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	partitions := make([]installedPartition, 0, len(table.Partitions))
+
+	for _, part := range table.Partitions {
+		start, size := table.Start(part), table.Size(part)
+
+		fs, probeErr := probeFilesystem(ctx, rawPath, start, size)
+		if probeErr != nil {
+			return nil, fmt.Errorf("partition %d: %w", part.Number, probeErr)
+		}
+
+		partitions = append(partitions, installedPartition{
+			Number:     part.Number,
+			TypeGUID:   fmt.Sprintf("%02x", part.Type),
+			PartUUID:   table.PartUUID(part),
+			Filesystem: fs["TYPE"],
+			UUID:       fs["UUID"],
+			FSLabel:    fs["LABEL"],
+			Start:      start,
+			Size:       size,
+		})
+	}
+
+	return partitions, nil
+}
+
+// probeFilesystem runs blkid in low-level probing mode on a byte range of a
+// disk image. An empty map means no filesystem signature was found.
+func probeFilesystem(ctx context.Context, rawPath string, offset, size int64) (map[string]string, error) {
+	var stdout, stderr bytes.Buffer
+
+	//nolint:gosec // G204: blkid is a trusted system command with validated inputs
+	cmd := exec.CommandContext(ctx, "blkid", "--probe", "--output", "export",
+		"--offset", strconv.FormatInt(offset, 10), "--size", strconv.FormatInt(size, 10), rawPath)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	runErr := cmd.Run()
+
+	var exitErr *exec.ExitError
+	if errors.As(runErr, &exitErr) && exitErr.ExitCode() == blkidNothingFound {
+		return map[string]string{}, nil
+	}
+
+	if runErr != nil {
+		return nil, fmt.Errorf("blkid: %w: %s", runErr, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	return parseShellVars(stdout.Bytes()), nil
+}
+
+// probeDevice runs blkid in low-level probing mode on a block device.
+func probeDevice(ctx context.Context, dev string) (map[string]string, error) {
+	out, err := runCommand(ctx, "blkid", "--probe", "--output", "export", dev)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseShellVars(out), nil
+}
+
+// diskMounts tracks the loop devices, unlocked LUKS containers and mounts
+// created for partitions of a raw disk image so Close can tear them down
+// in reverse order.
+type diskMounts struct {
+	rawPath string
+	dir     string
+	loops   []string
+	mappers []string
+	mounts  []string
+}
+
+func newDiskMounts(rawPath string) (*diskMounts, error) {
+	dir, err := os.MkdirTemp("", "bootc-mnt-")
+	if err != nil {
+		return nil, err
+	}
+
+	return &diskMounts{rawPath: rawPath, dir: dir}, nil
+}
+
+// Attach binds a partition to a loop device and returns the device path.
+func (d *diskMounts) Attach(ctx context.Context, part installedPartition) (string, error) {
+	args := []string{"--find", "--show",
+		"--offset", strconv.FormatInt(part.Start, 10),
+		"--sizelimit", strconv.FormatInt(part.Size, 10)}
+
+	out, err := runCommand(ctx, "losetup", append(append(args, loopSectorArgs(ctx)...), d.rawPath)...)
+	if err != nil {
+		return "", err
+	}
+
+	dev := strings.TrimSpace(string(out))
+	d.loops = append(d.loops, dev)
+
+	return dev, nil
+}
+
+// AttachDisk binds the whole disk image to a loop device with partition
+// scanning, so tools that look up the parent disk of a mount find it, and
+// returns the device path. Partition N is available as <device>pN once
+// udev has processed the partition events, which AttachDisk waits for.
+func (d *diskMounts) AttachDisk(ctx context.Context) (string, error) {
+	args := append([]string{"--find", "--show", "--partscan"}, loopSectorArgs(ctx)...)
+
+	out, err := runCommand(ctx, "losetup", append(args, d.rawPath)...)
+	if err != nil {
+		return "", err
+	}
+
+	dev := strings.TrimSpace(string(out))
+	d.loops = append(d.loops, dev)
+
+	_, err = runCommand(ctx, "udevadm", "settle")
+	if err != nil {
+		return "", err
+	}
+
+	return dev, nil
+}
+
+// Mount attaches a partition and mounts its filesystem, returning the mount point.
+func (d *diskMounts) Mount(ctx context.Context, part installedPartition) (string, error) {
+	target := filepath.Join(d.dir, "p"+strconv.Itoa(part.Number))
+
+	err := d.MountAt(ctx, part, target)
+	if err != nil {
+		return "", err
+	}
+
+	return target, nil
+}
+
+// MountAt attaches a partition and mounts its filesystem on target, which
+// is created if needed. LUKS containers are unlocked first.
+func (d *diskMounts) MountAt(ctx context.Context, part installedPartition, target string) error {
+	dev, err := d.Attach(ctx, part)
+	if err != nil {
+		return err
+	}
+
+	fsType := part.Filesystem
+
+	if fsType == luksFilesystem {
+		var fs map[string]string
+
+		dev, err = d.Unlock(ctx, dev)
+		if err == nil {
+			fs, err = probeDevice(ctx, dev)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		fsType = fs["TYPE"]
+	}
+
+	return d.mountDevice(ctx, dev, fsType, target)
+}
+
+// Unlock opens the LUKS container on dev and returns the unlocked device.
+// It uses the passphrase of ctx when set, otherwise the TPM2 of the build
+// host as tpm2-luks images are bound to it.
+func (d *diskMounts) Unlock(ctx context.Context, dev string) (string, error) {
+	name := "bootc-" + filepath.Base(dev)
+
+	var err error
+
+	if passphrase := luksPassphrase(ctx); passphrase != nil {
+		_, err = runCommandInput(ctx, nil, passphrase, "cryptsetup", "open", "--key-file", "-", dev, name)
+	} else {
+		_, err = runCommand(ctx, "systemd-cryptsetup", "attach", name, dev, "-", tpm2LUKSOptions)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	d.mappers = append(d.mappers, name)
+
+	return filepath.Join("/dev/mapper", name), nil
+}
+
+func (d *diskMounts) mountDevice(ctx context.Context, dev, fsType, target string, options ...string) error {
+	err := os.MkdirAll(target, 0o700)
+	if err != nil {
+		return err
+	}
+
+	args := []string{"-t", fsType}
+	if len(options) > 0 {
+		args = append(args, "-o", strings.Join(options, ","))
+	}
+
+	_, err = runCommand(ctx, "mount", append(args, dev, target)...)
+	if err != nil {
+		return err
+	}
+
+	d.mounts = append(d.mounts, target)
+
+	return nil
+}
+
+// Close unmounts, locks and detaches everything in reverse order, even
+// when ctx has been cancelled, and removes the scratch directory.
+func (d *diskMounts) Close(ctx context.Context) error {
+	ctx = context.WithoutCancel(ctx)
+
+	var errs []error
+
+	for _, target := range slices.Backward(d.mounts) {
+		if _, err := runCommand(ctx, "umount", target); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, name := range slices.Backward(d.mappers) {
+		if _, err := runCommand(ctx, "cryptsetup", "close", name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, dev := range slices.Backward(d.loops) {
+		if _, err := runCommand(ctx, "losetup", "--detach", dev); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	d.mounts, d.mappers, d.loops = nil, nil, nil
+
+	if len(errs) == 0 {
+		errs = append(errs, os.RemoveAll(d.dir))
+	}
+
+	return errors.Join(errs...)
+}
+
+// bootFilesystem returns the partition holding /boot and the directory of
+// /boot within it.
+func bootFilesystem(partitions []installedPartition) (installedPartition, string, bool) {
+	for _, part := range partitions {
+		if part.Label == "boot" || part.FSLabel == "boot" {
+			return part, "", true
+		}
+	}
+
+	for _, part := range partitions {
+		if part.IsRoot() {
+			return part, "boot", true
+		}
+	}
+
+	return installedPartition{}, "", false
+}
+
+// customizeBoot mounts the filesystem holding /boot of a raw disk and
+// calls customize with the /boot directory. Without a separate boot
+// partition, /boot of the root filesystem is used.
+func customizeBoot(ctx context.Context, rawPath string, customize func(bootDir string) error) error {
+	partitions, err := readInstalledPartitions(ctx, rawPath)
+	if err != nil {
+		return err
+	}
+
+	boot, subdir, found := bootFilesystem(partitions)
+	if !found {
+		return fmt.Errorf("%w: no boot or root partition", ErrDeploymentNotFound)
+	}
+
+	mounts, err := newDiskMounts(rawPath)
+	if err != nil {
+		return err
+	}
+
+	mountPoint, err := mounts.Mount(ctx, boot)
+	if err == nil {
+		err = customize(filepath.Join(mountPoint, subdir))
+	}
+
+	return errors.Join(err, mounts.Close(ctx))
+}
+
+// fileSHA256 returns the hex-encoded SHA-256 digest of a file.
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	sum := sha256.New()
+	if _, err := io.Copy(sum, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
@@ -0,0 +1,99 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInstalledPartition_IsRoot(t *testing.T) {
+	tests := []struct {
+		name string
+		part installedPartition
+		want bool
+	}{
+		{"root_type", installedPartition{TypeGUID: testRootTypeGUID}, true},
+		{"root_label", installedPartition{Label: "root"}, true},
+		{"root_fs_label", installedPartition{FSLabel: "root"}, true},
+		{"esp", installedPartition{TypeGUID: testESPTypeGUID, Label: "EFI-SYSTEM"}, false},
+		{"boot", installedPartition{Label: "boot", FSLabel: "boot"}, false},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := testCase.part.IsRoot(); got != testCase.want {
+				t.Errorf("IsRoot() = %v, want %v", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestReadInstalledPartitions(t *testing.T) {
+	requireCmd(t, "blkid")
+
+	rawPath := filepath.Join(t.TempDir(), "disk.raw")
+	disk := buildTestGPT(t, 512, []testGPTPartition{
+		{testESPTypeGUID, "11111111-2222-3333-4444-555555555555", "EFI-SYSTEM", 34, 40},
+		{testRootTypeGUID, "66666666-7777-8888-9999-aaaaaaaaaaaa", "root", 41, 60},
+	})
+
+	if err := os.WriteFile(rawPath, disk, testSecureFilePerms); err != nil {
+		t.Fatal(err)
+	}
+
+	partitions, err := readInstalledPartitions(t.Context(), rawPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(partitions) != 2 {
+		t.Fatalf("partitions = %d, want 2", len(partitions))
+	}
+
+	root := partitions[1]
+	if !root.IsRoot() || root.Number != 2 || root.Start != 41*512 || root.Size != 20*512 {
+		t.Errorf("root = %+v", root)
+	}
+
+	if root.Filesystem != "" || root.UUID != "" {
+		t.Errorf("expected no filesystem on zeroed partition, got %+v", root)
+	}
+}
+
+func TestFileSHA256(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "disk.qcow2")
+	if err := os.WriteFile(target, []byte("bootc"), testSecureFilePerms); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := fileSHA256(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const want = "c849269db7b370c15155e0d0329420728b3e06b6a5cd4c2659219ac34bd51691"
+	if got != want {
+		t.Errorf("digest = %q, want %q", got, want)
+	}
+
+	if _, err := fileSHA256(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
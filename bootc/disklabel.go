@@ -0,0 +1,267 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+const (
+	nativeSectorSize = 4096
+
+	mbrDiskIDOffset  = 440
+	mbrEntriesOffset = 446
+	mbrEntrySize     = 16
+	mbrEntries       = 4
+	mbrBootable      = 0x80
+
+	// MBR partition types sfdisk writes for the layout's GPT types.
+	mbrESPType   = "ef"
+	mbrSwapType  = "82"
+	mbrLinuxType = "83"
+)
+
+var ErrInvalidMBR = errors.New("invalid MBR")
+
+// mbrExtendedTypes are the MBR types of extended partition containers,
+// whose logical partitions the layout never creates.
+var mbrExtendedTypes = []byte{0x05, 0x0f, 0x85}
+
+// diskLabel is the partition table and logical sector size of a disk the
+// provider partitions.
+type diskLabel struct {
+	Table      string
+	SectorSize int64
+	// BIOSBoot creates a BIOS boot partition on GPT and marks /boot active
+	// on MBR, so GRUB can boot the disk from legacy BIOS.
+	BIOSBoot bool
+}
+
+// diskLabel resolves partition_table, sector_size and bios_boot. BIOS boot
+// defaults to the build host being x86_64, as bootc install to-disk does.
+func (m *ImageResourceModel) diskLabel() diskLabel {
+	label := diskLabel{
+		Table:      partitionTableGPT,
+		SectorSize: defaultSectorSize,
+		BIOSBoot:   hostArchitecture() == "x86_64",
+	}
+
+	if !m.PartitionTable.IsNull() {
+		label.Table = m.PartitionTable.ValueString()
+	}
+
+	if !m.SectorSize.IsNull() {
+		label.SectorSize = m.SectorSize.ValueInt64()
+	}
+
+	if !m.BIOSBoot.IsNull() {
+		label.BIOSBoot = m.BIOSBoot.ValueBool()
+	}
+
+	return label
+}
+
+// sectorSizeKey is the context key of the logical sector size of the disk
+// image being built.
+type sectorSizeKey struct{}
+
+// withSectorSize returns a context whose loop devices and partition table
+// reads use sectorSize.
+func withSectorSize(ctx context.Context, sectorSize int64) context.Context {
+	return context.WithValue(ctx, sectorSizeKey{}, sectorSize)
+}
+
+func diskSectorSize(ctx context.Context) int64 {
+	sectorSize, ok := ctx.Value(sectorSizeKey{}).(int64)
+	if !ok {
+		return defaultSectorSize
+	}
+
+	return sectorSize
+}
+
+// loopSectorArgs returns the losetup arguments that give a loop device the
+// logical sector size of ctx.
+func loopSectorArgs(ctx context.Context) []string {
+	if sectorSize := diskSectorSize(ctx); sectorSize != defaultSectorSize {
+		return []string{"--sector-size", strconv.FormatInt(sectorSize, 10)}
+	}
+
+	return nil
+}
+
+// mbrPartition is a used primary entry of an MBR partition table.
+type mbrPartition struct {
+	Number   int
+	Type     byte
+	Bootable bool
+	FirstLBA uint32
+	Sectors  uint32
+}
+
+// mbrTable is a parsed MBR partition table.
+type mbrTable struct {
+	Partitions []mbrPartition
+	SectorSize int64
+	DiskID     uint32
+}
+
+// Start returns the partition's byte offset on disk.
+func (t *mbrTable) Start(p mbrPartition) int64 {
+	return int64(p.FirstLBA) * t.SectorSize
+}
+
+// Size returns the partition's size in bytes.
+func (t *mbrTable) Size(p mbrPartition) int64 {
+	return int64(p.Sectors) * t.SectorSize
+}
+
+// PartUUID returns the partition UUID the kernel and blkid report for an
+// MBR partition.
+func (t *mbrTable) PartUUID(p mbrPartition) string {
+	return fmt.Sprintf("%08x-%02x", t.DiskID, p.Number)
+}
+
+// readMBR parses the primary entries of an MBR partition table. The MBR
+// does not record the sector size, so it is passed in.
+func readMBR(disk io.ReaderAt, sectorSize int64) (*mbrTable, error) {
+	sector := make([]byte, defaultSectorSize)
+	if _, err := disk.ReadAt(sector, 0); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	if sector[mbrSignatureOff] != 0x55 || sector[mbrSignatureOff+1] != 0xAA {
+		return nil, fmt.Errorf("%w: boot signature not found", ErrInvalidMBR)
+	}
+
+	le := binary.LittleEndian
+	table := &mbrTable{DiskID: le.Uint32(sector[mbrDiskIDOffset:]), SectorSize: sectorSize}
+
+	for idx := range mbrEntries {
+		entry := sector[mbrEntriesOffset+idx*mbrEntrySize : mbrEntriesOffset+(idx+1)*mbrEntrySize]
+
+		partType := entry[4]
+		if partType == 0 || partType == mbrProtectiveType || slices.Contains(mbrExtendedTypes, partType) {
+			continue
+		}
+
+		table.Partitions = append(table.Partitions, mbrPartition{
+			Number:   idx + 1,
+			Type:     partType,
+			Bootable: entry[0] == mbrBootable,
+			FirstLBA: le.Uint32(entry[8:12]),
+			Sectors:  le.Uint32(entry[12:16]),
+		})
+	}
+
+	return table, nil
+}
+
+// setMBRDiskID replaces the disk identifier of an MBR, from which the
+// partition UUIDs are formed.
+func setMBRDiskID(disk readWriterAt, diskID uint32) error {
+	_, err := readMBR(disk, defaultSectorSize)
+	if err != nil {
+		return err
+	}
+
+	encoded := binary.LittleEndian.AppendUint32(nil, diskID)
+
+	_, err = disk.WriteAt(encoded, mbrDiskIDOffset)
+
+	return err
+}
+
+// mbrPartitionType returns the MBR type sfdisk writes for a layout
+// partition's GPT type.
+func mbrPartitionType(typeGUID string) string {
+	switch typeGUID {
+	case espPartitionType:
+		return mbrESPType
+	case swapPartitionType:
+		return mbrSwapType
+	default:
+		return mbrLinuxType
+	}
+}
+
+// validateDiskLabel checks partition_table, sector_size and bios_boot
+// against the firmware and partition table limits.
+func validateDiskLabel(data *ImageResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if !data.SectorSize.IsNull() && !data.SectorSize.IsUnknown() {
+		if size := data.SectorSize.ValueInt64(); size != defaultSectorSize && size != nativeSectorSize {
+			diags.AddAttributeError(path.Root("sector_size"), "Invalid sector size",
+				fmt.Sprintf("Expected %d or %d, got: %d", defaultSectorSize, nativeSectorSize, size))
+
+			return diags
+		}
+	}
+
+	if data.SectorSize.IsUnknown() || data.PartitionTable.IsUnknown() || data.BIOSBoot.IsUnknown() {
+		return diags
+	}
+
+	label := data.diskLabel()
+
+	if !data.BIOSBoot.IsNull() && label.BIOSBoot {
+		switch {
+		case hostArchitecture() != "x86_64":
+			diags.AddAttributeError(path.Root("bios_boot"), "Invalid BIOS boot option",
+				"Legacy BIOS boot is only available on x86_64, the build host is "+hostArchitecture()+".")
+		case data.Bootloader.ValueString() == "systemd":
+			diags.AddAttributeError(path.Root("bios_boot"), "Conflicting BIOS boot options",
+				"systemd-boot only boots from UEFI. Use bootloader = \"grub\" for BIOS boot.")
+		case label.SectorSize == nativeSectorSize:
+			diags.AddAttributeError(path.Root("bios_boot"), "Conflicting BIOS boot options",
+				"Legacy BIOS and GRUB's i386-pc platform read 512-byte sectors and cannot boot a 4Kn disk.")
+		}
+	}
+
+	// Sizes truncate accepts but parseSize does not are left to truncate.
+	if size, err := parseSize(data.DiskSize.ValueString()); knownString(data.DiskSize) && err == nil {
+		switch {
+		case size%label.SectorSize != 0:
+			diags.AddAttributeError(path.Root("disk_size"), "Invalid disk size",
+				fmt.Sprintf("disk_size must be a multiple of the %d-byte sector size.", label.SectorSize))
+		case label.Table == partitionTableMBR && size/label.SectorSize > 1<<32:
+			diags.AddAttributeError(path.Root("disk_size"), "Invalid disk size",
+				fmt.Sprintf("An MBR addresses at most 2^32 sectors, %dT with %d-byte sectors. Use partition_table = \"gpt\".",
+					(1<<32)*label.SectorSize>>40, label.SectorSize))
+		}
+	}
+
+	// The MBR holds four primary partitions: the ESP, /boot, root and one
+	// partition_layout entry.
+	if label.Table == partitionTableMBR && data.PartitionLayout != nil && len(data.PartitionLayout.Partitions) > 1 {
+		diags.AddAttributeError(path.Root("partition_layout"), "Too many partitions",
+			fmt.Sprintf("partition_table = \"mbr\" allows one partition_layout partition besides the ESP, /boot and root, got %d.",
+				len(data.PartitionLayout.Partitions)))
+	}
+
+	return diags
+}
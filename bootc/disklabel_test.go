@@ -0,0 +1,221 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// buildTestMBR returns an MBR sector with the given disk identifier and
+// primary entries of type, first LBA and sector count.
+func buildTestMBR(diskID uint32, entries ...[3]uint32) []byte {
+	sector := make([]byte, defaultSectorSize)
+	le := binary.LittleEndian
+
+	le.PutUint32(sector[mbrDiskIDOffset:], diskID)
+
+	for idx, entry := range entries {
+		raw := sector[mbrEntriesOffset+idx*mbrEntrySize:]
+		raw[4] = byte(entry[0])
+		le.PutUint32(raw[8:12], entry[1])
+		le.PutUint32(raw[12:16], entry[2])
+	}
+
+	sector[mbrEntriesOffset] = mbrBootable
+	sector[mbrSignatureOff], sector[mbrSignatureOff+1] = 0x55, 0xAA
+
+	return sector
+}
+
+func TestReadMBR(t *testing.T) {
+	sector := buildTestMBR(0x1234abcd, [3]uint32{0xef, 256, 1024}, [3]uint32{0x05, 2048, 100}, [3]uint32{0x83, 4096, 8192})
+
+	table, err := readMBR(bytes.NewReader(sector), nativeSectorSize)
+	if err != nil {
+		t.Fatalf("readMBR() error = %v", err)
+	}
+
+	if len(table.Partitions) != 2 {
+		t.Fatalf("partitions = %+v, want ESP and Linux", table.Partitions)
+	}
+
+	esp, root := table.Partitions[0], table.Partitions[1]
+
+	if !esp.Bootable || esp.Type != 0xef || table.Start(esp) != 256*nativeSectorSize || table.Size(esp) != 1024*nativeSectorSize {
+		t.Errorf("esp = %+v", esp)
+	}
+
+	if root.Number != 3 || root.Bootable || table.PartUUID(root) != "1234abcd-03" {
+		t.Errorf("root = %+v, partuuid %s", root, table.PartUUID(root))
+	}
+
+	_, err = readMBR(bytes.NewReader(make([]byte, defaultSectorSize)), defaultSectorSize)
+	if !errors.Is(err, ErrInvalidMBR) {
+		t.Errorf("readMBR() error = %v, want ErrInvalidMBR", err)
+	}
+}
+
+func TestSetMBRDiskID(t *testing.T) {
+	rawPath := filepath.Join(t.TempDir(), "disk.raw")
+
+	err := os.WriteFile(rawPath, buildTestMBR(1, [3]uint32{0x83, 2048, 2048}), testSecureFilePerms)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	disk, err := os.OpenFile(rawPath, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer disk.Close()
+
+	err = setMBRDiskID(disk, 0xdeadbeef)
+	if err != nil {
+		t.Fatalf("setMBRDiskID() error = %v", err)
+	}
+
+	table, err := readMBR(disk, defaultSectorSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if table.DiskID != 0xdeadbeef || len(table.Partitions) != 1 || table.Partitions[0].FirstLBA != 2048 {
+		t.Errorf("table = %+v", table)
+	}
+}
+
+func TestMBRPartitionType(t *testing.T) {
+	for typeGUID, want := range map[string]string{
+		espPartitionType:      mbrESPType,
+		swapPartitionType:     mbrSwapType,
+		linuxPartitionType:    mbrLinuxType,
+		rootPartitionTypes[0]: mbrLinuxType,
+	} {
+		if got := mbrPartitionType(typeGUID); got != want {
+			t.Errorf("mbrPartitionType(%s) = %s, want %s", typeGUID, got, want)
+		}
+	}
+}
+
+func TestDiskSectorSize(t *testing.T) {
+	if got := diskSectorSize(t.Context()); got != defaultSectorSize {
+		t.Errorf("diskSectorSize() = %d, want %d", got, defaultSectorSize)
+	}
+
+	if args := loopSectorArgs(withSectorSize(t.Context(), defaultSectorSize)); args != nil {
+		t.Errorf("loopSectorArgs() = %v, want none for 512-byte sectors", args)
+	}
+
+	args := loopSectorArgs(withSectorSize(t.Context(), nativeSectorSize))
+	if !slices.Equal(args, []string{"--sector-size", "4096"}) {
+		t.Errorf("loopSectorArgs() = %v", args)
+	}
+}
+
+func TestImageResourceModel_DiskLabel(t *testing.T) {
+	label := (&ImageResourceModel{}).diskLabel()
+	if label.Table != partitionTableGPT || label.SectorSize != defaultSectorSize || label.BIOSBoot != (hostArchitecture() == "x86_64") {
+		t.Errorf("default label = %+v", label)
+	}
+
+	data := ImageResourceModel{
+		PartitionTable: types.StringValue(partitionTableMBR),
+		SectorSize:     types.Int64Value(nativeSectorSize),
+		BIOSBoot:       types.BoolValue(false),
+	}
+
+	label = data.diskLabel()
+	if label.Table != partitionTableMBR || label.SectorSize != nativeSectorSize || label.BIOSBoot {
+		t.Errorf("label = %+v", label)
+	}
+
+	if !data.partitionsDisk() {
+		t.Error("partitionsDisk() = false for an mbr 4Kn disk")
+	}
+
+	if (&ImageResourceModel{SectorSize: types.Int64Value(defaultSectorSize)}).partitionsDisk() {
+		t.Error("partitionsDisk() = true for 512-byte sectors")
+	}
+}
+
+func TestValidateDiskLabel(t *testing.T) {
+	mbr := types.StringValue(partitionTableMBR)
+
+	tests := []struct {
+		name    string
+		data    ImageResourceModel
+		wantErr string
+		x86Only bool
+	}{
+		{"none", ImageResourceModel{}, "", false},
+		{"mbr", ImageResourceModel{PartitionTable: mbr, DiskSize: types.StringValue("20G")}, "", false},
+		{"native", ImageResourceModel{SectorSize: types.Int64Value(nativeSectorSize), BIOSBoot: types.BoolValue(false)}, "", false},
+		{"sector_size", ImageResourceModel{SectorSize: types.Int64Value(1024)}, "Invalid sector size", false},
+		{"unaligned_disk", ImageResourceModel{
+			SectorSize: types.Int64Value(nativeSectorSize),
+			DiskSize:   types.StringValue("1001K"),
+		}, "Invalid disk size", false},
+		{"mbr_too_large", ImageResourceModel{PartitionTable: mbr, DiskSize: types.StringValue("3T")}, "Invalid disk size", false},
+		{"mbr_4kn_large", ImageResourceModel{
+			PartitionTable: mbr,
+			SectorSize:     types.Int64Value(nativeSectorSize),
+			DiskSize:       types.StringValue("3T"),
+		}, "", false},
+		{"mbr_partitions", ImageResourceModel{PartitionTable: mbr, PartitionLayout: &PartitionLayoutModel{
+			Partitions: []LayoutPartitionModel{{}, {}},
+		}}, "Too many partitions", false},
+		{"bios_systemd", ImageResourceModel{
+			BIOSBoot:   types.BoolValue(true),
+			Bootloader: types.StringValue("systemd"),
+		}, "Conflicting BIOS boot options", true},
+		{"bios_native", ImageResourceModel{
+			BIOSBoot:   types.BoolValue(true),
+			SectorSize: types.Int64Value(nativeSectorSize),
+		}, "Conflicting BIOS boot options", true},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			if testCase.x86Only && hostArchitecture() != "x86_64" {
+				t.Skip("legacy BIOS boot requires an x86_64 build host")
+			}
+
+			diags := validateDiskLabel(&testCase.data)
+
+			if testCase.wantErr == "" {
+				if diags.HasError() {
+					t.Errorf("unexpected diagnostics: %v", diags)
+				}
+
+				return
+			}
+
+			if !diags.HasError() || diags.Errors()[0].Summary() != testCase.wantErr {
+				t.Errorf("diagnostics = %v, want %q", diags, testCase.wantErr)
+			}
+		})
+	}
+}
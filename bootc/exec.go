@@ -0,0 +1,120 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// bridgeReexecEnv marks a provider process re-executed to run bootc with an
+// environment of its own.
+const bridgeReexecEnv = "BOOTC_PROVIDER_BRIDGE_REEXEC"
+
+// commandEnvKey is the context key of the environment added to every
+// command run with the context.
+type commandEnvKey struct{}
+
+// withCommandEnv returns a context whose commands, including bootc, run
+// with the KEY=value entries of env added to their environment. The
+// provider's own environment is left alone, so concurrent resource
+// operations do not see env.
+func withCommandEnv(ctx context.Context, env []string) context.Context {
+	return context.WithValue(ctx, commandEnvKey{}, append(commandEnv(ctx), env...))
+}
+
+func commandEnv(ctx context.Context) []string {
+	env, _ := ctx.Value(commandEnvKey{}).([]string)
+
+	return env
+}
+
+// bootcRun runs bootc through the bridge. With a command environment on
+// ctx, it runs in a re-executed provider process that has it, because
+// bootc and the tools it spawns read the process environment.
+func bootcRun(ctx context.Context, args []string) error {
+	if len(commandEnv(ctx)) == 0 {
+		return BootcRun(args)
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	_, err = runCommandEnv(ctx, []string{bridgeReexecEnv + "=1"}, self, args...)
+
+	return err
+}
+
+// BridgeReexec runs bootc with the process arguments and exits when the
+// process was re-executed by bootcRun. main calls it before serving the
+// provider.
+func BridgeReexec() {
+	if os.Getenv(bridgeReexecEnv) == "" {
+		return
+	}
+
+	err := BootcRun(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	os.Exit(0)
+}
+
+// runCommand runs a system helper and returns its stdout. On failure the
+// error carries the command's stderr so diagnostics stay actionable.
+func runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return runCommandEnv(ctx, nil, name, args...)
+}
+
+// runCommandEnv is runCommand with extra KEY=value environment entries,
+// added after those of ctx.
+func runCommandEnv(ctx context.Context, env []string, name string, args ...string) ([]byte, error) {
+	return runCommandInput(ctx, env, nil, name, args...)
+}
+
+// runCommandInput is runCommandEnv with input fed to the command's stdin.
+func runCommandInput(ctx context.Context, env []string, input []byte, name string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+
+	//nolint:gosec // G204: helpers are trusted system commands with validated inputs
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if input != nil {
+		cmd.Stdin = bytes.NewReader(input)
+	}
+
+	if env = append(commandEnv(ctx), env...); len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+
+	runErr := cmd.Run()
+	if runErr != nil {
+		return nil, fmt.Errorf("%s: %w: %s", name, runErr, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,405 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// snapshotsSubvolume is the nested subvolume snapper keeps snapshots in.
+const snapshotsSubvolume = ".snapshots"
+
+var (
+	// btrfsCompressionPattern matches the algorithms and levels of the btrfs
+	// compress mount option.
+	btrfsCompressionPattern = regexp.MustCompile(`^(zlib(:[1-9])?|lzo|zstd(:(1[0-5]|[1-9]))?)$`)
+
+	// subvolumeNamePattern matches top-level btrfs subvolume names.
+	subvolumeNamePattern = regexp.MustCompile(`^[A-Za-z0-9@_][A-Za-z0-9@._-]*$`)
+
+	// filesystemMountOptions are the mount options only some filesystems
+	// accept, without their "no" prefix. Options not listed are generic.
+	filesystemMountOptions = map[string][]string{
+		"btrfs": {
+			"autodefrag", "compress", "compress-force", "space_cache", "ssd", "ssd_spread",
+			"datacow", "datasum", "degraded", "flushoncommit", "max_inline", "commit", "discard",
+		},
+		"xfs": {
+			"allocsize", "attr2", "inode32", "inode64", "largeio", "logbufs", "logbsize",
+			"swalloc", "wsync", "discard",
+		},
+		"ext4": {
+			"journal_checksum", "journal_async_commit", "barrier", "data", "commit", "delalloc",
+			"errors", "discard", "init_itable", "auto_da_alloc",
+		},
+	}
+
+	// providerMountOptions are set by the provider and cannot be overridden.
+	providerMountOptions = []string{"subvol", "subvolid"}
+
+	// mkfsLabelOptions set the filesystem label, which the provider uses to
+	// find the root filesystem.
+	mkfsLabelOptions = []string{"-L", "--label"}
+)
+
+// FilesystemOptionsModel is the filesystem_options block of bootc_image.
+type FilesystemOptionsModel struct {
+	Btrfs        *BtrfsOptionsModel `tfsdk:"btrfs"`
+	MkfsOptions  types.List         `tfsdk:"mkfs_options"`
+	MountOptions types.List         `tfsdk:"mount_options"`
+}
+
+// BtrfsOptionsModel is the btrfs block of filesystem_options.
+type BtrfsOptionsModel struct {
+	Subvolumes  []BtrfsSubvolumeModel `tfsdk:"subvolume"`
+	Compression types.String          `tfsdk:"compression"`
+}
+
+// BtrfsSubvolumeModel is a subvolume entry of the btrfs block.
+type BtrfsSubvolumeModel struct {
+	Name       types.String `tfsdk:"name"`
+	MountPoint types.String `tfsdk:"mount_point"`
+	Snapshots  types.Bool   `tfsdk:"snapshots"`
+}
+
+// filesystemTuning is a resolved filesystem_options block.
+type filesystemTuning struct {
+	Compression  string
+	MkfsOptions  []string
+	MountOptions []string
+	Subvolumes   []btrfsSubvolume
+}
+
+// btrfsSubvolume is a top-level subvolume mounted from /etc/fstab.
+type btrfsSubvolume struct {
+	Name       string
+	MountPoint string
+	Snapshots  bool
+}
+
+// filesystemTuningFromModel converts a filesystem_options block. Subvolumes
+// are sorted by mount point so parents are mounted before children.
+func filesystemTuningFromModel(ctx context.Context, model *FilesystemOptionsModel) (filesystemTuning, diag.Diagnostics) {
+	var (
+		tuning filesystemTuning
+		diags  diag.Diagnostics
+	)
+
+	if model == nil {
+		return tuning, diags
+	}
+
+	for _, list := range []struct {
+		value  types.List
+		target *[]string
+	}{
+		{model.MkfsOptions, &tuning.MkfsOptions},
+		{model.MountOptions, &tuning.MountOptions},
+	} {
+		if !list.value.IsNull() {
+			diags.Append(list.value.ElementsAs(ctx, list.target, false)...)
+		}
+	}
+
+	if model.Btrfs == nil {
+		return tuning, diags
+	}
+
+	tuning.Compression = model.Btrfs.Compression.ValueString()
+
+	for _, sub := range model.Btrfs.Subvolumes {
+		tuning.Subvolumes = append(tuning.Subvolumes, btrfsSubvolume{
+			Name:       sub.Name.ValueString(),
+			MountPoint: filepath.Clean(sub.MountPoint.ValueString()),
+			Snapshots:  sub.Snapshots.ValueBool(),
+		})
+	}
+
+	slices.SortStableFunc(tuning.Subvolumes, func(a, b btrfsSubvolume) int {
+		return strings.Compare(a.MountPoint, b.MountPoint)
+	})
+
+	return tuning, diags
+}
+
+// RootMountOptions returns the mount options of the root filesystem.
+func (t filesystemTuning) RootMountOptions() []string {
+	options := slices.Clone(t.MountOptions)
+	if t.Compression != "" {
+		options = append(options, "compress="+t.Compression)
+	}
+
+	return options
+}
+
+// Kargs returns the kernel arguments that mount the root filesystem with
+// RootMountOptions.
+func (t filesystemTuning) Kargs() []string {
+	options := t.RootMountOptions()
+	if len(options) == 0 {
+		return nil
+	}
+
+	return []string{"rootflags=" + strings.Join(options, ",")}
+}
+
+// FstabEntry returns the /etc/fstab line mounting a subvolume of the root
+// filesystem with uuid.
+func (t filesystemTuning) FstabEntry(sub btrfsSubvolume, uuid string) string {
+	options := append([]string{"subvol=" + sub.Name}, t.RootMountOptions()...)
+
+	return fmt.Sprintf("UUID=%s %s btrfs %s 0 0\n", uuid, sub.MountPoint, strings.Join(options, ","))
+}
+
+// createSubvolumes creates the btrfs subvolumes at the top of the root
+// filesystem, moves the installed content below each mount point into them
+// and mounts them from the deployment's /etc/fstab. Nested mount points are
+// moved first, so their content is not copied into the parent subvolume as
+// well.
+func createSubvolumes(ctx context.Context, rawPath string, tuning filesystemTuning, mtime *time.Time) error {
+	return customizeDeployment(ctx, rawPath, func(deployment installedDeployment) error {
+		source, err := runCommand(ctx, "findmnt", "--noheadings", "--output", "SOURCE", "--mountpoint", deployment.Sysroot)
+		if err != nil {
+			return err
+		}
+
+		fs, err := probeDevice(ctx, strings.TrimSpace(string(source)))
+		if err != nil {
+			return err
+		}
+
+		for _, sub := range slices.Backward(tuning.Subvolumes) {
+			subvolume := filepath.Join(deployment.Sysroot, sub.Name)
+
+			_, err = runCommand(ctx, "btrfs", "subvolume", "create", subvolume)
+			if err != nil {
+				return err
+			}
+
+			varPath := deploymentVarPath(deployment, sub.MountPoint)
+
+			err = mkdirAllLabeled(varPath)
+			if err != nil {
+				return err
+			}
+
+			err = moveDirContents(ctx, varPath, subvolume)
+			if err != nil {
+				return err
+			}
+
+			if sub.Snapshots {
+				_, err = runCommand(ctx, "btrfs", "subvolume", "create", filepath.Join(subvolume, snapshotsSubvolume))
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		var fstab strings.Builder
+
+		for _, sub := range tuning.Subvolumes {
+			fstab.WriteString(tuning.FstabEntry(sub, fs["UUID"]))
+		}
+
+		return appendToFile(deployment.DeployPath("/etc/fstab"), fstab.String(), mtime)
+	})
+}
+
+// appendToFile appends content to a text file, adding a missing final
+// newline first and creating the file if needed.
+func appendToFile(target, content string, mtime *time.Time) error {
+	existing, err := os.ReadFile(target)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if len(existing) > 0 && !strings.HasSuffix(string(existing), "\n") {
+		existing = append(existing, '\n')
+	}
+
+	err = writeFileMode(target, append(existing, content...), defaultFileMode)
+	if err == nil && mtime != nil {
+		err = lchtimes(target, *mtime)
+	}
+
+	return err
+}
+
+// configuredRootFilesystem returns the root filesystem type a provider
+// partitioned disk gets, or "" while it is unknown.
+func configuredRootFilesystem(data *ImageResourceModel) string {
+	values := []types.String{data.Filesystem}
+	if data.InstallConfig != nil {
+		values = append(values, data.InstallConfig.RootFSType)
+	}
+
+	rootFS := defaultRootFilesystem
+
+	for _, value := range values {
+		if value.IsUnknown() {
+			return ""
+		}
+
+		if !value.IsNull() {
+			rootFS = value.ValueString()
+		}
+	}
+
+	return rootFS
+}
+
+// validateFilesystemOptions checks a filesystem_options block against the
+// root filesystem type rootFS, which is empty while unknown.
+func validateFilesystemOptions(data *ImageResourceModel, rootFS string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	model := data.FilesystemOptions
+	if model == nil {
+		return diags
+	}
+
+	blockPath := path.Root("filesystem_options")
+
+	for idx, option := range knownStrings(model.MkfsOptions) {
+		name, _, _ := strings.Cut(option, "=")
+		if slices.Contains(mkfsLabelOptions, name) {
+			diags.AddAttributeError(blockPath.AtName("mkfs_options").AtListIndex(idx), "Invalid mkfs option",
+				"The root filesystem label is set by the provider: "+option)
+		}
+	}
+
+	if len(model.MkfsOptions.Elements()) > 0 && data.PartitionLayout != nil && len(data.PartitionLayout.RootMkfsOptions.Elements()) > 0 {
+		diags.AddAttributeError(blockPath.AtName("mkfs_options"), "Conflicting mkfs options",
+			"Set either filesystem_options.mkfs_options or partition_layout.root_mkfs_options, not both.")
+	}
+
+	for idx, option := range knownStrings(model.MountOptions) {
+		name, _, _ := strings.Cut(option, "=")
+		optionPath := blockPath.AtName("mount_options").AtListIndex(idx)
+
+		switch {
+		case slices.Contains(providerMountOptions, name):
+			diags.AddAttributeError(optionPath, "Invalid mount option", name+" is set by the provider.")
+		case rootFS != "" && !mountOptionSupported(rootFS, name):
+			diags.AddAttributeError(optionPath, "Invalid mount option",
+				fmt.Sprintf("%s does not support the mount option %s.", rootFS, name))
+		}
+	}
+
+	if model.Btrfs == nil {
+		return diags
+	}
+
+	btrfsPath := blockPath.AtName("btrfs")
+
+	if rootFS != "" && rootFS != "btrfs" {
+		diags.AddAttributeError(btrfsPath, "Invalid filesystem options",
+			"The btrfs block requires filesystem = \"btrfs\" or install_config.root_fs_type = \"btrfs\".")
+	}
+
+	if knownString(model.Btrfs.Compression) && !btrfsCompressionPattern.MatchString(model.Btrfs.Compression.ValueString()) {
+		diags.AddAttributeError(btrfsPath.AtName("compression"), "Invalid compression",
+			"Expected zlib[:1-9], lzo, or zstd[:1-15], got: "+model.Btrfs.Compression.ValueString())
+	}
+
+	names := map[string]bool{}
+	mountPoints := map[string]bool{}
+
+	if data.PartitionLayout != nil {
+		for _, part := range data.PartitionLayout.Partitions {
+			mountPoints[filepath.Clean(part.MountPoint.ValueString())] = true
+		}
+	}
+
+	for idx, sub := range model.Btrfs.Subvolumes {
+		subPath := btrfsPath.AtName("subvolume").AtListIndex(idx)
+
+		if knownString(sub.Name) {
+			name := sub.Name.ValueString()
+
+			switch {
+			case !subvolumeNamePattern.MatchString(name):
+				diags.AddAttributeError(subPath.AtName("name"), "Invalid subvolume name",
+					"Expected a single path component of letters, digits, @, ., _ or -, got: "+name)
+			case name == "ostree" || name == "boot":
+				diags.AddAttributeError(subPath.AtName("name"), "Invalid subvolume name",
+					name+" is used by the installed system.")
+			case names[name]:
+				diags.AddAttributeError(subPath.AtName("name"), "Duplicate subvolume name",
+					"Subvolume name is already used: "+name)
+			}
+
+			names[name] = true
+		}
+
+		if !knownString(sub.MountPoint) {
+			continue
+		}
+
+		mountPoint := filepath.Clean(sub.MountPoint.ValueString())
+
+		switch {
+		case mountPoint != "/var" && !strings.HasPrefix(mountPoint, "/var/"):
+			diags.AddAttributeError(subPath.AtName("mount_point"), "Invalid mount point",
+				"Expected /var or a path below /var, got: "+sub.MountPoint.ValueString())
+		case mountPoints[mountPoint]:
+			diags.AddAttributeError(subPath.AtName("mount_point"), "Duplicate mount point",
+				"Mount point is already used by a partition or subvolume: "+sub.MountPoint.ValueString())
+		}
+
+		mountPoints[mountPoint] = true
+	}
+
+	return diags
+}
+
+// mountOptionSupported reports whether fsType accepts the mount option
+// name, which is either generic or specific to fsType.
+func mountOptionSupported(fsType, name string) bool {
+	name = strings.TrimPrefix(name, "no")
+
+	specific := false
+
+	for fs, options := range filesystemMountOptions {
+		for _, option := range options {
+			if strings.TrimPrefix(option, "no") != name {
+				continue
+			}
+
+			if fs == fsType {
+				return true
+			}
+
+			specific = true
+		}
+	}
+
+	return !specific
+}
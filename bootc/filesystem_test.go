@@ -0,0 +1,240 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// testBtrfsOptions is a btrfs layout with separate /var/home and /var
+// subvolumes, listed out of order.
+func testBtrfsOptions() *FilesystemOptionsModel {
+	subvolume := func(name, mountPoint string, snapshots bool) BtrfsSubvolumeModel {
+		return BtrfsSubvolumeModel{
+			Name:       types.StringValue(name),
+			MountPoint: types.StringValue(mountPoint),
+			Snapshots:  types.BoolValue(snapshots),
+		}
+	}
+
+	return &FilesystemOptionsModel{
+		Btrfs: &BtrfsOptionsModel{
+			Subvolumes: []BtrfsSubvolumeModel{
+				subvolume("@home", "/var/home/", true),
+				subvolume("@var", "/var", false),
+			},
+			Compression: types.StringValue("zstd:3"),
+		},
+		MkfsOptions:  types.ListValueMust(types.StringType, []attr.Value{types.StringValue("--nodesize=16k")}),
+		MountOptions: types.ListValueMust(types.StringType, []attr.Value{types.StringValue("noatime")}),
+	}
+}
+
+func TestFilesystemTuningFromModel(t *testing.T) {
+	tuning, diags := filesystemTuningFromModel(t.Context(), testBtrfsOptions())
+	if diags.HasError() {
+		t.Fatalf("diagnostics: %v", diags)
+	}
+
+	want := []btrfsSubvolume{
+		{Name: "@var", MountPoint: "/var"},
+		{Name: "@home", MountPoint: "/var/home", Snapshots: true},
+	}
+	if !slices.Equal(tuning.Subvolumes, want) {
+		t.Errorf("subvolumes = %+v, want %+v", tuning.Subvolumes, want)
+	}
+
+	if !slices.Equal(tuning.MkfsOptions, []string{"--nodesize=16k"}) || tuning.Compression != "zstd:3" {
+		t.Errorf("tuning = %+v", tuning)
+	}
+
+	tuning, diags = filesystemTuningFromModel(t.Context(), nil)
+	if diags.HasError() || tuning.Kargs() != nil || tuning.RootMountOptions() != nil {
+		t.Errorf("nil model = %+v, %v", tuning, diags)
+	}
+}
+
+func TestFilesystemTuning_Mount(t *testing.T) {
+	tuning := filesystemTuning{Compression: "zstd:3", MountOptions: []string{"noatime"}}
+
+	if got := tuning.RootMountOptions(); !slices.Equal(got, []string{"noatime", "compress=zstd:3"}) {
+		t.Errorf("RootMountOptions = %q", got)
+	}
+
+	if got := tuning.Kargs(); !slices.Equal(got, []string{"rootflags=noatime,compress=zstd:3"}) {
+		t.Errorf("Kargs = %q", got)
+	}
+
+	want := "UUID=9c3d /var/home btrfs subvol=@home,noatime,compress=zstd:3 0 0\n"
+	if got := tuning.FstabEntry(btrfsSubvolume{Name: "@home", MountPoint: "/var/home"}, "9c3d"); got != want {
+		t.Errorf("FstabEntry = %q, want %q", got, want)
+	}
+}
+
+func TestMountOptionSupported(t *testing.T) {
+	tests := []struct {
+		fsType string
+		option string
+		want   bool
+	}{
+		{"btrfs", "noatime", true},
+		{"btrfs", "compress", true},
+		{"btrfs", "nodatacow", true},
+		{"xfs", "compress", false},
+		{"xfs", "inode64", true},
+		{"xfs", "discard", true},
+		{"ext4", "nodelalloc", true},
+		{"ext4", "logbufs", false},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.fsType+"_"+testCase.option, func(t *testing.T) {
+			if got := mountOptionSupported(testCase.fsType, testCase.option); got != testCase.want {
+				t.Errorf("mountOptionSupported = %v, want %v", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestConfiguredRootFilesystem(t *testing.T) {
+	tests := []struct {
+		name string
+		data ImageResourceModel
+		want string
+	}{
+		{"default", ImageResourceModel{}, defaultRootFilesystem},
+		{"filesystem", ImageResourceModel{Filesystem: types.StringValue("btrfs")}, "btrfs"},
+		{"install_config", ImageResourceModel{
+			Filesystem:    types.StringValue("ext4"),
+			InstallConfig: &InstallConfigModel{RootFSType: types.StringValue("btrfs")},
+		}, "btrfs"},
+		{"unknown", ImageResourceModel{Filesystem: types.StringUnknown()}, ""},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := configuredRootFilesystem(&testCase.data); got != testCase.want {
+				t.Errorf("configuredRootFilesystem = %q, want %q", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestValidateFilesystemOptions(t *testing.T) {
+	if diags := validateFilesystemOptions(&ImageResourceModel{FilesystemOptions: testBtrfsOptions()}, "btrfs"); diags.HasError() {
+		t.Fatalf("btrfs options: %v", diags)
+	}
+
+	stringList := func(values ...string) types.List {
+		elements := make([]attr.Value, 0, len(values))
+		for _, value := range values {
+			elements = append(elements, types.StringValue(value))
+		}
+
+		return types.ListValueMust(types.StringType, elements)
+	}
+
+	tests := []struct {
+		name    string
+		rootFS  string
+		modify  func(*ImageResourceModel)
+		wantMsg string
+	}{
+		{"mkfs_label", "btrfs", func(d *ImageResourceModel) {
+			d.FilesystemOptions.MkfsOptions = stringList("--label=data")
+		}, "Invalid mkfs option"},
+		{"root_mkfs_options", "btrfs", func(d *ImageResourceModel) {
+			d.PartitionLayout = &PartitionLayoutModel{RootMkfsOptions: stringList("-m0")}
+		}, "Conflicting mkfs options"},
+		{"provider_mount_option", "btrfs", func(d *ImageResourceModel) {
+			d.FilesystemOptions.MountOptions = stringList("subvol=@root")
+		}, "Invalid mount option"},
+		{"unsupported_mount_option", "xfs", func(d *ImageResourceModel) {
+			d.FilesystemOptions.Btrfs = nil
+			d.FilesystemOptions.MountOptions = stringList("compress=zstd")
+		}, "Invalid mount option"},
+		{"btrfs_on_xfs", "xfs", func(d *ImageResourceModel) {
+			d.FilesystemOptions.MountOptions = types.ListNull(types.StringType)
+		}, "Invalid filesystem options"},
+		{"compression", "btrfs", func(d *ImageResourceModel) {
+			d.FilesystemOptions.Btrfs.Compression = types.StringValue("zstd:22")
+		}, "Invalid compression"},
+		{"subvolume_name", "btrfs", func(d *ImageResourceModel) {
+			d.FilesystemOptions.Btrfs.Subvolumes[0].Name = types.StringValue("@var/home")
+		}, "Invalid subvolume name"},
+		{"reserved_subvolume", "btrfs", func(d *ImageResourceModel) {
+			d.FilesystemOptions.Btrfs.Subvolumes[0].Name = types.StringValue("ostree")
+		}, "Invalid subvolume name"},
+		{"duplicate_subvolume", "btrfs", func(d *ImageResourceModel) {
+			d.FilesystemOptions.Btrfs.Subvolumes[0].Name = types.StringValue("@var")
+		}, "Duplicate subvolume name"},
+		{"outside_var", "btrfs", func(d *ImageResourceModel) {
+			d.FilesystemOptions.Btrfs.Subvolumes[0].MountPoint = types.StringValue("/home")
+		}, "Invalid mount point"},
+		{"partition_mount_point", "btrfs", func(d *ImageResourceModel) {
+			d.PartitionLayout = &PartitionLayoutModel{
+				Partitions: []LayoutPartitionModel{{MountPoint: types.StringValue("/var/home")}},
+			}
+		}, "Duplicate mount point"},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			data := ImageResourceModel{FilesystemOptions: testBtrfsOptions()}
+			testCase.modify(&data)
+
+			diags := validateFilesystemOptions(&data, testCase.rootFS)
+			if !diags.HasError() || !strings.Contains(diags.Errors()[0].Summary(), testCase.wantMsg) {
+				t.Errorf("diagnostics = %v, want %q", diags, testCase.wantMsg)
+			}
+		})
+	}
+}
+
+func TestAppendToFile(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "fstab")
+
+	err := os.WriteFile(target, []byte("UUID=0f1c /boot ext4 defaults 1 2"), testSecureFilePerms)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = appendToFile(target, "UUID=9c3d /var btrfs subvol=@var 0 0\n", nil)
+	if err != nil {
+		t.Fatalf("appendToFile: %v", err)
+	}
+
+	content, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "UUID=0f1c /boot ext4 defaults 1 2\nUUID=9c3d /var btrfs subvol=@var 0 0\n"
+	if string(content) != want {
+		t.Errorf("content = %q, want %q", content, want)
+	}
+}
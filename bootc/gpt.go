@@ -0,0 +1,289 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	partitionTableGPT  = "gpt"
+	partitionTableMBR  = "mbr"
+	partitionTableNone = "none"
+
+	gptSignature      = "EFI PART"
+	gptHeaderSize     = 92
+	gptEntryNameBytes = 72
+	mbrSignatureOff   = 510
+	mbrProtectiveType = 0xEE
+)
+
+var (
+	ErrInvalidGPT  = errors.New("invalid GPT")
+	ErrInvalidGUID = errors.New("invalid GUID")
+)
+
+// readWriterAt is a disk image that can be patched in place.
+type readWriterAt interface {
+	io.ReaderAt
+	io.WriterAt
+}
+
+// gptPartition is a used entry of a GUID partition table.
+type gptPartition struct {
+	TypeGUID   string
+	GUID       string
+	Name       string
+	FirstLBA   uint64
+	LastLBA    uint64
+	Attributes uint64
+	Number     int
+}
+
+// gptTable is a parsed primary GUID partition table.
+type gptTable struct {
+	DiskGUID   string
+	Partitions []gptPartition
+	SectorSize int64
+}
+
+// Start returns the partition's byte offset on disk.
+func (t *gptTable) Start(p gptPartition) int64 {
+	return int64(p.FirstLBA) * t.SectorSize
+}
+
+// Size returns the partition's size in bytes.
+func (t *gptTable) Size(p gptPartition) int64 {
+	return int64(p.LastLBA-p.FirstLBA+1) * t.SectorSize
+}
+
+// detectPartitionTable reports whether disk carries a GPT, a plain MBR or no
+// partition table at all.
+func detectPartitionTable(disk io.ReaderAt) (string, error) {
+	for _, sectorSize := range []int64{512, 4096} {
+		sig := make([]byte, len(gptSignature))
+		if _, err := disk.ReadAt(sig, sectorSize); err != nil && !errors.Is(err, io.EOF) {
+			return "", err
+		}
+
+		if string(sig) == gptSignature {
+			return partitionTableGPT, nil
+		}
+	}
+
+	mbr := make([]byte, 512)
+	if _, err := disk.ReadAt(mbr, 0); err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	if mbr[mbrSignatureOff] == 0x55 && mbr[mbrSignatureOff+1] == 0xAA && mbr[450] != mbrProtectiveType {
+		return partitionTableMBR, nil
+	}
+
+	return partitionTableNone, nil
+}
+
+// readGPT parses the primary GPT header and its partition entries, probing
+// for 512-byte and 4096-byte logical sectors.
+func readGPT(disk io.ReaderAt) (*gptTable, error) {
+	for _, sectorSize := range []int64{512, 4096} {
+		header := make([]byte, gptHeaderSize)
+		if _, err := disk.ReadAt(header, sectorSize); err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+
+		if string(header[:8]) != gptSignature {
+			continue
+		}
+
+		return parseGPTEntries(disk, header, sectorSize)
+	}
+
+	return nil, fmt.Errorf("%w: signature not found", ErrInvalidGPT)
+}
+
+func parseGPTEntries(disk io.ReaderAt, header []byte, sectorSize int64) (*gptTable, error) {
+	le := binary.LittleEndian
+
+	entriesLBA := le.Uint64(header[72:80])
+	entryCount := le.Uint32(header[80:84])
+	entrySize := le.Uint32(header[84:88])
+
+	if entrySize < 128 || entryCount > 1024 {
+		return nil, fmt.Errorf("%w: %d entries of %d bytes", ErrInvalidGPT, entryCount, entrySize)
+	}
+
+	entries := make([]byte, int(entryCount)*int(entrySize))
+	if _, err := disk.ReadAt(entries, int64(entriesLBA)*sectorSize); err != nil {
+		return nil, fmt.Errorf("%w: reading entries: %w", ErrInvalidGPT, err)
+	}
+
+	table := &gptTable{
+		DiskGUID:   formatGUID(header[56:72]),
+		SectorSize: sectorSize,
+	}
+
+	zero := make([]byte, 16)
+
+	for idx := range int(entryCount) {
+		entry := entries[idx*int(entrySize) : (idx+1)*int(entrySize)]
+		if bytes.Equal(entry[:16], zero) {
+			continue
+		}
+
+		table.Partitions = append(table.Partitions, gptPartition{
+			Number:     idx + 1,
+			TypeGUID:   formatGUID(entry[0:16]),
+			GUID:       formatGUID(entry[16:32]),
+			FirstLBA:   le.Uint64(entry[32:40]),
+			LastLBA:    le.Uint64(entry[40:48]),
+			Attributes: le.Uint64(entry[48:56]),
+			Name:       decodeGPTName(entry[56 : 56+gptEntryNameBytes]),
+		})
+	}
+
+	return table, nil
+}
+
+// formatGUID renders a mixed-endian on-disk GUID in its canonical form.
+func formatGUID(b []byte) string {
+	le := binary.LittleEndian
+
+	return fmt.Sprintf("%08x-%04x-%04x-%x-%x",
+		le.Uint32(b[0:4]), le.Uint16(b[4:6]), le.Uint16(b[6:8]), b[8:10], b[10:16])
+}
+
+// parseGUID encodes a canonical GUID string in the mixed-endian on-disk form.
+func parseGUID(guid string) ([]byte, error) {
+	raw, err := hex.DecodeString(strings.ReplaceAll(guid, "-", ""))
+	if err != nil || len(raw) != 16 || strings.Count(guid, "-") != 4 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidGUID, guid)
+	}
+
+	out := make([]byte, 16)
+	binary.LittleEndian.PutUint32(out[0:], binary.BigEndian.Uint32(raw[0:4]))
+	binary.LittleEndian.PutUint16(out[4:], binary.BigEndian.Uint16(raw[4:6]))
+	binary.LittleEndian.PutUint16(out[6:], binary.BigEndian.Uint16(raw[6:8]))
+	copy(out[8:], raw[8:])
+
+	return out, nil
+}
+
+// rewriteGPTGUIDs sets the disk GUID and the unique GUIDs of the given
+// partition numbers in both the primary and the backup GPT, recomputing the
+// header and entry array CRCs.
+func rewriteGPTGUIDs(disk readWriterAt, diskGUID string, partGUIDs map[int]string) error {
+	table, err := readGPT(disk)
+	if err != nil {
+		return err
+	}
+
+	encodedDisk, err := parseGUID(diskGUID)
+	if err != nil {
+		return err
+	}
+
+	encodedParts := make(map[int][]byte, len(partGUIDs))
+	for number, guid := range partGUIDs {
+		encoded, encodeErr := parseGUID(guid)
+		if encodeErr != nil {
+			return encodeErr
+		}
+
+		encodedParts[number] = encoded
+	}
+
+	le := binary.LittleEndian
+	sector := make([]byte, table.SectorSize)
+
+	if _, err := disk.ReadAt(sector, table.SectorSize); err != nil {
+		return err
+	}
+
+	headerLBAs := []uint64{1, le.Uint64(sector[32:40])}
+
+	for _, lba := range headerLBAs {
+		header := make([]byte, table.SectorSize)
+		if _, err := disk.ReadAt(header, int64(lba)*table.SectorSize); err != nil {
+			return fmt.Errorf("%w: header at LBA %d: %w", ErrInvalidGPT, lba, err)
+		}
+
+		if string(header[:8]) != gptSignature {
+			return fmt.Errorf("%w: no header at LBA %d", ErrInvalidGPT, lba)
+		}
+
+		headerSize := le.Uint32(header[12:16])
+		entriesLBA := le.Uint64(header[72:80])
+		entryCount := le.Uint32(header[80:84])
+		entrySize := le.Uint32(header[84:88])
+
+		if headerSize < gptHeaderSize || int64(headerSize) > table.SectorSize ||
+			entrySize < 128 || entryCount > 1024 {
+			return fmt.Errorf("%w: malformed header at LBA %d", ErrInvalidGPT, lba)
+		}
+
+		entries := make([]byte, int(entryCount)*int(entrySize))
+		if _, err := disk.ReadAt(entries, int64(entriesLBA)*table.SectorSize); err != nil {
+			return fmt.Errorf("%w: entries at LBA %d: %w", ErrInvalidGPT, entriesLBA, err)
+		}
+
+		for number, encoded := range encodedParts {
+			if number < 1 || number > int(entryCount) {
+				return fmt.Errorf("%w: no partition %d", ErrInvalidGPT, number)
+			}
+
+			copy(entries[(number-1)*int(entrySize)+16:], encoded)
+		}
+
+		copy(header[56:72], encodedDisk)
+		le.PutUint32(header[88:92], crc32.ChecksumIEEE(entries))
+		le.PutUint32(header[16:20], 0)
+		le.PutUint32(header[16:20], crc32.ChecksumIEEE(header[:headerSize]))
+
+		if _, err := disk.WriteAt(entries, int64(entriesLBA)*table.SectorSize); err != nil {
+			return err
+		}
+
+		if _, err := disk.WriteAt(header, int64(lba)*table.SectorSize); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func decodeGPTName(b []byte) string {
+	units := make([]uint16, 0, len(b)/2)
+	for idx := 0; idx+1 < len(b); idx += 2 {
+		unit := binary.LittleEndian.Uint16(b[idx:])
+		if unit == 0 {
+			break
+		}
+
+		units = append(units, unit)
+	}
+
+	return string(utf16.Decode(units))
+}
@@ -0,0 +1,314 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+const (
+	testESPTypeGUID  = "c12a7328-f81f-11d2-ba4b-00a0c93ec93b"
+	testRootTypeGUID = "4f68bce3-e8cd-4db1-96e7-fbcaf984b709"
+	testDiskGUID     = "01234567-89ab-cdef-0123-456789abcdef"
+)
+
+type testGPTPartition struct {
+	typeGUID string
+	guid     string
+	name     string
+	first    uint64
+	last     uint64
+}
+
+func mustParseGUID(t *testing.T, guid string) []byte {
+	t.Helper()
+
+	encoded, err := parseGUID(guid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return encoded
+}
+
+// buildTestGPT returns a disk image holding a protective MBR and a primary
+// and backup GPT with valid CRCs; entry slots are assigned in order.
+func buildTestGPT(t *testing.T, sectorSize int, parts []testGPTPartition) []byte {
+	t.Helper()
+
+	const entries, entrySize = 128, 128
+
+	entrySectors := entries * entrySize / sectorSize
+	totalSectors := 2 + 2*entrySectors + 1
+
+	for _, part := range parts {
+		totalSectors = max(totalSectors, int(part.last)+1+entrySectors+1)
+	}
+
+	disk := make([]byte, totalSectors*sectorSize)
+	le := binary.LittleEndian
+
+	disk[mbrSignatureOff], disk[mbrSignatureOff+1] = 0x55, 0xAA
+	disk[450] = mbrProtectiveType
+
+	array := make([]byte, entries*entrySize)
+	for idx, part := range parts {
+		entry := array[idx*entrySize:]
+		copy(entry[0:], mustParseGUID(t, part.typeGUID))
+		copy(entry[16:], mustParseGUID(t, part.guid))
+		le.PutUint64(entry[32:], part.first)
+		le.PutUint64(entry[40:], part.last)
+
+		for unit, r := range utf16.Encode([]rune(part.name)) {
+			le.PutUint16(entry[56+unit*2:], r)
+		}
+	}
+
+	backupLBA := uint64(totalSectors - 1)
+	backupEntriesLBA := backupLBA - uint64(entrySectors)
+
+	for _, layout := range [][3]uint64{{1, backupLBA, 2}, {backupLBA, 1, backupEntriesLBA}} {
+		header := disk[int(layout[0])*sectorSize:]
+		copy(header, gptSignature)
+		le.PutUint32(header[8:], 0x00010000)
+		le.PutUint32(header[12:], gptHeaderSize)
+		le.PutUint64(header[24:], layout[0])
+		le.PutUint64(header[32:], layout[1])
+		le.PutUint64(header[40:], uint64(2+entrySectors))
+		le.PutUint64(header[48:], backupEntriesLBA-1)
+		copy(header[56:], mustParseGUID(t, testDiskGUID))
+		le.PutUint64(header[72:], layout[2])
+		le.PutUint32(header[80:], entries)
+		le.PutUint32(header[84:], entrySize)
+		le.PutUint32(header[88:], crc32.ChecksumIEEE(array))
+		le.PutUint32(header[16:], crc32.ChecksumIEEE(header[:gptHeaderSize]))
+
+		copy(disk[int(layout[2])*sectorSize:], array)
+	}
+
+	return disk
+}
+
+// verifyTestGPTCRCs checks the header and entry CRCs of the GPT at lba.
+func verifyTestGPTCRCs(t *testing.T, disk []byte, sectorSize int, lba uint64) {
+	t.Helper()
+
+	le := binary.LittleEndian
+
+	header := slices.Clone(disk[int(lba)*sectorSize : int(lba)*sectorSize+gptHeaderSize])
+	if string(header[:8]) != gptSignature {
+		t.Fatalf("no GPT header at LBA %d", lba)
+	}
+
+	wantHeaderCRC := le.Uint32(header[16:])
+	le.PutUint32(header[16:], 0)
+
+	if got := crc32.ChecksumIEEE(header); got != wantHeaderCRC {
+		t.Errorf("LBA %d: header CRC = %08x, stored %08x", lba, got, wantHeaderCRC)
+	}
+
+	entriesOff := int(le.Uint64(header[72:])) * sectorSize
+	array := disk[entriesOff : entriesOff+int(le.Uint32(header[80:])*le.Uint32(header[84:]))]
+
+	if got := crc32.ChecksumIEEE(array); got != le.Uint32(header[88:]) {
+		t.Errorf("LBA %d: entries CRC = %08x, stored %08x", lba, got, le.Uint32(header[88:]))
+	}
+}
+
+func TestReadGPT(t *testing.T) {
+	parts := []testGPTPartition{
+		{testESPTypeGUID, "11111111-2222-3333-4444-555555555555", "EFI-SYSTEM", 64, 1023},
+		{testRootTypeGUID, "66666666-7777-8888-9999-aaaaaaaaaaaa", "root", 1024, 4000},
+	}
+
+	for _, sectorSize := range []int{512, 4096} {
+		t.Run(strings.Repeat("s", sectorSize/512), func(t *testing.T) {
+			raw := buildTestGPT(t, sectorSize, parts)
+			verifyTestGPTCRCs(t, raw, sectorSize, 1)
+
+			disk := bytes.NewReader(raw)
+
+			tableType, err := detectPartitionTable(disk)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tableType != partitionTableGPT {
+				t.Errorf("table type = %q, want gpt", tableType)
+			}
+
+			table, err := readGPT(disk)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if table.SectorSize != int64(sectorSize) {
+				t.Errorf("sector size = %d, want %d", table.SectorSize, sectorSize)
+			}
+
+			if table.DiskGUID != testDiskGUID {
+				t.Errorf("disk GUID = %q", table.DiskGUID)
+			}
+
+			if len(table.Partitions) != len(parts) {
+				t.Fatalf("partitions = %d, want %d", len(table.Partitions), len(parts))
+			}
+
+			for idx, want := range parts {
+				got := table.Partitions[idx]
+				if got.Number != idx+1 || got.TypeGUID != want.typeGUID || got.GUID != want.guid ||
+					got.Name != want.name {
+					t.Errorf("partition %d = %+v, want %+v", idx+1, got, want)
+				}
+			}
+
+			esp := table.Partitions[0]
+			if table.Start(esp) != 64*int64(sectorSize) {
+				t.Errorf("start = %d", table.Start(esp))
+			}
+
+			if table.Size(esp) != 960*int64(sectorSize) {
+				t.Errorf("size = %d", table.Size(esp))
+			}
+		})
+	}
+}
+
+func TestDetectPartitionTable(t *testing.T) {
+	mbr := make([]byte, 4096*2)
+	mbr[mbrSignatureOff], mbr[mbrSignatureOff+1] = 0x55, 0xAA
+	mbr[450] = 0x83
+
+	tests := []struct {
+		name string
+		disk []byte
+		want string
+	}{
+		{"mbr", mbr, partitionTableMBR},
+		{"empty", make([]byte, 4096*2), partitionTableNone},
+		{"short", make([]byte, 100), partitionTableNone},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := detectPartitionTable(bytes.NewReader(testCase.disk))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != testCase.want {
+				t.Errorf("detectPartitionTable = %q, want %q", got, testCase.want)
+			}
+		})
+	}
+
+	if _, err := readGPT(bytes.NewReader(mbr)); !errors.Is(err, ErrInvalidGPT) {
+		t.Errorf("expected ErrInvalidGPT, got %v", err)
+	}
+}
+
+func TestParseGUID(t *testing.T) {
+	encoded, err := parseGUID(testESPTypeGUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := formatGUID(encoded); got != testESPTypeGUID {
+		t.Errorf("round trip = %q, want %q", got, testESPTypeGUID)
+	}
+
+	for _, bad := range []string{"", "not-a-guid", "c12a7328f81f11d2ba4b00a0c93ec93b", "c12a7328-f81f-11d2-ba4b-00a0c93ec9"} {
+		if _, err := parseGUID(bad); !errors.Is(err, ErrInvalidGUID) {
+			t.Errorf("parseGUID(%q): expected ErrInvalidGUID, got %v", bad, err)
+		}
+	}
+}
+
+func TestRewriteGPTGUIDs(t *testing.T) {
+	const (
+		newDiskGUID = "aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee"
+		newPartGUID = "12345678-9abc-def0-1234-56789abcdef0"
+	)
+
+	parts := []testGPTPartition{
+		{testESPTypeGUID, "11111111-2222-3333-4444-555555555555", "EFI-SYSTEM", 40, 50},
+		{testRootTypeGUID, "66666666-7777-8888-9999-aaaaaaaaaaaa", "root", 51, 100},
+	}
+
+	for _, sectorSize := range []int{512, 4096} {
+		t.Run(strings.Repeat("s", sectorSize/512), func(t *testing.T) {
+			rawPath := filepath.Join(t.TempDir(), "disk.raw")
+			if err := os.WriteFile(rawPath, buildTestGPT(t, sectorSize, parts), testSecureFilePerms); err != nil {
+				t.Fatal(err)
+			}
+
+			disk, err := os.OpenFile(rawPath, os.O_RDWR, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer disk.Close()
+
+			if err := rewriteGPTGUIDs(disk, newDiskGUID, map[int]string{2: newPartGUID}); err != nil {
+				t.Fatal(err)
+			}
+
+			table, err := readGPT(disk)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if table.DiskGUID != newDiskGUID {
+				t.Errorf("disk GUID = %q", table.DiskGUID)
+			}
+
+			if table.Partitions[0].GUID != parts[0].guid {
+				t.Errorf("untouched partition GUID changed to %q", table.Partitions[0].GUID)
+			}
+
+			if table.Partitions[1].GUID != newPartGUID || table.Partitions[1].Name != "root" {
+				t.Errorf("partition 2 = %+v", table.Partitions[1])
+			}
+
+			raw, err := os.ReadFile(rawPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			backupLBA := uint64(len(raw)/sectorSize - 1)
+			verifyTestGPTCRCs(t, raw, sectorSize, 1)
+			verifyTestGPTCRCs(t, raw, sectorSize, backupLBA)
+
+			if got := formatGUID(raw[int(backupLBA)*sectorSize+56:]); got != newDiskGUID {
+				t.Errorf("backup disk GUID = %q", got)
+			}
+
+			if err := rewriteGPTGUIDs(disk, newDiskGUID, map[int]string{200: newPartGUID}); !errors.Is(err, ErrInvalidGPT) {
+				t.Errorf("expected ErrInvalidGPT for unknown partition, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	butane "github.com/coreos/butane/config"
+	butanecommon "github.com/coreos/butane/config/common"
+	ignition "github.com/coreos/ignition/v2/config"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	// ignitionConfigPath is where coreos-installer places the config on the
+	// boot filesystem and where Ignition's initrd stage looks for it.
+	ignitionConfigPath = "ignition/config.ign"
+	// ignitionFirstbootStamp makes CoreOS's GRUB configuration append
+	// ignition.firstboot to the kernel command line until Ignition has run.
+	ignitionFirstbootStamp = "ignition.firstboot"
+	ignitionConfigMode     = 0o600
+
+	defaultIgnitionPlatform = "metal"
+)
+
+var ErrInvalidIgnition = errors.New("invalid Ignition config")
+
+// IgnitionModel is the ignition block of bootc_image.
+type IgnitionModel struct {
+	Config   types.String `tfsdk:"config"`
+	Butane   types.String `tfsdk:"butane"`
+	FilesDir types.String `tfsdk:"files_dir"`
+	Platform types.String `tfsdk:"platform"`
+}
+
+// renderIgnition returns the Ignition JSON of the block, translating Butane
+// YAML when given and validating the result. Non-fatal findings are
+// returned as warnings.
+func renderIgnition(model IgnitionModel) ([]byte, string, error) {
+	config := []byte(model.Config.ValueString())
+
+	if !model.Butane.IsNull() {
+		translated, translateReport, err := butane.TranslateBytes([]byte(model.Butane.ValueString()),
+			butanecommon.TranslateBytesOptions{
+				TranslateOptions: butanecommon.TranslateOptions{FilesDir: model.FilesDir.ValueString()},
+			})
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: butane: %w: %s", ErrInvalidIgnition, err, translateReport.String())
+		}
+
+		if translateReport.IsFatal() {
+			return nil, "", fmt.Errorf("%w: butane: %s", ErrInvalidIgnition, translateReport.String())
+		}
+
+		config = translated
+	}
+
+	_, parseReport, err := ignition.Parse(config)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %w: %s", ErrInvalidIgnition, err, parseReport.String())
+	}
+
+	if parseReport.IsFatal() {
+		return nil, "", fmt.Errorf("%w: %s", ErrInvalidIgnition, parseReport.String())
+	}
+
+	return config, parseReport.String(), nil
+}
+
+// ignitionPlatform returns the ignition.platform.id karg value.
+func ignitionPlatform(model IgnitionModel) string {
+	if model.Platform.IsNull() || model.Platform.ValueString() == "" {
+		return defaultIgnitionPlatform
+	}
+
+	return model.Platform.ValueString()
+}
+
+// writeIgnition places an Ignition config and the first-boot stamp on the
+// boot filesystem of a freshly installed raw disk.
+func writeIgnition(ctx context.Context, rawPath string, config []byte, mtime *time.Time) error {
+	return customizeBoot(ctx, rawPath, func(bootDir string) error {
+		err := writeBootFile(filepath.Join(bootDir, ignitionConfigPath), config, ignitionConfigMode, mtime)
+		if err != nil {
+			return err
+		}
+
+		return writeBootFile(filepath.Join(bootDir, ignitionFirstbootStamp), nil, defaultFileMode, mtime)
+	})
+}
+
+// writeBootFile writes a root-owned file on a mounted boot filesystem.
+func writeBootFile(target string, content []byte, mode os.FileMode, mtime *time.Time) error {
+	err := mkdirAllLabeled(filepath.Dir(target))
+	if err == nil {
+		err = writeFileMode(target, content, mode)
+	}
+
+	if err == nil {
+		err = copySELinuxLabel(filepath.Dir(target), target)
+	}
+
+	if err == nil && mtime != nil {
+		err = lchtimes(target, *mtime)
+	}
+
+	return err
+}
+
+// validateIgnition checks the ignition block and, when its values are
+// known, that the config renders.
+func validateIgnition(model IgnitionModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	blockPath := path.Root("ignition")
+
+	if model.Config.IsUnknown() || model.Butane.IsUnknown() || model.FilesDir.IsUnknown() {
+		return diags
+	}
+
+	if model.Config.IsNull() == model.Butane.IsNull() {
+		diags.AddAttributeError(blockPath, "Invalid Ignition block", "Exactly one of config or butane must be set.")
+
+		return diags
+	}
+
+	if !model.FilesDir.IsNull() && model.Butane.IsNull() {
+		diags.AddAttributeError(blockPath.AtName("files_dir"), "Invalid Ignition block", "files_dir requires butane.")
+
+		return diags
+	}
+
+	_, warnings, err := renderIgnition(model)
+	if err != nil {
+		diags.AddAttributeError(blockPath, "Invalid Ignition config", err.Error())
+	} else if warnings != "" {
+		diags.AddAttributeWarning(blockPath, "Ignition config warnings", warnings)
+	}
+
+	return diags
+}
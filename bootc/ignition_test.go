@@ -0,0 +1,162 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const testButane = `variant: fcos
+version: 1.5.0
+passwd:
+  users:
+    - name: core
+      ssh_authorized_keys:
+        - ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIExample core@example
+storage:
+  files:
+    - path: /etc/hostname
+      contents:
+        inline: node1
+`
+
+func TestRenderIgnition(t *testing.T) {
+	filesDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(filesDir, "motd"), []byte("welcome\n"), testSecureFilePerms); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		model       IgnitionModel
+		name        string
+		wantVersion string
+		wantErr     bool
+	}{
+		{IgnitionModel{Config: types.StringValue(`{"ignition":{"version":"3.4.0"}}`), Butane: types.StringNull()}, "json", "3.4.0", false},
+		{IgnitionModel{Config: types.StringValue(`{"ignition":{"version":"2.2.0"}}`), Butane: types.StringNull()}, "spec2", "", true},
+		{IgnitionModel{Config: types.StringValue(`{"ignition":`), Butane: types.StringNull()}, "malformed", "", true},
+		{IgnitionModel{Config: types.StringNull(), Butane: types.StringValue(testButane)}, "butane", "3.4.0", false},
+		{
+			IgnitionModel{
+				Config:   types.StringNull(),
+				Butane:   types.StringValue("variant: fcos\nversion: 1.5.0\nstorage:\n  files:\n    - path: /etc/motd\n      contents:\n        local: motd\n"),
+				FilesDir: types.StringValue(filesDir),
+			},
+			"butane_local", "3.4.0", false,
+		},
+		{IgnitionModel{Config: types.StringNull(), Butane: types.StringValue("version: 1.5.0\n")}, "butane_no_variant", "", true},
+		{
+			IgnitionModel{
+				Config: types.StringNull(),
+				Butane: types.StringValue("variant: fcos\nversion: 1.5.0\nstorage:\n  files:\n    - path: /etc/motd\n      contents:\n        local: motd\n"),
+			},
+			"butane_local_without_files_dir", "", true,
+		},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			config, _, err := renderIgnition(testCase.model)
+			if testCase.wantErr {
+				if !errors.Is(err, ErrInvalidIgnition) {
+					t.Errorf("expected ErrInvalidIgnition, got %v", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var parsed struct {
+				Ignition struct {
+					Version string `json:"version"`
+				} `json:"ignition"`
+			}
+
+			if err := json.Unmarshal(config, &parsed); err != nil {
+				t.Fatal(err)
+			}
+
+			if parsed.Ignition.Version != testCase.wantVersion {
+				t.Errorf("version = %q, want %q", parsed.Ignition.Version, testCase.wantVersion)
+			}
+		})
+	}
+}
+
+func TestValidateIgnition(t *testing.T) {
+	tests := []struct {
+		model   IgnitionModel
+		name    string
+		wantErr bool
+	}{
+		{IgnitionModel{Config: types.StringValue(`{"ignition":{"version":"3.4.0"}}`)}, "config", false},
+		{IgnitionModel{}, "neither", true},
+		{IgnitionModel{Config: types.StringValue("{}"), Butane: types.StringValue(testButane)}, "both", true},
+		{IgnitionModel{Config: types.StringValue(`{"ignition":{"version":"3.4.0"}}`), FilesDir: types.StringValue("/tmp")}, "files_dir_without_butane", true},
+		{IgnitionModel{Config: types.StringUnknown()}, "unknown_skipped", false},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			diags := validateIgnition(testCase.model)
+			if diags.HasError() != testCase.wantErr {
+				t.Errorf("HasError = %v, want %v: %v", diags.HasError(), testCase.wantErr, diags)
+			}
+		})
+	}
+}
+
+func TestIgnitionPlatform(t *testing.T) {
+	if got := ignitionPlatform(IgnitionModel{Platform: types.StringNull()}); got != defaultIgnitionPlatform {
+		t.Errorf("default platform = %q", got)
+	}
+
+	if got := ignitionPlatform(IgnitionModel{Platform: types.StringValue("qemu")}); got != "qemu" {
+		t.Errorf("platform = %q", got)
+	}
+}
+
+func TestBootFilesystem(t *testing.T) {
+	esp := installedPartition{Number: 2, Label: "EFI-SYSTEM", TypeGUID: testESPTypeGUID}
+	boot := installedPartition{Number: 3, Label: "boot", FSLabel: "boot"}
+	root := installedPartition{Number: 4, Label: "root", TypeGUID: testRootTypeGUID}
+
+	got, subdir, found := bootFilesystem([]installedPartition{esp, boot, root})
+	if !found || got.Number != 3 || subdir != "" {
+		t.Errorf("with boot partition = %+v, %q, %v", got, subdir, found)
+	}
+
+	got, subdir, found = bootFilesystem([]installedPartition{esp, root})
+	if !found || got.Number != 4 || subdir != "boot" {
+		t.Errorf("without boot partition = %+v, %q, %v", got, subdir, found)
+	}
+
+	if _, _, found := bootFilesystem([]installedPartition{esp}); found {
+		t.Error("expected no boot filesystem")
+	}
+}
@@ -0,0 +1,247 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"runtime"
+	"slices"
+
+	"github.com/BurntSushi/toml"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	installSourceImage     = "image"
+	installSourceTerraform = "terraform"
+	installSourceBoth      = "image+terraform"
+)
+
+// installBlockSetups are the block setups bootc install to-disk supports.
+var installBlockSetups = []string{"direct", "tpm2-luks"}
+
+// InstallConfigModel is the install_config block of bootc_image and
+// bootc_install_config.
+type InstallConfigModel struct {
+	RootFSType         types.String `tfsdk:"root_fs_type"`
+	Block              types.List   `tfsdk:"block"`
+	Kargs              types.List   `tfsdk:"kargs"`
+	MatchArchitectures types.List   `tfsdk:"match_architectures"`
+}
+
+// installConfig is the [install] table of a bootc install configuration
+// file in /usr/lib/bootc/install/.
+type installConfig struct {
+	RootFSType         string   `toml:"root-fs-type,omitempty"`
+	Block              []string `toml:"block,omitempty"`
+	Kargs              []string `toml:"kargs,omitempty"`
+	MatchArchitectures []string `toml:"match-architectures,omitempty"`
+}
+
+// printedInstallConfig is the output of bootc install print-configuration.
+// Older images set the root filesystem under filesystem.root.type.
+//
+//nolint:tagliatelle // field names follow bootc's JSON output
+type printedInstallConfig struct {
+	Filesystem *struct {
+		Root *struct {
+			Type string `json:"type"`
+		} `json:"root"`
+	} `json:"filesystem"`
+	RootFSType string   `json:"root-fs-type"`
+	Block      []string `json:"block"`
+	Kargs      []string `json:"kargs"`
+}
+
+// installConfigFromModel converts an install_config block.
+func installConfigFromModel(ctx context.Context, model *InstallConfigModel) (installConfig, diag.Diagnostics) {
+	var (
+		config installConfig
+		diags  diag.Diagnostics
+	)
+
+	if model == nil {
+		return config, diags
+	}
+
+	config.RootFSType = model.RootFSType.ValueString()
+
+	for _, list := range []struct {
+		value  types.List
+		target *[]string
+	}{
+		{model.Block, &config.Block},
+		{model.Kargs, &config.Kargs},
+		{model.MatchArchitectures, &config.MatchArchitectures},
+	} {
+		if !list.value.IsNull() {
+			diags.Append(list.value.ElementsAs(ctx, list.target, false)...)
+		}
+	}
+
+	return config, diags
+}
+
+// TOML renders the configuration as an install configuration file.
+func (c installConfig) TOML() (string, error) {
+	var buf bytes.Buffer
+
+	encoder := toml.NewEncoder(&buf)
+	encoder.Indent = ""
+
+	err := encoder.Encode(struct {
+		Install installConfig `toml:"install"`
+	}{c})
+
+	return buf.String(), err
+}
+
+// AppliesTo reports whether the configuration is used on arch, following
+// bootc's match-architectures filter.
+func (c installConfig) AppliesTo(arch string) bool {
+	return len(c.MatchArchitectures) == 0 || slices.Contains(c.MatchArchitectures, arch)
+}
+
+// InstallArgs returns the bootc install to-disk flags that apply the
+// configuration on top of the image's own. Only the first block setup is
+// passed, as bootc picks one per install.
+func (c installConfig) InstallArgs() []string {
+	var args []string
+
+	if c.RootFSType != "" {
+		args = append(args, "--filesystem", c.RootFSType)
+	}
+
+	if len(c.Block) > 0 {
+		args = append(args, "--block-setup", c.Block[0])
+	}
+
+	for _, karg := range c.Kargs {
+		args = append(args, "--karg", karg)
+	}
+
+	return args
+}
+
+// parsePrintedInstallConfig parses bootc install print-configuration.
+func parsePrintedInstallConfig(raw []byte) (installConfig, error) {
+	var printed printedInstallConfig
+
+	err := json.Unmarshal(raw, &printed)
+	if err != nil {
+		return installConfig{}, err
+	}
+
+	config := installConfig{
+		RootFSType: printed.RootFSType,
+		Block:      printed.Block,
+		Kargs:      printed.Kargs,
+	}
+
+	if config.RootFSType == "" && printed.Filesystem != nil && printed.Filesystem.Root != nil {
+		config.RootFSType = printed.Filesystem.Root.Type
+	}
+
+	return config, nil
+}
+
+// mergeInstallConfig layers an override on the image configuration the
+// way bootc merges configuration files: scalars and block are replaced,
+// kargs are appended. It returns the merged configuration and where each
+// set key came from. An override whose match-architectures excludes arch
+// is ignored.
+func mergeInstallConfig(image, override installConfig, arch string) (installConfig, map[string]string) {
+	merged := installConfig{
+		RootFSType: image.RootFSType,
+		Block:      image.Block,
+		Kargs:      image.Kargs,
+	}
+
+	sources := map[string]string{}
+
+	for key, set := range map[string]bool{
+		"root_fs_type": image.RootFSType != "",
+		"block":        len(image.Block) > 0,
+		"kargs":        len(image.Kargs) > 0,
+	} {
+		if set {
+			sources[key] = installSourceImage
+		}
+	}
+
+	if !override.AppliesTo(arch) {
+		return merged, sources
+	}
+
+	if override.RootFSType != "" {
+		merged.RootFSType = override.RootFSType
+		sources["root_fs_type"] = installSourceTerraform
+	}
+
+	if len(override.Block) > 0 {
+		merged.Block = override.Block
+		sources["block"] = installSourceTerraform
+	}
+
+	if len(override.Kargs) > 0 {
+		merged.Kargs = append(slices.Clone(image.Kargs), override.Kargs...)
+
+		sources["kargs"] = installSourceTerraform
+		if len(image.Kargs) > 0 {
+			sources["kargs"] = installSourceBoth
+		}
+	}
+
+	return merged, sources
+}
+
+// hostArchitecture returns the architecture name bootc matches
+// match-architectures against.
+func hostArchitecture() string {
+	switch runtime.GOARCH {
+	case "amd64":
+		return "x86_64"
+	case "arm64":
+		return "aarch64"
+	case "ppc64le":
+		return "powerpc64"
+	default:
+		return runtime.GOARCH
+	}
+}
+
+// validateInstallConfig checks the values of an install_config block.
+func validateInstallConfig(model *InstallConfigModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if model == nil || model.Block.IsUnknown() {
+		return diags
+	}
+
+	for _, setup := range knownStrings(model.Block) {
+		if !slices.Contains(installBlockSetups, setup) {
+			diags.AddAttributeError(path.Root("install_config").AtName("block"), "Invalid block setup",
+				"Expected one of direct, tpm2-luks, got: "+setup)
+		}
+	}
+
+	return diags
+}
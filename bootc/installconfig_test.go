@@ -0,0 +1,157 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestInstallConfig_TOML(t *testing.T) {
+	got, err := installConfig{
+		RootFSType:         "btrfs",
+		Block:              []string{"tpm2-luks"},
+		Kargs:              []string{"console=ttyS0,115200n8"},
+		MatchArchitectures: []string{"x86_64"},
+	}.TOML()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `[install]
+root-fs-type = "btrfs"
+block = ["tpm2-luks"]
+kargs = ["console=ttyS0,115200n8"]
+match-architectures = ["x86_64"]
+`
+	if got != want {
+		t.Errorf("TOML =\n%s\nwant\n%s", got, want)
+	}
+
+	empty, err := installConfig{}.TOML()
+	if err != nil || empty != "[install]\n" {
+		t.Errorf("empty TOML = %q, %v", empty, err)
+	}
+}
+
+func TestInstallConfig_InstallArgs(t *testing.T) {
+	args := installConfig{
+		RootFSType: "ext4",
+		Block:      []string{"direct", "tpm2-luks"},
+		Kargs:      []string{"nosmt", "quiet"},
+	}.InstallArgs()
+
+	want := []string{"--filesystem", "ext4", "--block-setup", "direct", "--karg", "nosmt", "--karg", "quiet"}
+	if !slices.Equal(args, want) {
+		t.Errorf("InstallArgs = %q, want %q", args, want)
+	}
+}
+
+func TestParsePrintedInstallConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"root_fs_type", `{"root-fs-type":"xfs","kargs":["rw"]}`, "xfs"},
+		{"filesystem_root", `{"filesystem":{"root":{"type":"ext4"}},"block":["direct"]}`, "ext4"},
+		{"empty", `{}`, ""},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			config, err := parsePrintedInstallConfig([]byte(testCase.raw))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if config.RootFSType != testCase.want {
+				t.Errorf("RootFSType = %q, want %q", config.RootFSType, testCase.want)
+			}
+		})
+	}
+
+	if _, err := parsePrintedInstallConfig([]byte("not json")); err == nil {
+		t.Error("expected error for invalid output")
+	}
+}
+
+func TestMergeInstallConfig(t *testing.T) {
+	image := installConfig{RootFSType: "xfs", Block: []string{"direct", "tpm2-luks"}, Kargs: []string{"rw"}}
+
+	merged, sources := mergeInstallConfig(image, installConfig{RootFSType: "btrfs", Kargs: []string{"nosmt"}}, "x86_64")
+
+	if merged.RootFSType != "btrfs" || !slices.Equal(merged.Block, image.Block) || !slices.Equal(merged.Kargs, []string{"rw", "nosmt"}) {
+		t.Errorf("merged = %+v", merged)
+	}
+
+	wantSources := map[string]string{"root_fs_type": "terraform", "block": "image", "kargs": "image+terraform"}
+	if !maps.Equal(sources, wantSources) {
+		t.Errorf("sources = %v, want %v", sources, wantSources)
+	}
+
+	skipped, sources := mergeInstallConfig(image, installConfig{RootFSType: "btrfs", MatchArchitectures: []string{"aarch64"}}, "x86_64")
+	if skipped.RootFSType != "xfs" || sources["root_fs_type"] != "image" {
+		t.Errorf("override for another architecture applied: %+v %v", skipped, sources)
+	}
+
+	if !slices.Equal(image.Kargs, []string{"rw"}) {
+		t.Errorf("image kargs modified: %q", image.Kargs)
+	}
+}
+
+func TestInstallConfigFromModel(t *testing.T) {
+	strs := func(values ...string) types.List {
+		elems := make([]attr.Value, 0, len(values))
+		for _, value := range values {
+			elems = append(elems, types.StringValue(value))
+		}
+
+		return types.ListValueMust(types.StringType, elems)
+	}
+
+	model := &InstallConfigModel{
+		RootFSType:         types.StringNull(),
+		Block:              strs("direct"),
+		Kargs:              types.ListNull(types.StringType),
+		MatchArchitectures: strs("x86_64", "aarch64"),
+	}
+
+	config, diags := installConfigFromModel(t.Context(), model)
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	if config.RootFSType != "" || !slices.Equal(config.Block, []string{"direct"}) || len(config.Kargs) != 0 ||
+		!config.AppliesTo("aarch64") || config.AppliesTo("s390x") {
+		t.Errorf("config = %+v", config)
+	}
+
+	if diags := validateInstallConfig(model); diags.HasError() {
+		t.Errorf("unexpected errors: %v", diags)
+	}
+
+	model.Block = strs("direct", "raid")
+	if diags := validateInstallConfig(model); !diags.HasError() {
+		t.Error("expected error for unknown block setup")
+	}
+}
@@ -0,0 +1,500 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	packagingISO = "iso"
+
+	afterInstallReboot   = "reboot"
+	afterInstallPoweroff = "poweroff"
+	afterInstallNone     = "none"
+
+	// isoVolumeLabel is the ISO volume ID the installer's GRUB and
+	// dmsquash-live find the medium by.
+	isoVolumeLabel = "BOOTC-INSTALLER"
+	// isoLiveDir is where dmsquash-live mounts the medium.
+	isoLiveDir = "/run/initramfs/live"
+
+	isoKernelPath    = "images/pxeboot/vmlinuz"
+	isoInitrdPath    = "images/pxeboot/initrd.img"
+	isoEFIBootPath   = "images/efiboot.img"
+	isoSquashfsPath  = "LiveOS/squashfs.img"
+	isoImageLayout   = "bootc/image"
+	isoInstallScript = "bootc/install.sh"
+	isoAuthorizedKey = "bootc/authorized_keys"
+
+	// efiBootSlack is the free space of the EFI boot image in MiB.
+	efiBootSlack = 4
+
+	// bootupdEFIPath holds the shim and GRUB binaries bootupd installs onto
+	// the ESP.
+	bootupdEFIPath = "usr/lib/bootupd/updates/EFI"
+)
+
+var ErrLiveEnvironment = errors.New("unsupported live boot environment")
+
+// InstallerModel maps the installer block.
+type InstallerModel struct {
+	Kargs        types.List   `tfsdk:"kargs"`
+	TargetDisk   types.String `tfsdk:"target_disk"`
+	AfterInstall types.String `tfsdk:"after_install"`
+}
+
+// isoInstaller is the resolved configuration of an installer ISO.
+type isoInstaller struct {
+	SourceImage    string
+	TargetDisk     string
+	AfterInstall   string
+	AuthorizedKeys string
+	// InstallArgs is the bootc install to-disk invocation, without the
+	// target disk.
+	InstallArgs []string
+	Kargs       []string
+}
+
+// installerFromModel resolves the installer block for an ISO whose
+// automated install mirrors args, the bootc install invocation of the disk
+// image. It returns nil when packaging does not list iso.
+func installerFromModel(ctx context.Context, data *ImageResourceModel, args []string) (*isoInstaller, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	if !data.hasPackaging(packagingISO) || data.Installer == nil {
+		return nil, diags
+	}
+
+	installer := &isoInstaller{
+		SourceImage:    data.SourceImage.ValueString(),
+		TargetDisk:     data.Installer.TargetDisk.ValueString(),
+		AfterInstall:   afterInstallReboot,
+		AuthorizedKeys: data.RootSSHAuthorizedKeys.ValueString(),
+	}
+
+	_, image := splitImageRef(installer.SourceImage)
+	installer.InstallArgs = installerArgs(args, image)
+
+	if !data.Installer.AfterInstall.IsNull() {
+		installer.AfterInstall = data.Installer.AfterInstall.ValueString()
+	}
+
+	diags.Append(data.Installer.Kargs.ElementsAs(ctx, &installer.Kargs, false)...)
+
+	return installer, diags
+}
+
+// installerArgs rewrites the loopback install of the disk image into an
+// install onto a real disk from the image layout on the ISO. Without
+// target_imgref, the installed system tracks image, the source image without
+// its transport, not the ISO.
+func installerArgs(args []string, sourceImage string) []string {
+	installArgs := make([]string, 0, len(args)+2)
+	tracksImage := false
+
+	for idx := 0; idx < len(args); idx++ {
+		switch args[idx] {
+		case "--via-loopback":
+			installArgs = append(installArgs, "--wipe")
+		case "--source-imgref":
+			installArgs = append(installArgs, args[idx], "oci:"+isoLiveDir+"/"+isoImageLayout)
+			idx++
+		case "--root-ssh-authorized-keys":
+			installArgs = append(installArgs, args[idx], isoLiveDir+"/"+isoAuthorizedKey)
+			idx++
+		case "--target-imgref":
+			tracksImage = true
+
+			installArgs = append(installArgs, args[idx])
+		default:
+			installArgs = append(installArgs, args[idx])
+		}
+	}
+
+	if !tracksImage {
+		installArgs = append(installArgs, "--target-imgref", sourceImage)
+	}
+
+	return installArgs
+}
+
+// shellQuote quotes an argument for /bin/sh.
+func shellQuote(arg string) string {
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// InstallScript renders the script the installer environment runs from the
+// ISO once booted.
+func (i isoInstaller) InstallScript() string {
+	quoted := make([]string, 0, len(i.InstallArgs)+1)
+	for _, arg := range append(slices.Clone(i.InstallArgs), i.TargetDisk) {
+		quoted = append(quoted, shellQuote(arg))
+	}
+
+	return "#!/bin/sh\n" +
+		"# Installs " + i.SourceImage + " from the image layout on this medium.\n" +
+		"set -eu\n" +
+		"exec " + strings.Join(quoted, " ") + "\n"
+}
+
+// GrubConfig renders the GRUB configuration of the ISO. The installer
+// environment is the source image booted live from the squashfs, which runs
+// the install script through systemd.run and then reboots or powers off.
+func (i isoInstaller) GrubConfig() string {
+	kargs := append(liveKargs("CDLABEL="+isoVolumeLabel),
+		"'systemd.run=\"/bin/sh "+isoLiveDir+"/"+isoInstallScript+"\"'",
+		"systemd.run_success_action="+i.AfterInstall, "systemd.run_failure_action=none")
+
+	return "set default=0\nset timeout=5\n\n" +
+		"search --no-floppy --set=root --label " + isoVolumeLabel + "\n\n" +
+		"menuentry 'Install bootc image onto " + i.TargetDisk + "' {\n" +
+		"\tlinux /" + isoKernelPath + " " + strings.Join(append(kargs, i.Kargs...), " ") + "\n" +
+		"\tinitrd /" + isoInitrdPath + "\n" +
+		"}\n"
+}
+
+// writeInstallerISO builds a UEFI-bootable hybrid installer ISO: the source
+// image as an OCI layout, its kernel with a live initramfs, its root
+// filesystem as the installer environment and the bootupd EFI binaries.
+func writeInstallerISO(ctx context.Context, installer isoInstaller, output string, mtime *time.Time) error {
+	var env []string
+	if mtime != nil {
+		env = []string{"SOURCE_DATE_EPOCH=" + strconv.FormatInt(mtime.Unix(), 10)}
+	}
+
+	return withPackagingDir(output, func(dir string) error {
+		isoRoot := filepath.Join(dir, "iso")
+
+		for _, sub := range []string{"images/pxeboot", "LiveOS", "bootc"} {
+			if err := os.MkdirAll(filepath.Join(isoRoot, sub), 0o755); err != nil {
+				return err
+			}
+		}
+
+		_, err := runCommand(ctx, "skopeo", "copy", "--quiet", "--preserve-digests",
+			skopeoImageRef(splitImageRef(installer.SourceImage)), "oci:"+filepath.Join(isoRoot, isoImageLayout))
+		if err != nil {
+			return err
+		}
+
+		err = writeInstallerEnvironment(ctx, installer, isoRoot, env)
+		if err != nil {
+			return err
+		}
+
+		err = writeFileMode(filepath.Join(isoRoot, isoInstallScript), []byte(installer.InstallScript()), 0o755)
+		if err != nil {
+			return err
+		}
+
+		if installer.AuthorizedKeys != "" {
+			keys, err := os.ReadFile(installer.AuthorizedKeys)
+			if err != nil {
+				return err
+			}
+
+			err = writeFileMode(filepath.Join(isoRoot, isoAuthorizedKey), keys, 0o644)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = runCommandEnv(ctx, env, "xorriso", "-as", "mkisofs", "-quiet", "-iso-level", "3", "-R", "-J",
+			"-V", isoVolumeLabel, "-e", isoEFIBootPath, "-no-emul-boot", "-isohybrid-gpt-basdat",
+			"-o", output, isoRoot)
+
+		return err
+	})
+}
+
+// writeInstallerEnvironment writes the kernel, a live initramfs, the
+// squashfs root and the EFI boot image of the source image into isoRoot.
+func writeInstallerEnvironment(ctx context.Context, installer isoInstaller, isoRoot string, env []string) error {
+	return withImageMount(ctx, installer.SourceImage, func(imageID, rootfs string) error {
+		err := writeLiveBoot(ctx, imageID, rootfs,
+			filepath.Join(isoRoot, isoKernelPath), filepath.Join(isoRoot, isoInitrdPath))
+		if err != nil {
+			return err
+		}
+
+		err = writeEFIBootImage(ctx, filepath.Join(rootfs, bootupdEFIPath), filepath.Join(isoRoot, isoEFIBootPath),
+			installer.GrubConfig())
+		if err != nil {
+			return err
+		}
+
+		return writeLiveRootfs(ctx, env, rootfs, filepath.Join(isoRoot, isoSquashfsPath))
+	})
+}
+
+// withImageMount pulls sourceImage with the transport of its reference and
+// calls fn with the pulled image ID and the root of its podman image mount.
+func withImageMount(ctx context.Context, sourceImage string, fn func(imageID, rootfs string) error) error {
+	out, err := runCommand(ctx, "podman", "pull", "--quiet", "--policy", pullPolicyMissing,
+		podmanImageRef(splitImageRef(sourceImage)))
+	if err != nil {
+		return err
+	}
+
+	lines := strings.Fields(string(out))
+	if len(lines) == 0 {
+		return fmt.Errorf("%w: podman pull reported no image ID for %s", ErrLiveEnvironment, sourceImage)
+	}
+
+	imageID := lines[len(lines)-1]
+
+	out, err = runCommand(ctx, "podman", "image", "mount", imageID)
+	if err != nil {
+		return err
+	}
+
+	err = fn(imageID, strings.TrimSpace(string(out)))
+
+	_, unmountErr := runCommand(context.WithoutCancel(ctx), "podman", "image", "unmount", imageID)
+
+	return errors.Join(err, unmountErr)
+}
+
+// writeLiveBoot copies the kernel of image, mounted at rootfs, to kernelPath
+// and regenerates its initramfs into initrdPath inside the image with dracut's
+// dmsquash-live module and the given extra modules.
+func writeLiveBoot(ctx context.Context, image, rootfs, kernelPath, initrdPath string, modules ...string) error {
+	kernels, err := filepath.Glob(filepath.Join(rootfs, "usr/lib/modules/*/vmlinuz"))
+	if err != nil || len(kernels) == 0 {
+		return fmt.Errorf("%w: no kernel in /usr/lib/modules of %s", ErrLiveEnvironment, image)
+	}
+
+	kernel, err := os.ReadFile(kernels[0])
+	if err != nil {
+		return err
+	}
+
+	err = writeFileMode(kernelPath, kernel, 0o644)
+	if err != nil {
+		return err
+	}
+
+	kver := filepath.Base(filepath.Dir(kernels[0]))
+
+	_, err = runCommand(ctx, "podman", "run", "--rm", "--network=none", "--security-opt", "label=disable",
+		"--volume", filepath.Dir(initrdPath)+":/out", "--entrypoint", "dracut", image,
+		"--quiet", "--force", "--no-hostonly", "--add", strings.Join(append([]string{"dmsquash-live"}, modules...), " "),
+		"--kver", kver, "/out/"+filepath.Base(initrdPath))
+	if err != nil {
+		return fmt.Errorf("%w: dracut: %w", ErrLiveEnvironment, err)
+	}
+
+	return nil
+}
+
+// writeLiveRootfs writes the image mounted at rootfs as the squashfs root of
+// a live boot.
+func writeLiveRootfs(ctx context.Context, env []string, rootfs, output string) error {
+	_, err := runCommandEnv(ctx, env, "mksquashfs", rootfs, output, "-noappend", "-quiet", "-comp", "xz")
+
+	return err
+}
+
+// liveKargs returns the kernel arguments booting a live root from the
+// squashfs at root, a dmsquash-live root= value without the live: prefix.
+// SELinux stays enabled but permissive, as the squashfs is not labeled.
+func liveKargs(root string) []string {
+	return []string{"root=live:" + root, "rd.live.image", "rd.live.overlay.overlayfs=1", "enforcing=0"}
+}
+
+// writeEFIBootImage writes the El Torito EFI boot image of the ISO: a FAT
+// filesystem holding the image's bootupd EFI binaries from efiDir, with
+// grubConfig in each vendor directory, where the shim's GRUB looks for it.
+func writeEFIBootImage(ctx context.Context, efiDir, output, grubConfig string) error {
+	vendors, err := os.ReadDir(efiDir)
+	if err != nil {
+		return fmt.Errorf("%w: no bootupd EFI binaries: %w", ErrLiveEnvironment, err)
+	}
+
+	var size int64
+
+	err = filepath.WalkDir(efiDir, func(_ string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err == nil {
+			size += info.Size()
+		}
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// Leave room for the FAT, directories and cluster slack.
+	size = (size/mebibyte + efiBootSlack) * mebibyte
+
+	err = os.WriteFile(output, nil, 0o644)
+	if err == nil {
+		err = os.Truncate(output, size)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	_, err = runCommand(ctx, "mkfs.vfat", "-n", "EFIBOOT", output)
+	if err != nil {
+		return err
+	}
+
+	mounts, err := newDiskMounts(output)
+	if err != nil {
+		return err
+	}
+
+	err = func() error {
+		espDir, err := mounts.Mount(withSectorSize(ctx, defaultSectorSize),
+			installedPartition{Number: 1, Size: size, Filesystem: "vfat"})
+		if err != nil {
+			return err
+		}
+
+		_, err = runCommand(ctx, "cp", "-r", "--", efiDir, filepath.Join(espDir, "EFI"))
+		if err != nil {
+			return err
+		}
+
+		for _, vendor := range vendors {
+			if !vendor.IsDir() {
+				continue
+			}
+
+			err = os.WriteFile(filepath.Join(espDir, "EFI", vendor.Name(), "grub.cfg"), []byte(grubConfig), 0o644)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}()
+
+	return errors.Join(err, mounts.Close(ctx))
+}
+
+// validateLiveKargs checks that each kernel argument of a live boot is a
+// single word the boot loader passes through unquoted.
+func validateLiveKargs(kargs types.List, attrPath path.Path) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	for idx, elem := range kargs.Elements() {
+		karg, ok := elem.(types.String)
+		if ok && knownString(karg) && (karg.ValueString() == "" || strings.ContainsAny(karg.ValueString(), " \t\n'\"")) {
+			diags.AddAttributeError(attrPath.AtListIndex(idx), "Invalid kernel argument",
+				"Expected a single argument name or name=value, got: "+karg.ValueString())
+		}
+	}
+
+	return diags
+}
+
+// validateInstaller checks the installer block against packaging and the
+// options an install onto a real disk can reproduce.
+func validateInstaller(data *ImageResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	blockPath := path.Root("installer")
+	iso := data.hasPackaging(packagingISO)
+
+	switch {
+	case iso && (data.Installer == nil || data.Installer.TargetDisk.IsNull()):
+		diags.AddAttributeError(blockPath.AtName("target_disk"), "Missing installer target disk",
+			"packaging = \"iso\" requires installer.target_disk, the disk the ISO installs onto.")
+
+		return diags
+	case data.Installer == nil:
+		return diags
+	case !iso:
+		diags.AddAttributeError(blockPath, "Unused installer block",
+			"The installer block configures the installer ISO. Add \"iso\" to packaging.")
+
+		return diags
+	}
+
+	if disk := data.Installer.TargetDisk; knownString(disk) &&
+		(!strings.HasPrefix(disk.ValueString(), "/dev/") || strings.ContainsAny(disk.ValueString(), " \t\n'\"")) {
+		diags.AddAttributeError(blockPath.AtName("target_disk"), "Invalid installer target disk",
+			"Expected a device path such as /dev/sda or /dev/disk/by-path/..., got: "+disk.ValueString())
+	}
+
+	diags.Append(validateLiveKargs(data.Installer.Kargs, blockPath.AtName("kargs"))...)
+
+	if data.partitionsDisk() {
+		diags.AddAttributeError(path.Root("packaging"), "Conflicting installer options",
+			"The installer ISO runs bootc install to-disk on the target and cannot reproduce partition_layout, "+
+				"filesystem_options, luks-passphrase, partition_table, bios_boot or sector_size.")
+	}
+
+	if unsupported := installerUnsupported(data); len(unsupported) > 0 {
+		diags.AddAttributeError(path.Root("packaging"), "Conflicting installer options",
+			"The installer ISO only runs bootc install to-disk on the target and does not apply the customizations "+
+				"of the disk image, so a machine installed from it would differ from the disk image. Remove "+
+				strings.Join(unsupported, ", ")+" or build the iso packaging from a separate bootc_image.")
+	}
+
+	return diags
+}
+
+// installerUnsupported returns the attributes that customize the disk image
+// after bootc install, which an install from the ISO does not reproduce.
+func installerUnsupported(data *ImageResourceModel) []string {
+	var unsupported []string
+
+	for _, attr := range []struct {
+		name string
+		set  bool
+	}{
+		{"files", len(data.Files) > 0},
+		{"systemd_units", len(data.SystemdUnits) > 0},
+		{"network", len(data.Network) > 0},
+		{"ignition", data.Ignition != nil},
+		{"bound_images", len(data.BoundImages) > 0},
+		{"host_registry_auth", !data.HostRegistryAuth.IsNull()},
+		{"auto_update", data.AutoUpdate != nil},
+		{"kargs_remove", len(data.KargsRemove.Elements()) > 0},
+		{"secure_boot", data.SecureBoot != nil},
+		{"reproducible", data.Reproducible != nil},
+		{"platform (guest agent " + data.guestAgent() + ")", data.guestAgent() != ""},
+	} {
+		if attr.set {
+			unsupported = append(unsupported, attr.name)
+		}
+	}
+
+	return unsupported
+}
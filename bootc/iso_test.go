@@ -0,0 +1,184 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestInstallerArgs(t *testing.T) {
+	args := []string{
+		"bootc", "install", "to-disk", "--via-loopback", "--source-imgref", "containers-storage:quay.io/example/os:1",
+		"--root-ssh-authorized-keys", "/tmp/keys", "--karg", "console=ttyS0",
+	}
+
+	got := installerArgs(args, "quay.io/example/os:1")
+	want := []string{
+		"bootc", "install", "to-disk", "--wipe", "--source-imgref", "oci:/run/initramfs/live/bootc/image",
+		"--root-ssh-authorized-keys", "/run/initramfs/live/bootc/authorized_keys", "--karg", "console=ttyS0",
+		"--target-imgref", "quay.io/example/os:1",
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("installerArgs() = %q, want %q", got, want)
+	}
+
+	got = installerArgs([]string{"bootc", "install", "to-disk", "--target-imgref", "registry/os:stable"}, "registry/os:1")
+	if strings.Count(strings.Join(got, " "), "--target-imgref") != 1 || got[len(got)-1] != "registry/os:stable" {
+		t.Errorf("installerArgs() = %q, want the configured target_imgref kept", got)
+	}
+}
+
+func TestInstallerFromModel(t *testing.T) {
+	data := ImageResourceModel{
+		SourceImage: types.StringValue("containers-storage:localhost/os:dev"),
+		Packaging:   packagingList(packagingISO),
+		Installer:   &InstallerModel{TargetDisk: types.StringValue("/dev/sda"), Kargs: types.ListNull(types.StringType)},
+	}
+
+	installer, diags := installerFromModel(t.Context(), &data, []string{"bootc", "install", "to-disk"})
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	if installer.AfterInstall != afterInstallReboot ||
+		!slices.Equal(installer.InstallArgs, []string{"bootc", "install", "to-disk", "--target-imgref", "localhost/os:dev"}) {
+		t.Errorf("installer = %+v", installer)
+	}
+}
+
+func TestIsoInstaller_InstallScript(t *testing.T) {
+	installer := isoInstaller{
+		SourceImage: "quay.io/example/os:1",
+		TargetDisk:  "/dev/nvme0n1",
+		InstallArgs: []string{"bootc", "install", "to-disk", "--karg", "rd.info='x'"},
+	}
+
+	script := installer.InstallScript()
+
+	if !strings.HasPrefix(script, "#!/bin/sh\n") {
+		t.Errorf("script should start with a shebang:\n%s", script)
+	}
+
+	want := `exec 'bootc' 'install' 'to-disk' '--karg' 'rd.info='\''x'\''' '/dev/nvme0n1'` + "\n"
+	if !strings.HasSuffix(script, want) {
+		t.Errorf("script = %q, want suffix %q", script, want)
+	}
+}
+
+func TestIsoInstaller_GrubConfig(t *testing.T) {
+	installer := isoInstaller{
+		TargetDisk:   "/dev/sda",
+		AfterInstall: afterInstallPoweroff,
+		Kargs:        []string{"console=ttyS0,115200n8"},
+	}
+
+	config := installer.GrubConfig()
+
+	for _, want := range []string{
+		"search --no-floppy --set=root --label BOOTC-INSTALLER",
+		"linux /images/pxeboot/vmlinuz root=live:CDLABEL=BOOTC-INSTALLER rd.live.image",
+		`'systemd.run="/bin/sh /run/initramfs/live/bootc/install.sh"'`,
+		"systemd.run_success_action=poweroff",
+		"console=ttyS0,115200n8\n",
+		"initrd /images/pxeboot/initrd.img",
+		"onto /dev/sda",
+	} {
+		if !strings.Contains(config, want) {
+			t.Errorf("grub.cfg missing %s:\n%s", want, config)
+		}
+	}
+}
+
+func TestValidateInstaller(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    ImageResourceModel
+		wantErr string
+	}{
+		{"none", ImageResourceModel{}, ""},
+		{"iso", ImageResourceModel{
+			Packaging: packagingList(packagingISO),
+			Installer: &InstallerModel{
+				TargetDisk: types.StringValue("/dev/disk/by-path/pci-0000:00:1f.2-ata-1"),
+				Kargs:      types.ListValueMust(types.StringType, nil),
+			},
+		}, ""},
+		{"missing_block", ImageResourceModel{Packaging: packagingList(packagingISO)}, "Missing installer target disk"},
+		{"missing_target", ImageResourceModel{
+			Packaging: packagingList(packagingISO),
+			Installer: &InstallerModel{TargetDisk: types.StringNull()},
+		}, "Missing installer target disk"},
+		{"unused", ImageResourceModel{
+			Installer: &InstallerModel{TargetDisk: types.StringValue("/dev/sda")},
+		}, "Unused installer block"},
+		{"target_disk", ImageResourceModel{
+			Packaging: packagingList(packagingISO),
+			Installer: &InstallerModel{TargetDisk: types.StringValue("sda")},
+		}, "Invalid installer target disk"},
+		{"kargs", ImageResourceModel{
+			Packaging: packagingList(packagingISO),
+			Installer: &InstallerModel{
+				TargetDisk: types.StringValue("/dev/sda"),
+				Kargs:      packagingList("console=ttyS0 quiet"),
+			},
+		}, "Invalid kernel argument"},
+		{"partitions", ImageResourceModel{
+			Packaging:      packagingList(packagingISO),
+			Installer:      &InstallerModel{TargetDisk: types.StringValue("/dev/sda")},
+			PartitionTable: types.StringValue(partitionTableMBR),
+		}, "Conflicting installer options"},
+		{"files", ImageResourceModel{
+			Packaging: packagingList(packagingISO),
+			Installer: &InstallerModel{TargetDisk: types.StringValue("/dev/sda")},
+			Files:     []FileModel{{Path: types.StringValue("/etc/motd")}},
+		}, "Conflicting installer options"},
+		{"secure_boot", ImageResourceModel{
+			Packaging:  packagingList(packagingISO),
+			Installer:  &InstallerModel{TargetDisk: types.StringValue("/dev/sda")},
+			SecureBoot: &SecureBootModel{},
+		}, "Conflicting installer options"},
+		{"kargs_remove", ImageResourceModel{
+			Packaging:   packagingList(packagingISO),
+			Installer:   &InstallerModel{TargetDisk: types.StringValue("/dev/sda")},
+			KargsRemove: packagingList("quiet"),
+		}, "Conflicting installer options"},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			diags := validateInstaller(&testCase.data)
+
+			if testCase.wantErr == "" {
+				if diags.HasError() {
+					t.Errorf("unexpected diagnostics: %v", diags)
+				}
+
+				return
+			}
+
+			if !diags.HasError() || diags.Errors()[0].Summary() != testCase.wantErr {
+				t.Errorf("diagnostics = %v, want %q", diags, testCase.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// bootEntriesGlob matches the Boot Loader Specification entries ostree
+// writes for each deployment.
+const bootEntriesGlob = "loader/entries/*.conf"
+
+// protectedKargs are needed to boot the deployment and cannot be removed.
+var protectedKargs = []string{"ostree", "root"}
+
+var ErrBootEntriesNotFound = errors.New("no boot loader entries found")
+
+// splitKargs splits a kernel command line into arguments, keeping
+// double-quoted values together.
+func splitKargs(cmdline string) []string {
+	var (
+		kargs   []string
+		current strings.Builder
+		quoted  bool
+	)
+
+	for _, r := range cmdline {
+		switch {
+		case r == '"':
+			quoted = !quoted
+
+			current.WriteRune(r)
+		case (r == ' ' || r == '\t') && !quoted:
+			if current.Len() > 0 {
+				kargs = append(kargs, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+
+	if current.Len() > 0 {
+		kargs = append(kargs, current.String())
+	}
+
+	return kargs
+}
+
+// kargKey returns the name of a kernel argument (console=ttyS0 → console).
+func kargKey(karg string) string {
+	key, _, _ := strings.Cut(karg, "=")
+
+	return key
+}
+
+// removeKargs drops the arguments matched by remove from kargs. An entry
+// without a value removes every argument with that name; an entry with a
+// value removes only the exact argument. Arguments listed in keep are
+// never removed, so an explicitly added karg survives removal of its name.
+func removeKargs(kargs, remove, keep []string) []string {
+	return slices.DeleteFunc(slices.Clone(kargs), func(karg string) bool {
+		if slices.Contains(keep, karg) {
+			return false
+		}
+
+		for _, pattern := range remove {
+			if pattern == karg || (!strings.Contains(pattern, "=") && pattern == kargKey(karg)) {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// bootEntryKargs removes kargs from the options line of every boot loader
+// entry under bootDir and returns the resulting kernel arguments of the
+// first entry.
+func bootEntryKargs(bootDir string, remove, keep []string, mtime *time.Time) ([]string, error) {
+	entries, err := filepath.Glob(filepath.Join(bootDir, bootEntriesGlob))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return nil, ErrBootEntriesNotFound
+	}
+
+	slices.Sort(entries)
+
+	var effective []string
+
+	for idx, entry := range entries {
+		kargs, err := rewriteBootEntry(entry, remove, keep, mtime)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(entry), err)
+		}
+
+		if idx == 0 {
+			effective = kargs
+		}
+	}
+
+	return effective, nil
+}
+
+// rewriteBootEntry applies removeKargs to the options line of a boot
+// loader entry and returns the remaining arguments. The file is only
+// rewritten when an argument was removed.
+func rewriteBootEntry(entry string, remove, keep []string, mtime *time.Time) ([]string, error) {
+	content, err := os.ReadFile(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(string(content), "\n")
+
+	var (
+		kargs   []string
+		changed bool
+	)
+
+	for idx, line := range lines {
+		key, value, _ := strings.Cut(strings.TrimSpace(line), " ")
+		if key != "options" {
+			continue
+		}
+
+		current := splitKargs(value)
+		kargs = removeKargs(current, remove, keep)
+
+		if len(kargs) != len(current) {
+			lines[idx] = "options " + strings.Join(kargs, " ")
+			changed = true
+		}
+	}
+
+	if !changed {
+		return kargs, nil
+	}
+
+	info, err := os.Stat(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.WriteFile(entry, []byte(strings.Join(lines, "\n")), info.Mode().Perm())
+	if err == nil && mtime != nil {
+		err = lchtimes(entry, *mtime)
+	}
+
+	return kargs, err
+}
+
+// applyKargs removes kargs from the boot loader entries of a raw disk and
+// returns the effective kernel command line of the installed deployment.
+func applyKargs(ctx context.Context, rawPath string, remove, keep []string, mtime *time.Time) ([]string, error) {
+	var effective []string
+
+	err := customizeBoot(ctx, rawPath, func(bootDir string) error {
+		var err error
+
+		effective, err = bootEntryKargs(bootDir, remove, keep, mtime)
+
+		return err
+	})
+
+	return effective, err
+}
+
+// validateKargsRemove checks that kargs_remove entries are single arguments
+// and leave the deployment bootable.
+func validateKargsRemove(list types.List) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	for idx, elem := range list.Elements() {
+		str, ok := elem.(types.String)
+		if !ok || str.IsNull() || str.IsUnknown() {
+			continue
+		}
+
+		karg := str.ValueString()
+		elemPath := path.Root("kargs_remove").AtListIndex(idx)
+
+		switch {
+		case karg == "" || strings.ContainsAny(karg, " \t\""):
+			diags.AddAttributeError(elemPath, "Invalid kernel argument",
+				"Expected a single argument name or name=value, got: "+karg)
+		case slices.Contains(protectedKargs, kargKey(karg)):
+			diags.AddAttributeError(elemPath, "Invalid kernel argument",
+				kargKey(karg)+"= is required to boot the deployment and cannot be removed.")
+		}
+	}
+
+	return diags
+}
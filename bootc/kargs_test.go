@@ -0,0 +1,160 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const testBootEntry = `title Fedora Linux 42 (ostree:0)
+version 1
+options root=UUID=0f1c rw boot=UUID=4a2e rhgb quiet console=tty0 console=ttyS0,115200n8 systemd.setenv="A=b c" ostree=/ostree/boot.1/default/abc/0
+linux /ostree/default-abc/vmlinuz
+initrd /ostree/default-abc/initramfs.img
+`
+
+func TestSplitKargs(t *testing.T) {
+	got := splitKargs(`  root=UUID=0f1c  rw systemd.setenv="A=b c"	quiet `)
+	want := []string{"root=UUID=0f1c", "rw", `systemd.setenv="A=b c"`, "quiet"}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("splitKargs = %q, want %q", got, want)
+	}
+}
+
+func TestRemoveKargs(t *testing.T) {
+	kargs := []string{"rw", "rhgb", "quiet", "console=tty0", "console=ttyS0,115200n8", "nosmt=force"}
+
+	tests := []struct {
+		name   string
+		remove []string
+		keep   []string
+		want   []string
+	}{
+		{"by_name", []string{"rhgb", "quiet"}, nil, []string{"rw", "console=tty0", "console=ttyS0,115200n8", "nosmt=force"}},
+		{"exact", []string{"console=tty0"}, nil, []string{"rw", "rhgb", "quiet", "console=ttyS0,115200n8", "nosmt=force"}},
+		{"name_removes_all_values", []string{"console"}, nil, []string{"rw", "rhgb", "quiet", "nosmt=force"}},
+		{"keep_wins", []string{"console"}, []string{"console=ttyS0,115200n8"}, []string{"rw", "rhgb", "quiet", "console=ttyS0,115200n8", "nosmt=force"}},
+		{"keep_by_name", []string{"nosmt"}, []string{"nosmt=force"}, kargs},
+		{"no_match", []string{"nosmt=off", "splash"}, nil, kargs},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := removeKargs(kargs, testCase.remove, testCase.keep); !slices.Equal(got, testCase.want) {
+				t.Errorf("removeKargs = %q, want %q", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestBootEntryKargs(t *testing.T) {
+	bootDir := t.TempDir()
+	entriesDir := filepath.Join(bootDir, "loader.1", "entries")
+
+	if err := os.MkdirAll(entriesDir, testDefaultDirPerms); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink("loader.1", filepath.Join(bootDir, "loader")); err != nil {
+		t.Fatal(err)
+	}
+
+	entry := filepath.Join(entriesDir, "ostree-1.conf")
+	if err := os.WriteFile(entry, []byte(testBootEntry), testSecureFilePerms); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := bootEntryKargs(t.TempDir(), nil, nil, nil)
+	if !errors.Is(err, ErrBootEntriesNotFound) {
+		t.Errorf("expected ErrBootEntriesNotFound, got %v", err)
+	}
+
+	unchanged, err := bootEntryKargs(bootDir, []string{"splash"}, nil, nil)
+	if err != nil || len(unchanged) != 9 {
+		t.Fatalf("kargs = %q, %v", unchanged, err)
+	}
+
+	mtime := time.Unix(1760745600, 0)
+
+	got, err := bootEntryKargs(bootDir, []string{"rhgb", "quiet", "console"}, []string{"console=ttyS0,115200n8"}, &mtime)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"root=UUID=0f1c", "rw", "boot=UUID=4a2e", "console=ttyS0,115200n8",
+		`systemd.setenv="A=b c"`, "ostree=/ostree/boot.1/default/abc/0",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("effective kargs = %q, want %q", got, want)
+	}
+
+	content, err := os.ReadFile(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantEntry := `title Fedora Linux 42 (ostree:0)
+version 1
+options root=UUID=0f1c rw boot=UUID=4a2e console=ttyS0,115200n8 systemd.setenv="A=b c" ostree=/ostree/boot.1/default/abc/0
+linux /ostree/default-abc/vmlinuz
+initrd /ostree/default-abc/initramfs.img
+`
+	if string(content) != wantEntry {
+		t.Errorf("entry =\n%s\nwant\n%s", content, wantEntry)
+	}
+
+	info, err := os.Stat(entry)
+	if err != nil || !info.ModTime().Equal(mtime) || info.Mode().Perm() != testSecureFilePerms {
+		t.Errorf("entry stat = %v, %v", info, err)
+	}
+}
+
+func TestValidateKargsRemove(t *testing.T) {
+	tests := []struct {
+		name    string
+		kargs   []attr.Value
+		wantErr bool
+	}{
+		{"names_and_values", []attr.Value{types.StringValue("rhgb"), types.StringValue("console=tty0")}, false},
+		{"unknown", []attr.Value{types.StringUnknown()}, false},
+		{"empty", []attr.Value{types.StringValue("")}, true},
+		{"two_args", []attr.Value{types.StringValue("rhgb quiet")}, true},
+		{"ostree", []attr.Value{types.StringValue("ostree")}, true},
+		{"root", []attr.Value{types.StringValue("root=UUID=0f1c")}, true},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			diags := validateKargsRemove(types.ListValueMust(types.StringType, testCase.kargs))
+			if diags.HasError() != testCase.wantErr {
+				t.Errorf("HasError = %v, want %v: %v", diags.HasError(), testCase.wantErr, diags)
+			}
+		})
+	}
+}
@@ -0,0 +1,596 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	espPartitionType      = "c12a7328-f81f-11d2-ba4b-00a0c93ec93b"
+	biosBootPartitionType = "21686148-6449-6e6f-744e-656564454649"
+	swapPartitionType     = "0657fd6d-a4ab-43c4-84e5-0933c84b4f4f"
+	linuxPartitionType    = "0fc63daf-8483-4772-8e79-3d69d8477de4"
+
+	defaultESPSize        = "512M"
+	defaultBootSize       = "1G"
+	defaultBootFilesystem = "ext4"
+	defaultRootFilesystem = "xfs"
+	biosBootSize          = "1M"
+
+	swapMountPoint    = "swap"
+	defaultSectorSize = 512
+	maxFSLabelLen     = 12
+)
+
+var (
+	ErrInvalidSize    = errors.New("invalid size")
+	ErrLayoutMismatch = errors.New("partition table does not match partition_layout")
+)
+
+// sizePattern matches sizes with an optional binary K, M, G or T suffix.
+var sizePattern = regexp.MustCompile(`^([0-9]+)([KMGT]?)$`)
+
+// rootPartitionTypeByArch maps bootc architecture names to the
+// Discoverable Partitions Specification root partition type.
+var rootPartitionTypeByArch = map[string]string{
+	"x86_64":    rootPartitionTypes[0],
+	"aarch64":   rootPartitionTypes[1],
+	"powerpc64": rootPartitionTypes[2],
+	"s390x":     rootPartitionTypes[3],
+	"riscv64":   rootPartitionTypes[4],
+}
+
+// PartitionLayoutModel is the partition_layout block of bootc_image.
+type PartitionLayoutModel struct {
+	Partitions      []LayoutPartitionModel `tfsdk:"partition"`
+	RootMkfsOptions types.List             `tfsdk:"root_mkfs_options"`
+	ESPSize         types.String           `tfsdk:"esp_size"`
+	BootSize        types.String           `tfsdk:"boot_size"`
+	BootFilesystem  types.String           `tfsdk:"boot_filesystem"`
+}
+
+// LayoutPartitionModel is a partition entry of the partition_layout block.
+type LayoutPartitionModel struct {
+	MkfsOptions types.List   `tfsdk:"mkfs_options"`
+	MountPoint  types.String `tfsdk:"mount_point"`
+	Size        types.String `tfsdk:"size"`
+	Filesystem  types.String `tfsdk:"filesystem"`
+	Label       types.String `tfsdk:"label"`
+}
+
+// layoutPartition is a resolved partition of a custom layout. Partitions
+// are created in order, so the partition number is the index plus one.
+type layoutPartition struct {
+	Name        string
+	TypeGUID    string
+	Size        string
+	Filesystem  string
+	FSLabel     string
+	MountPoint  string
+	MkfsOptions []string
+	// MountOptions are used while bootc installs onto the partition.
+	MountOptions []string
+	// Encrypted puts the filesystem in a LUKS container unlocked with the
+	// context's passphrase.
+	Encrypted bool
+	// Bootable sets the MBR active flag some BIOSes look for.
+	Bootable bool
+}
+
+// IsExtra reports whether the partition is mounted from the deployment's
+// /etc/fstab rather than set up by bootc.
+func (p layoutPartition) IsExtra() bool {
+	return p.MountPoint == swapMountPoint || p.MountPoint == "/var" || strings.HasPrefix(p.MountPoint, "/var/")
+}
+
+// parseSize converts a size with an optional binary K, M, G or T suffix to
+// bytes.
+func parseSize(size string) (int64, error) {
+	match := sizePattern.FindStringSubmatch(size)
+	if match == nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidSize, size)
+	}
+
+	value, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidSize, err)
+	}
+
+	shift := strings.Index("KMGT", match[2]) + 1
+	if match[2] == "" {
+		shift = 0
+	}
+
+	return value << (10 * shift), nil
+}
+
+// partitionLayout resolves the partition_layout block for label. A GPT
+// gets a BIOS boot partition for BIOS boot, and root comes last so that it
+// takes the remaining space unless rootSize is set.
+func partitionLayout(
+	ctx context.Context,
+	model *PartitionLayoutModel,
+	rootFS, rootSize, arch string,
+	label diskLabel,
+) ([]layoutPartition, diag.Diagnostics) {
+	var (
+		diags diag.Diagnostics
+		parts []layoutPartition
+	)
+
+	valueOr := func(value types.String, fallback string) string {
+		if value.IsNull() || value.ValueString() == "" {
+			return fallback
+		}
+
+		return value.ValueString()
+	}
+
+	stringList := func(list types.List) []string {
+		var values []string
+		if !list.IsNull() {
+			diags.Append(list.ElementsAs(ctx, &values, false)...)
+		}
+
+		return values
+	}
+
+	if label.BIOSBoot && label.Table == partitionTableGPT {
+		parts = append(parts, layoutPartition{Name: "BIOS-BOOT", TypeGUID: biosBootPartitionType, Size: biosBootSize})
+	}
+
+	parts = append(parts,
+		layoutPartition{
+			Name: "EFI-SYSTEM", TypeGUID: espPartitionType, Size: valueOr(model.ESPSize, defaultESPSize),
+			Filesystem: "vfat", FSLabel: "EFI-SYSTEM", MountPoint: "/boot/efi",
+		},
+		layoutPartition{
+			Name: "boot", TypeGUID: linuxPartitionType, Size: valueOr(model.BootSize, defaultBootSize),
+			Filesystem: valueOr(model.BootFilesystem, defaultBootFilesystem), FSLabel: "boot", MountPoint: "/boot",
+			Bootable: label.BIOSBoot && label.Table == partitionTableMBR,
+		},
+	)
+
+	extras := make([]layoutPartition, 0, len(model.Partitions))
+
+	for _, entry := range model.Partitions {
+		mountPoint := filepath.Clean(entry.MountPoint.ValueString())
+		if entry.MountPoint.ValueString() == swapMountPoint {
+			mountPoint = swapMountPoint
+		}
+
+		part := layoutPartition{
+			Name:        strings.ReplaceAll(strings.TrimPrefix(mountPoint, "/"), "/", "-"),
+			TypeGUID:    linuxPartitionType,
+			Size:        entry.Size.ValueString(),
+			Filesystem:  valueOr(entry.Filesystem, rootFS),
+			FSLabel:     entry.Label.ValueString(),
+			MountPoint:  mountPoint,
+			MkfsOptions: stringList(entry.MkfsOptions),
+		}
+
+		if mountPoint == swapMountPoint {
+			part.TypeGUID = swapPartitionType
+			part.Filesystem = swapMountPoint
+		}
+
+		extras = append(extras, part)
+	}
+
+	// Parents before children, so fstab mounts nest correctly.
+	slices.SortStableFunc(extras, func(a, b layoutPartition) int { return strings.Compare(a.MountPoint, b.MountPoint) })
+
+	rootType, ok := rootPartitionTypeByArch[arch]
+	if !ok {
+		rootType = linuxPartitionType
+	}
+
+	parts = append(parts, extras...)
+	parts = append(parts, layoutPartition{
+		Name: "root", TypeGUID: rootType, Size: rootSize, Filesystem: rootFS, FSLabel: "root",
+		MountPoint: "/", MkfsOptions: stringList(model.RootMkfsOptions),
+	})
+
+	return parts, diags
+}
+
+// sfdiskScript renders the layout as an sfdisk script for label. A
+// partition without a size takes the remaining space. MBR partitions have
+// no names and get the MBR type of their GPT type.
+func sfdiskScript(parts []layoutPartition, label diskLabel) (string, error) {
+	var script strings.Builder
+
+	if label.Table == partitionTableMBR {
+		script.WriteString("label: dos\n")
+	} else {
+		script.WriteString("label: gpt\n")
+	}
+
+	if label.SectorSize != defaultSectorSize {
+		script.WriteString("sector-size: " + strconv.FormatInt(label.SectorSize, 10) + "\n")
+	}
+
+	for _, part := range parts {
+		fields := []string{"type=" + part.TypeGUID, fmt.Sprintf("name=%q", part.Name)}
+		if label.Table == partitionTableMBR {
+			fields = []string{"type=" + mbrPartitionType(part.TypeGUID)}
+		}
+
+		if part.Bootable {
+			fields = append(fields, "bootable")
+		}
+
+		if part.Size != "" {
+			bytes, err := parseSize(part.Size)
+			if err != nil {
+				return "", fmt.Errorf("%s: %w", part.Name, err)
+			}
+
+			sectors := (bytes + label.SectorSize - 1) / label.SectorSize
+			fields = append([]string{"size=" + strconv.FormatInt(sectors, 10)}, fields...)
+		}
+
+		script.WriteString(strings.Join(fields, ", ") + "\n")
+	}
+
+	return script.String(), nil
+}
+
+// mkfsCommand returns the command that creates the partition's filesystem
+// on dev.
+func mkfsCommand(part layoutPartition, dev string) (string, []string) {
+	var (
+		name string
+		args []string
+	)
+
+	switch part.Filesystem {
+	case "vfat":
+		name, args = "mkfs.vfat", []string{"-F", "32"}
+		if part.FSLabel != "" {
+			args = append(args, "-n", part.FSLabel)
+		}
+	case swapMountPoint:
+		name = "mkswap"
+	case "ext4":
+		name, args = "mkfs.ext4", []string{"-q", "-F"}
+	default:
+		name, args = "mkfs."+part.Filesystem, []string{"-q", "-f"}
+	}
+
+	if part.Filesystem != "vfat" && part.FSLabel != "" {
+		args = append(args, "-L", part.FSLabel)
+	}
+
+	args = append(args, part.MkfsOptions...)
+
+	return name, append(args, dev)
+}
+
+// createLayout writes the partition table of the layout to rawPath and
+// creates each partition's filesystem.
+func createLayout(ctx context.Context, rawPath string, parts []layoutPartition, label diskLabel) error {
+	script, err := sfdiskScript(parts, label)
+	if err != nil {
+		return err
+	}
+
+	_, err = runCommandInput(ctx, nil, []byte(script), "sfdisk", "--quiet", "--no-reread", "--no-tell-kernel",
+		"--wipe", "always", rawPath)
+	if err != nil {
+		return err
+	}
+
+	partitions, err := readInstalledPartitions(ctx, rawPath)
+	if err != nil {
+		return err
+	}
+
+	if len(partitions) != len(parts) {
+		return fmt.Errorf("%w: created %d partitions, expected %d", ErrLayoutMismatch, len(partitions), len(parts))
+	}
+
+	mounts, err := newDiskMounts(rawPath)
+	if err != nil {
+		return err
+	}
+
+	for idx, part := range parts {
+		if part.Filesystem == "" {
+			continue
+		}
+
+		var dev string
+
+		dev, err = mounts.Attach(ctx, partitions[idx])
+		if err == nil && part.Encrypted {
+			dev, err = formatLUKS(ctx, mounts, dev)
+		}
+
+		if err != nil {
+			break
+		}
+
+		name, args := mkfsCommand(part, dev)
+
+		_, err = runCommand(ctx, name, args...)
+		if err != nil {
+			err = fmt.Errorf("%s: %w", part.Name, err)
+
+			break
+		}
+	}
+
+	return errors.Join(err, mounts.Close(ctx))
+}
+
+// mountLayoutTarget mounts the root, /boot and EFI system partitions of a
+// custom layout as bootc install to-filesystem expects them and calls
+// install with the target directory. The disk is attached as a whole so
+// bootc can find it to install the bootloader.
+func mountLayoutTarget(ctx context.Context, rawPath string, parts []layoutPartition, install func(target string) error) error {
+	mounts, err := newDiskMounts(rawPath)
+	if err != nil {
+		return err
+	}
+
+	target := filepath.Join(mounts.dir, "target")
+
+	// Mount points sort parents first: "/" < "/boot" < "/boot/efi".
+	order := make([]int, 0, 3)
+
+	for idx, part := range parts {
+		if part.MountPoint != "" && !part.IsExtra() {
+			order = append(order, idx)
+		}
+	}
+
+	slices.SortFunc(order, func(a, b int) int { return strings.Compare(parts[a].MountPoint, parts[b].MountPoint) })
+
+	disk, err := mounts.AttachDisk(ctx)
+
+	for _, idx := range order {
+		if err != nil {
+			break
+		}
+
+		dev := disk + "p" + strconv.Itoa(idx+1)
+		if parts[idx].Encrypted {
+			dev, err = mounts.Unlock(ctx, dev)
+			if err != nil {
+				break
+			}
+		}
+
+		err = mounts.mountDevice(ctx, dev, parts[idx].Filesystem, filepath.Join(target, parts[idx].MountPoint),
+			parts[idx].MountOptions...)
+	}
+
+	if err == nil {
+		err = install(target)
+	}
+
+	return errors.Join(err, mounts.Close(ctx))
+}
+
+// finalizeLayout moves the installed /var content below each extra mount
+// point onto its partition and adds the partitions to the deployment's
+// /etc/fstab.
+func finalizeLayout(ctx context.Context, rawPath string, parts []layoutPartition, mtime *time.Time) error {
+	partitions, err := readInstalledPartitions(ctx, rawPath)
+	if err != nil {
+		return err
+	}
+
+	if len(partitions) != len(parts) {
+		return fmt.Errorf("%w: found %d partitions, expected %d", ErrLayoutMismatch, len(partitions), len(parts))
+	}
+
+	return customizeDeployment(ctx, rawPath, func(deployment installedDeployment) error {
+		mounts, err := newDiskMounts(rawPath)
+		if err != nil {
+			return err
+		}
+
+		err = populateLayout(ctx, mounts, deployment, parts, partitions)
+		if err == nil {
+			err = appendFstab(deployment, parts, partitions, mtime)
+		}
+
+		return errors.Join(err, mounts.Close(ctx))
+	})
+}
+
+// populateLayout mounts the extra filesystems nested as they are on the
+// booted system and moves the deployment's /var content onto them.
+func populateLayout(
+	ctx context.Context,
+	mounts *diskMounts,
+	deployment installedDeployment,
+	parts []layoutPartition,
+	partitions []installedPartition,
+) error {
+	scratch := filepath.Join(mounts.dir, "layout")
+
+	var copyRoots []string
+
+	for idx, part := range parts {
+		if !part.IsExtra() || part.Filesystem == swapMountPoint {
+			continue
+		}
+
+		err := mkdirAllLabeled(deploymentVarPath(deployment, part.MountPoint))
+		if err != nil {
+			return err
+		}
+
+		err = mounts.MountAt(ctx, partitions[idx], filepath.Join(scratch, part.MountPoint))
+		if err != nil {
+			return err
+		}
+
+		// Nested mounts are filled by the copy of their parent.
+		if !slices.ContainsFunc(copyRoots, func(root string) bool { return strings.HasPrefix(part.MountPoint, root+"/") }) {
+			copyRoots = append(copyRoots, part.MountPoint)
+		}
+	}
+
+	for _, mountPoint := range copyRoots {
+		err := moveDirContents(ctx, deploymentVarPath(deployment, mountPoint), filepath.Join(scratch, mountPoint))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// moveDirContents copies the content of src into dst, with reflinks where
+// both share a filesystem, and then empties src, so the root filesystem
+// does not keep a hidden copy under the mount point. src itself stays as
+// the mount point.
+func moveDirContents(ctx context.Context, src, dst string) error {
+	if _, err := runCommand(ctx, "cp", "-a", "--reflink=auto", "--", src+"/.", dst); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(src, entry.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deploymentVarPath maps /var or a path below it to the stateroot's /var.
+func deploymentVarPath(deployment installedDeployment, mountPoint string) string {
+	return filepath.Join(deployment.Var, strings.TrimPrefix(mountPoint, "/var"))
+}
+
+// fstabEntry returns the /etc/fstab line mounting an extra partition.
+func fstabEntry(part layoutPartition, uuid string) string {
+	if part.Filesystem == swapMountPoint {
+		return fmt.Sprintf("UUID=%s none swap defaults 0 0\n", uuid)
+	}
+
+	return fmt.Sprintf("UUID=%s %s %s defaults 0 2\n", uuid, part.MountPoint, part.Filesystem)
+}
+
+// appendFstab adds the extra partitions to the deployment's /etc/fstab.
+func appendFstab(
+	deployment installedDeployment,
+	parts []layoutPartition,
+	partitions []installedPartition,
+	mtime *time.Time,
+) error {
+	var entries strings.Builder
+
+	for idx, part := range parts {
+		if part.IsExtra() {
+			entries.WriteString(fstabEntry(part, partitions[idx].UUID))
+		}
+	}
+
+	if entries.Len() == 0 {
+		return nil
+	}
+
+	return appendToFile(deployment.DeployPath("/etc/fstab"), entries.String(), mtime)
+}
+
+// validatePartitionLayout checks the values of a partition_layout block.
+func validatePartitionLayout(model *PartitionLayoutModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if model == nil {
+		return diags
+	}
+
+	blockPath := path.Root("partition_layout")
+
+	for _, size := range []struct {
+		value types.String
+		name  string
+	}{
+		{model.ESPSize, "esp_size"},
+		{model.BootSize, "boot_size"},
+	} {
+		if knownString(size.value) {
+			if _, err := parseSize(size.value.ValueString()); err != nil {
+				diags.AddAttributeError(blockPath.AtName(size.name), "Invalid size", err.Error())
+			}
+		}
+	}
+
+	seen := map[string]bool{}
+
+	for idx, entry := range model.Partitions {
+		entryPath := blockPath.AtName("partition").AtListIndex(idx)
+
+		if knownString(entry.MountPoint) {
+			mountPoint := entry.MountPoint.ValueString()
+			clean := filepath.Clean(mountPoint)
+
+			switch {
+			case mountPoint != swapMountPoint && clean != "/var" && !strings.HasPrefix(clean, "/var/"):
+				diags.AddAttributeError(entryPath.AtName("mount_point"), "Invalid mount point",
+					"Expected /var, a path below /var, or swap, got: "+mountPoint)
+			case seen[clean] && clean != swapMountPoint:
+				diags.AddAttributeError(entryPath.AtName("mount_point"), "Duplicate mount point",
+					"Mount point is already used by another partition: "+mountPoint)
+			case mountPoint == swapMountPoint && !entry.Filesystem.IsNull():
+				diags.AddAttributeError(entryPath.AtName("filesystem"), "Invalid filesystem",
+					"filesystem cannot be set on a swap partition.")
+			}
+
+			seen[clean] = true
+		}
+
+		if knownString(entry.Size) {
+			if _, err := parseSize(entry.Size.ValueString()); err != nil {
+				diags.AddAttributeError(entryPath.AtName("size"), "Invalid size", err.Error())
+			}
+		}
+
+		if knownString(entry.Label) && len(entry.Label.ValueString()) > maxFSLabelLen {
+			diags.AddAttributeError(entryPath.AtName("label"), "Invalid label",
+				fmt.Sprintf("Filesystem labels are limited to %d characters, got: %s", maxFSLabelLen, entry.Label.ValueString()))
+		}
+	}
+
+	return diags
+}
@@ -0,0 +1,352 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// testComplianceLayout is the /var, /var/log, /var/log/audit layout of the
+// compliance baseline plus swap, listed out of order.
+func testComplianceLayout() *PartitionLayoutModel {
+	entry := func(mountPoint, size string) LayoutPartitionModel {
+		return LayoutPartitionModel{
+			MountPoint:  types.StringValue(mountPoint),
+			Size:        types.StringValue(size),
+			Filesystem:  types.StringNull(),
+			Label:       types.StringNull(),
+			MkfsOptions: types.ListNull(types.StringType),
+		}
+	}
+
+	audit := entry("/var/log/audit", "2G")
+	audit.Filesystem = types.StringValue("ext4")
+	audit.Label = types.StringValue("audit")
+	audit.MkfsOptions = types.ListValueMust(types.StringType, []attr.Value{types.StringValue("-m0")})
+
+	return &PartitionLayoutModel{
+		Partitions: []LayoutPartitionModel{
+			audit,
+			entry("/var/log", "4G"),
+			entry("swap", "1G"),
+			entry("/var", "10G"),
+		},
+		RootMkfsOptions: types.ListNull(types.StringType),
+		ESPSize:         types.StringNull(),
+		BootSize:        types.StringValue("768M"),
+		BootFilesystem:  types.StringNull(),
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		size    string
+		want    int64
+		wantErr bool
+	}{
+		{"512", 512, false},
+		{"4K", 4 << 10, false},
+		{"512M", 512 << 20, false},
+		{"10G", 10 << 30, false},
+		{"1T", 1 << 40, false},
+		{"1.5G", 0, true},
+		{"10GB", 0, true},
+		{"", 0, true},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.size, func(t *testing.T) {
+			got, err := parseSize(testCase.size)
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("parseSize error = %v, wantErr %v", err, testCase.wantErr)
+			}
+
+			if err != nil && !errors.Is(err, ErrInvalidSize) {
+				t.Errorf("error = %v, want ErrInvalidSize", err)
+			}
+
+			if got != testCase.want {
+				t.Errorf("parseSize = %d, want %d", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestPartitionLayout(t *testing.T) {
+	gpt := diskLabel{Table: partitionTableGPT, SectorSize: defaultSectorSize, BIOSBoot: true}
+
+	parts, diags := partitionLayout(t.Context(), testComplianceLayout(), "xfs", "", "x86_64", gpt)
+	if diags.HasError() {
+		t.Fatalf("diagnostics: %v", diags)
+	}
+
+	var mountPoints []string
+	for _, part := range parts {
+		mountPoints = append(mountPoints, part.MountPoint)
+	}
+
+	want := []string{"", "/boot/efi", "/boot", "/var", "/var/log", "/var/log/audit", "swap", "/"}
+	if !slices.Equal(mountPoints, want) {
+		t.Fatalf("mount points = %q, want %q", mountPoints, want)
+	}
+
+	if parts[2].Size != "768M" || parts[1].Size != defaultESPSize || parts[2].Filesystem != "ext4" {
+		t.Errorf("boot partitions = %+v", parts[1:3])
+	}
+
+	if parts[4].Name != "var-log" || parts[4].Filesystem != "xfs" {
+		t.Errorf("/var/log = %+v", parts[4])
+	}
+
+	if parts[5].Filesystem != "ext4" || parts[5].FSLabel != "audit" || !slices.Equal(parts[5].MkfsOptions, []string{"-m0"}) {
+		t.Errorf("/var/log/audit = %+v", parts[5])
+	}
+
+	if parts[6].TypeGUID != swapPartitionType || parts[6].Filesystem != swapMountPoint {
+		t.Errorf("swap = %+v", parts[6])
+	}
+
+	root := parts[7]
+	if root.TypeGUID != rootPartitionTypes[0] || root.Size != "" || root.FSLabel != "root" {
+		t.Errorf("root = %+v", root)
+	}
+
+	parts, _ = partitionLayout(t.Context(), testComplianceLayout(), "btrfs", "20G", "aarch64",
+		diskLabel{Table: partitionTableGPT, SectorSize: defaultSectorSize})
+	if parts[0].Name != "EFI-SYSTEM" || parts[len(parts)-1].TypeGUID != rootPartitionTypes[1] {
+		t.Errorf("aarch64 layout = %+v", parts)
+	}
+
+	if parts[len(parts)-1].Size != "20G" || parts[3].Filesystem != "btrfs" {
+		t.Errorf("root size and default filesystem not applied: %+v", parts)
+	}
+
+	parts, _ = partitionLayout(t.Context(), &PartitionLayoutModel{}, "xfs", "", "x86_64",
+		diskLabel{Table: partitionTableMBR, SectorSize: defaultSectorSize, BIOSBoot: true})
+	if len(parts) != 3 || parts[0].Name != "EFI-SYSTEM" || !parts[1].Bootable || parts[2].Bootable {
+		t.Errorf("mbr layout = %+v", parts)
+	}
+}
+
+func TestSfdiskScript(t *testing.T) {
+	script, err := sfdiskScript([]layoutPartition{
+		{Name: "EFI-SYSTEM", TypeGUID: espPartitionType, Size: "512M"},
+		{Name: "var-log", TypeGUID: linuxPartitionType, Size: "1001"},
+		{Name: "root", TypeGUID: rootPartitionTypes[0]},
+	}, diskLabel{Table: partitionTableGPT, SectorSize: defaultSectorSize})
+	if err != nil {
+		t.Fatalf("sfdiskScript: %v", err)
+	}
+
+	want := "label: gpt\n" +
+		"size=1048576, type=" + espPartitionType + `, name="EFI-SYSTEM"` + "\n" +
+		"size=2, type=" + linuxPartitionType + `, name="var-log"` + "\n" +
+		"type=" + rootPartitionTypes[0] + `, name="root"` + "\n"
+	if script != want {
+		t.Errorf("script = %q, want %q", script, want)
+	}
+
+	script, err = sfdiskScript([]layoutPartition{
+		{Name: "EFI-SYSTEM", TypeGUID: espPartitionType, Size: "512M"},
+		{Name: "boot", TypeGUID: linuxPartitionType, Size: "1001", Bootable: true},
+		{Name: "swap", TypeGUID: swapPartitionType, Size: "1G"},
+		{Name: "root", TypeGUID: rootPartitionTypes[0]},
+	}, diskLabel{Table: partitionTableMBR, SectorSize: nativeSectorSize})
+	if err != nil {
+		t.Fatalf("sfdiskScript: %v", err)
+	}
+
+	want = "label: dos\nsector-size: 4096\n" +
+		"size=131072, type=ef\n" +
+		"size=1, type=83, bootable\n" +
+		"size=262144, type=82\n" +
+		"type=83\n"
+	if script != want {
+		t.Errorf("mbr script = %q, want %q", script, want)
+	}
+
+	_, err = sfdiskScript([]layoutPartition{{Name: "var", Size: "10GB"}}, diskLabel{SectorSize: defaultSectorSize})
+	if !errors.Is(err, ErrInvalidSize) {
+		t.Errorf("error = %v, want ErrInvalidSize", err)
+	}
+}
+
+func TestMkfsCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		part     layoutPartition
+		wantName string
+		wantArgs []string
+	}{
+		{
+			"esp", layoutPartition{Filesystem: "vfat", FSLabel: "EFI-SYSTEM"},
+			"mkfs.vfat", []string{"-F", "32", "-n", "EFI-SYSTEM", "/dev/loop1"},
+		},
+		{
+			"ext4", layoutPartition{Filesystem: "ext4", FSLabel: "audit", MkfsOptions: []string{"-m0"}},
+			"mkfs.ext4", []string{"-q", "-F", "-L", "audit", "-m0", "/dev/loop1"},
+		},
+		{
+			"xfs", layoutPartition{Filesystem: "xfs"},
+			"mkfs.xfs", []string{"-q", "-f", "/dev/loop1"},
+		},
+		{
+			"swap", layoutPartition{Filesystem: swapMountPoint, FSLabel: "swap"},
+			"mkswap", []string{"-L", "swap", "/dev/loop1"},
+		},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			name, args := mkfsCommand(testCase.part, "/dev/loop1")
+			if name != testCase.wantName || !slices.Equal(args, testCase.wantArgs) {
+				t.Errorf("mkfsCommand = %s %q, want %s %q", name, args, testCase.wantName, testCase.wantArgs)
+			}
+		})
+	}
+}
+
+func TestAppendFstab(t *testing.T) {
+	dir := t.TempDir()
+	deployment := installedDeployment{Dir: dir, Var: filepath.Join(dir, "var")}
+	fstab := filepath.Join(dir, "etc", "fstab")
+
+	err := os.MkdirAll(filepath.Dir(fstab), testDefaultDirPerms)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(fstab, []byte("UUID=0f1c /boot ext4 defaults 1 2"), testSecureFilePerms)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := []layoutPartition{
+		{MountPoint: "/boot", Filesystem: "ext4"},
+		{MountPoint: "/var/log", Filesystem: "xfs"},
+		{MountPoint: swapMountPoint, Filesystem: swapMountPoint},
+		{MountPoint: "/", Filesystem: "xfs"},
+	}
+	partitions := []installedPartition{{UUID: "0f1c"}, {UUID: "4a2e"}, {UUID: "77b0"}, {UUID: "9c3d"}}
+
+	err = appendFstab(deployment, parts, partitions, nil)
+	if err != nil {
+		t.Fatalf("appendFstab: %v", err)
+	}
+
+	content, err := os.ReadFile(fstab)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "UUID=0f1c /boot ext4 defaults 1 2\n" +
+		"UUID=4a2e /var/log xfs defaults 0 2\n" +
+		"UUID=77b0 none swap defaults 0 0\n"
+	if string(content) != want {
+		t.Errorf("fstab = %q, want %q", content, want)
+	}
+}
+
+func TestMoveDirContents(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "var", "log")
+	dst := t.TempDir()
+	file := filepath.Join(src, "audit", "audit.log")
+
+	err := os.MkdirAll(filepath.Dir(file), testDefaultDirPerms)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(file, []byte("type=DAEMON_START"), testSecureFilePerms)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = moveDirContents(t.Context(), src, dst)
+	if err != nil {
+		t.Fatalf("moveDirContents: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dst, "audit", "audit.log"))
+	if err != nil || string(content) != "type=DAEMON_START" {
+		t.Errorf("moved file = %q, %v", content, err)
+	}
+
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		t.Fatalf("source mount point was removed: %v", err)
+	}
+
+	if len(entries) != 0 {
+		t.Errorf("source still holds %d entries, want it empty", len(entries))
+	}
+}
+
+func TestValidatePartitionLayout(t *testing.T) {
+	if diags := validatePartitionLayout(testComplianceLayout()); diags.HasError() {
+		t.Fatalf("compliance layout: %v", diags)
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(*PartitionLayoutModel)
+		wantMsg string
+	}{
+		{"outside_var", func(m *PartitionLayoutModel) {
+			m.Partitions[0].MountPoint = types.StringValue("/home")
+		}, "Invalid mount point"},
+		{"duplicate", func(m *PartitionLayoutModel) {
+			m.Partitions[1].MountPoint = types.StringValue("/var/log/")
+			m.Partitions[0].MountPoint = types.StringValue("/var/log")
+		}, "Duplicate mount point"},
+		{"swap_filesystem", func(m *PartitionLayoutModel) {
+			m.Partitions[2].Filesystem = types.StringValue("xfs")
+		}, "Invalid filesystem"},
+		{"size", func(m *PartitionLayoutModel) {
+			m.Partitions[3].Size = types.StringValue("ten gigs")
+		}, "Invalid size"},
+		{"esp_size", func(m *PartitionLayoutModel) {
+			m.ESPSize = types.StringValue("-1")
+		}, "Invalid size"},
+		{"label", func(m *PartitionLayoutModel) {
+			m.Partitions[0].Label = types.StringValue("compliance-audit")
+		}, "Invalid label"},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			model := testComplianceLayout()
+			testCase.modify(model)
+
+			diags := validatePartitionLayout(model)
+			if !diags.HasError() || !strings.Contains(diags.Errors()[0].Summary(), testCase.wantMsg) {
+				t.Errorf("diagnostics = %v, want %q", diags, testCase.wantMsg)
+			}
+		})
+	}
+}
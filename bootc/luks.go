@@ -0,0 +1,285 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+const (
+	blockSetupDirect         = "direct"
+	blockSetupTPM2LUKS       = "tpm2-luks"
+	blockSetupLUKSPassphrase = "luks-passphrase"
+
+	// luksFilesystem is the blkid type of a LUKS container.
+	luksFilesystem = "crypto_LUKS"
+
+	// tpm2LUKSOptions are the crypttab options matching the kargs bootc
+	// adds for tpm2-luks.
+	tpm2LUKSOptions = "tpm2-device=auto,headless=true"
+
+	luksBuildKeyPath = "/etc/luks/bootc-build.key"
+	luksRekeyUnit    = "bootc-luks-rekey.service"
+)
+
+var ErrRootNotEncrypted = errors.New("root partition is not a LUKS container")
+
+// luksPassphraseKey is the context key of the passphrase that unlocks an
+// encrypted root during the build.
+type luksPassphraseKey struct{}
+
+// withLUKSPassphrase returns a context whose disk mounts unlock LUKS
+// containers with passphrase rather than the build host's TPM2.
+func withLUKSPassphrase(ctx context.Context, passphrase []byte) context.Context {
+	return context.WithValue(ctx, luksPassphraseKey{}, passphrase)
+}
+
+func luksPassphrase(ctx context.Context) []byte {
+	passphrase, _ := ctx.Value(luksPassphraseKey{}).([]byte)
+
+	return passphrase
+}
+
+// luksSetup describes how the encrypted root of an image is unlocked on
+// boot.
+type luksSetup struct {
+	Passphrase []byte
+	BlockSetup string
+	Rekey      bool
+}
+
+// mapperName is the device-mapper name systemd gives a LUKS volume
+// unlocked from crypttab or rd.luks.uuid.
+func mapperName(luksUUID string) string {
+	return "luks-" + luksUUID
+}
+
+// Kargs returns the kernel arguments that unlock the root container in
+// the initramfs. bootc adds its own for tpm2-luks.
+func (s luksSetup) Kargs(luksUUID string) []string {
+	if s.BlockSetup != blockSetupLUKSPassphrase {
+		return nil
+	}
+
+	kargs := []string{"rd.luks.uuid=" + luksUUID}
+	if s.Rekey {
+		kargs = append(kargs, "rd.luks.options="+luksUUID+"=tpm2-device=auto")
+	}
+
+	return kargs
+}
+
+// CrypttabEntry returns the /etc/crypttab line of the root container.
+func (s luksSetup) CrypttabEntry(luksUUID string) string {
+	options := "luks"
+
+	switch {
+	case s.BlockSetup == blockSetupTPM2LUKS:
+		options += "," + tpm2LUKSOptions
+	case s.Rekey:
+		options += ",tpm2-device=auto"
+	}
+
+	return fmt.Sprintf("%s UUID=%s none %s\n", mapperName(luksUUID), luksUUID, options)
+}
+
+// RekeyUnit returns the first-boot unit that binds the root container to
+// the device's TPM2 with the build passphrase and then wipes every
+// passphrase slot, so the passphrase shared by all images stops working.
+func (s luksSetup) RekeyUnit(luksUUID string) systemdUnit {
+	return systemdUnit{
+		Name:    luksRekeyUnit,
+		Enabled: true,
+		Content: fmt.Sprintf(`[Unit]
+Description=Bind the root LUKS volume to the TPM2 and remove the build passphrase
+ConditionPathExists=%[1]s
+ConditionSecurity=tpm2
+After=local-fs.target
+
+[Service]
+Type=oneshot
+ExecStart=/usr/bin/systemd-cryptenroll --unlock-key-file=%[1]s --tpm2-device=auto --wipe-slot=password /dev/disk/by-uuid/%[2]s
+ExecStartPost=/usr/bin/rm -f %[1]s
+
+[Install]
+WantedBy=multi-user.target
+`, luksBuildKeyPath, luksUUID),
+	}
+}
+
+// encryptedRoot returns the LUKS container holding the root filesystem.
+func encryptedRoot(partitions []installedPartition) (installedPartition, error) {
+	for _, part := range partitions {
+		if part.IsRoot() {
+			if part.Filesystem != luksFilesystem {
+				return installedPartition{}, fmt.Errorf("%w: partition %d is %q", ErrRootNotEncrypted, part.Number, part.Filesystem)
+			}
+
+			return part, nil
+		}
+	}
+
+	return installedPartition{}, fmt.Errorf("%w: no root partition", ErrDeploymentNotFound)
+}
+
+// encryptedRootKargs returns the kernel arguments of the LUKS root of a
+// freshly partitioned disk.
+func encryptedRootKargs(ctx context.Context, rawPath string, setup luksSetup) ([]string, error) {
+	partitions, err := readInstalledPartitions(ctx, rawPath)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := encryptedRoot(partitions)
+	if err != nil {
+		return nil, err
+	}
+
+	return setup.Kargs(root.UUID), nil
+}
+
+// formatLUKS creates a LUKS2 container on dev with the context's
+// passphrase and returns the unlocked device.
+func formatLUKS(ctx context.Context, mounts *diskMounts, dev string) (string, error) {
+	_, err := runCommandInput(ctx, nil, luksPassphrase(ctx), "cryptsetup", "luksFormat",
+		"--batch-mode", "--type", "luks2", "--key-file", "-", dev)
+	if err != nil {
+		return "", err
+	}
+
+	return mounts.Unlock(ctx, dev)
+}
+
+// configureLUKS adds the encrypted root to the deployment's /etc/crypttab
+// and, with rekey, installs the first-boot unit with the build passphrase
+// as its key file.
+func configureLUKS(ctx context.Context, rawPath string, setup luksSetup, mtime *time.Time) error {
+	partitions, err := readInstalledPartitions(ctx, rawPath)
+	if err != nil {
+		return err
+	}
+
+	root, err := encryptedRoot(partitions)
+	if err != nil {
+		return err
+	}
+
+	return customizeDeployment(ctx, rawPath, func(deployment installedDeployment) error {
+		crypttab := deployment.DeployPath("/etc/crypttab")
+
+		content, err := os.ReadFile(crypttab)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		var lines []string
+
+		// Replace an entry for the same volume, as bootc may write one.
+		for line := range strings.SplitSeq(string(content), "\n") {
+			fields := strings.Fields(line)
+			if line == "" || (len(fields) > 0 && fields[0] == mapperName(root.UUID)) {
+				continue
+			}
+
+			lines = append(lines, line)
+		}
+
+		lines = append(lines, strings.TrimSuffix(setup.CrypttabEntry(root.UUID), "\n"))
+
+		err = deployment.WriteFile(injectedFile{
+			Path:    "/etc/crypttab",
+			Content: []byte(strings.Join(lines, "\n") + "\n"),
+			Mode:    defaultFileMode,
+		}, mtime)
+		if err != nil || !setup.Rekey {
+			return err
+		}
+
+		err = deployment.WriteFile(injectedFile{Path: luksBuildKeyPath, Content: setup.Passphrase, Mode: 0o400}, mtime)
+		if err != nil {
+			return err
+		}
+
+		return deployment.InstallUnit(setup.RekeyUnit(root.UUID), mtime)
+	})
+}
+
+// validateBlockSetup checks block_setup against the options it depends on
+// or conflicts with.
+func validateBlockSetup(data *ImageResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	blockSetup := data.BlockSetup.ValueString()
+	encrypted := blockSetup == blockSetupTPM2LUKS || blockSetup == blockSetupLUKSPassphrase
+
+	if blockSetup != "" && data.InstallConfig != nil && len(data.InstallConfig.Block.Elements()) > 0 {
+		diags.AddAttributeError(path.Root("block_setup"), "Conflicting block options",
+			"Set either block_setup or install_config.block, not both.")
+	}
+
+	if encrypted && data.Reproducible != nil {
+		diags.AddAttributeError(path.Root("block_setup"), "Conflicting block options",
+			"Encrypted root filesystems cannot be reproducible: LUKS uses a random volume key and salts.")
+	}
+
+	if blockSetup == blockSetupTPM2LUKS && data.partitionsDisk() {
+		diags.AddAttributeError(path.Root("block_setup"), "Conflicting block options",
+			"tpm2-luks is set up by bootc install to-disk and cannot be used with partition_layout, filesystem_options, "+
+				"partition_table, bios_boot or sector_size.")
+	}
+
+	if blockSetup == blockSetupTPM2LUKS && (len(data.packagings()) > 0 ||
+		(knownString(data.Platform) && data.Platform.ValueString() != platformMetal)) {
+		diags.AddAttributeError(path.Root("block_setup"), "Conflicting block options",
+			"tpm2-luks seals the volume key to the TPM2 of the build host, so images packaged or built for a platform "+
+				"other than metal cannot unlock their root. Use luks-passphrase with luks_rekey_on_first_boot to bind "+
+				"the target device's TPM2 instead.")
+	}
+
+	if data.BlockSetup.IsUnknown() || data.LUKSPassphrase.IsUnknown() {
+		return diags
+	}
+
+	hasPassphrase := !data.LUKSPassphrase.IsNull()
+
+	switch {
+	case blockSetup == blockSetupLUKSPassphrase && !hasPassphrase:
+		diags.AddAttributeError(path.Root("luks_passphrase_wo"), "Missing LUKS passphrase",
+			"luks_passphrase_wo must be set when block_setup is luks-passphrase.")
+	case blockSetup == blockSetupLUKSPassphrase && data.LUKSPassphrase.ValueString() == "":
+		diags.AddAttributeError(path.Root("luks_passphrase_wo"), "Missing LUKS passphrase",
+			"luks_passphrase_wo must not be empty.")
+	case blockSetup != blockSetupLUKSPassphrase && hasPassphrase:
+		diags.AddAttributeError(path.Root("luks_passphrase_wo"), "Unused LUKS passphrase",
+			"luks_passphrase_wo is only used when block_setup is luks-passphrase.")
+	}
+
+	if data.LUKSRekeyOnFirstBoot.ValueBool() && blockSetup != blockSetupLUKSPassphrase {
+		diags.AddAttributeError(path.Root("luks_rekey_on_first_boot"), "Invalid LUKS option",
+			"luks_rekey_on_first_boot requires block_setup = \"luks-passphrase\".")
+	}
+
+	return diags
+}
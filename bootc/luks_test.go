@@ -0,0 +1,216 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const testLUKSUUID = "5c1a0e2f-7b9d-4e61-9f3a-2d8c4b6e1a07"
+
+func TestLUKSSetup_Kargs(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup luksSetup
+		want  []string
+	}{
+		{"tpm2_luks", luksSetup{BlockSetup: blockSetupTPM2LUKS}, nil},
+		{"passphrase", luksSetup{BlockSetup: blockSetupLUKSPassphrase}, []string{"rd.luks.uuid=" + testLUKSUUID}},
+		{"rekey", luksSetup{BlockSetup: blockSetupLUKSPassphrase, Rekey: true}, []string{
+			"rd.luks.uuid=" + testLUKSUUID, "rd.luks.options=" + testLUKSUUID + "=tpm2-device=auto",
+		}},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := testCase.setup.Kargs(testLUKSUUID); !slices.Equal(got, testCase.want) {
+				t.Errorf("Kargs = %q, want %q", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestLUKSSetup_CrypttabEntry(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup luksSetup
+		want  string
+	}{
+		{"tpm2_luks", luksSetup{BlockSetup: blockSetupTPM2LUKS}, "luks,tpm2-device=auto,headless=true"},
+		{"passphrase", luksSetup{BlockSetup: blockSetupLUKSPassphrase}, "luks"},
+		{"rekey", luksSetup{BlockSetup: blockSetupLUKSPassphrase, Rekey: true}, "luks,tpm2-device=auto"},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			want := "luks-" + testLUKSUUID + " UUID=" + testLUKSUUID + " none " + testCase.want + "\n"
+			if got := testCase.setup.CrypttabEntry(testLUKSUUID); got != want {
+				t.Errorf("CrypttabEntry = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestLUKSSetup_RekeyUnit(t *testing.T) {
+	unit := luksSetup{BlockSetup: blockSetupLUKSPassphrase, Rekey: true}.RekeyUnit(testLUKSUUID)
+
+	if unit.Name != luksRekeyUnit || !unit.Enabled {
+		t.Errorf("unit = %+v", unit)
+	}
+
+	for _, want := range []string{
+		"ConditionPathExists=" + luksBuildKeyPath,
+		"--unlock-key-file=" + luksBuildKeyPath,
+		"--wipe-slot=password /dev/disk/by-uuid/" + testLUKSUUID,
+		"ExecStartPost=/usr/bin/rm -f " + luksBuildKeyPath,
+	} {
+		if !strings.Contains(unit.Content, want) {
+			t.Errorf("unit content missing %q", want)
+		}
+	}
+
+	if got := parseUnitInstall(unit.Content).WantedBy; !slices.Equal(got, []string{"multi-user.target"}) {
+		t.Errorf("WantedBy = %q", got)
+	}
+}
+
+func TestEncryptedRoot(t *testing.T) {
+	esp := installedPartition{Number: 1, Label: "EFI-SYSTEM", Filesystem: "vfat"}
+	root := installedPartition{Number: 2, TypeGUID: rootPartitionTypes[0], Filesystem: luksFilesystem, UUID: testLUKSUUID}
+
+	got, err := encryptedRoot([]installedPartition{esp, root})
+	if err != nil || got.UUID != testLUKSUUID {
+		t.Errorf("encryptedRoot = %+v, %v", got, err)
+	}
+
+	root.Filesystem = testFilesystem
+
+	_, err = encryptedRoot([]installedPartition{esp, root})
+	if !errors.Is(err, ErrRootNotEncrypted) {
+		t.Errorf("error = %v, want ErrRootNotEncrypted", err)
+	}
+
+	_, err = encryptedRoot([]installedPartition{esp})
+	if !errors.Is(err, ErrDeploymentNotFound) {
+		t.Errorf("error = %v, want ErrDeploymentNotFound", err)
+	}
+}
+
+func TestLUKSPassphraseContext(t *testing.T) {
+	if luksPassphrase(t.Context()) != nil {
+		t.Error("expected no passphrase in a plain context")
+	}
+
+	ctx := withLUKSPassphrase(t.Context(), []byte("correct horse"))
+	if string(luksPassphrase(ctx)) != "correct horse" {
+		t.Errorf("passphrase = %q", luksPassphrase(ctx))
+	}
+}
+
+func TestValidateBlockSetup(t *testing.T) {
+	passphrase := types.StringValue("correct horse")
+
+	tests := []struct {
+		name    string
+		data    ImageResourceModel
+		wantErr string
+	}{
+		{"none", ImageResourceModel{}, ""},
+		{"tpm2_luks", ImageResourceModel{BlockSetup: types.StringValue(blockSetupTPM2LUKS)}, ""},
+		{"passphrase", ImageResourceModel{
+			BlockSetup:     types.StringValue(blockSetupLUKSPassphrase),
+			LUKSPassphrase: passphrase,
+		}, ""},
+		{"passphrase_with_layout", ImageResourceModel{
+			BlockSetup:      types.StringValue(blockSetupLUKSPassphrase),
+			LUKSPassphrase:  passphrase,
+			PartitionLayout: &PartitionLayoutModel{},
+		}, ""},
+		{"missing_passphrase", ImageResourceModel{
+			BlockSetup: types.StringValue(blockSetupLUKSPassphrase),
+		}, "Missing LUKS passphrase"},
+		{"empty_passphrase", ImageResourceModel{
+			BlockSetup:     types.StringValue(blockSetupLUKSPassphrase),
+			LUKSPassphrase: types.StringValue(""),
+		}, "Missing LUKS passphrase"},
+		{"unused_passphrase", ImageResourceModel{
+			BlockSetup:     types.StringValue(blockSetupTPM2LUKS),
+			LUKSPassphrase: passphrase,
+		}, "Unused LUKS passphrase"},
+		{"rekey_without_passphrase_mode", ImageResourceModel{
+			BlockSetup:           types.StringValue(blockSetupTPM2LUKS),
+			LUKSRekeyOnFirstBoot: types.BoolValue(true),
+		}, "Invalid LUKS option"},
+		{"tpm2_luks_metal", ImageResourceModel{
+			BlockSetup: types.StringValue(blockSetupTPM2LUKS),
+			Platform:   types.StringValue(platformMetal),
+		}, ""},
+		{"tpm2_luks_with_platform", ImageResourceModel{
+			BlockSetup: types.StringValue(blockSetupTPM2LUKS),
+			Platform:   types.StringValue(platformAWS),
+		}, "Conflicting block options"},
+		{"tpm2_luks_with_packaging", ImageResourceModel{
+			BlockSetup: types.StringValue(blockSetupTPM2LUKS),
+			Packaging:  packagingList(packagingOVA),
+		}, "Conflicting block options"},
+		{"tpm2_luks_with_layout", ImageResourceModel{
+			BlockSetup:      types.StringValue(blockSetupTPM2LUKS),
+			PartitionLayout: &PartitionLayoutModel{},
+		}, "Conflicting block options"},
+		{"tpm2_luks_with_filesystem_options", ImageResourceModel{
+			BlockSetup:        types.StringValue(blockSetupTPM2LUKS),
+			FilesystemOptions: &FilesystemOptionsModel{},
+		}, "Conflicting block options"},
+		{"reproducible", ImageResourceModel{
+			BlockSetup:   types.StringValue(blockSetupTPM2LUKS),
+			Reproducible: &ReproducibleModel{Seed: types.StringValue("seed")},
+		}, "Conflicting block options"},
+		{"install_config_block", ImageResourceModel{
+			BlockSetup: types.StringValue(blockSetupDirect),
+			InstallConfig: &InstallConfigModel{
+				Block: types.ListValueMust(types.StringType, []attr.Value{types.StringValue(blockSetupDirect)}),
+			},
+		}, "Conflicting block options"},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			diags := validateBlockSetup(&testCase.data)
+
+			if testCase.wantErr == "" {
+				if diags.HasError() {
+					t.Errorf("unexpected diagnostics: %v", diags)
+				}
+
+				return
+			}
+
+			if !diags.HasError() || diags.Errors()[0].Summary() != testCase.wantErr {
+				t.Errorf("diagnostics = %v, want %q", diags, testCase.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,409 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net"
+	"net/netip"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	nmConnectionsDir = "/etc/NetworkManager/system-connections"
+	nmKeyfileMode    = 0o600
+
+	connTypeEthernet = "ethernet"
+	connTypeBond     = "bond"
+	connTypeVLAN     = "vlan"
+	connTypeBridge   = "bridge"
+)
+
+// NetworkConnectionModel is an entry of the network block of bootc_image.
+type NetworkConnectionModel struct {
+	IPv4Addresses types.List   `tfsdk:"ipv4_addresses"`
+	IPv4DNS       types.List   `tfsdk:"ipv4_dns"`
+	IPv6Addresses types.List   `tfsdk:"ipv6_addresses"`
+	IPv6DNS       types.List   `tfsdk:"ipv6_dns"`
+	DNSSearch     types.List   `tfsdk:"dns_search"`
+	BondOptions   types.Map    `tfsdk:"bond_options"`
+	Name          types.String `tfsdk:"name"`
+	Type          types.String `tfsdk:"type"`
+	InterfaceName types.String `tfsdk:"interface_name"`
+	MACAddress    types.String `tfsdk:"mac_address"`
+	Controller    types.String `tfsdk:"controller"`
+	BondMode      types.String `tfsdk:"bond_mode"`
+	VLANParent    types.String `tfsdk:"vlan_parent"`
+	IPv4Method    types.String `tfsdk:"ipv4_method"`
+	IPv4Gateway   types.String `tfsdk:"ipv4_gateway"`
+	IPv6Method    types.String `tfsdk:"ipv6_method"`
+	IPv6Gateway   types.String `tfsdk:"ipv6_gateway"`
+	MTU           types.Int64  `tfsdk:"mtu"`
+	VLANID        types.Int64  `tfsdk:"vlan_id"`
+}
+
+// networkConnection is a resolved network entry.
+type networkConnection struct {
+	BondOptions   map[string]string
+	Name          string
+	Type          string
+	InterfaceName string
+	MACAddress    string
+	Controller    string
+	BondMode      string
+	VLANParent    string
+	IPv4Method    string
+	IPv4Gateway   string
+	IPv6Method    string
+	IPv6Gateway   string
+	IPv4Addresses []string
+	IPv4DNS       []string
+	IPv6Addresses []string
+	IPv6DNS       []string
+	DNSSearch     []string
+	MTU           int64
+	VLANID        int64
+}
+
+// keyfileSection is a [group] of a NetworkManager keyfile with its keys in
+// output order.
+type keyfileSection struct {
+	Name string
+	Keys [][2]string
+}
+
+func (s *keyfileSection) Set(key, value string) {
+	if value != "" {
+		s.Keys = append(s.Keys, [2]string{key, value})
+	}
+}
+
+// networkConnections converts the network block.
+func networkConnections(ctx context.Context, models []NetworkConnectionModel) ([]networkConnection, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	conns := make([]networkConnection, 0, len(models))
+
+	for _, model := range models {
+		conn := networkConnection{
+			Name:          model.Name.ValueString(),
+			Type:          model.Type.ValueString(),
+			InterfaceName: model.InterfaceName.ValueString(),
+			MACAddress:    strings.ToUpper(model.MACAddress.ValueString()),
+			Controller:    model.Controller.ValueString(),
+			BondMode:      model.BondMode.ValueString(),
+			VLANParent:    model.VLANParent.ValueString(),
+			IPv4Method:    model.IPv4Method.ValueString(),
+			IPv4Gateway:   model.IPv4Gateway.ValueString(),
+			IPv6Method:    model.IPv6Method.ValueString(),
+			IPv6Gateway:   model.IPv6Gateway.ValueString(),
+			MTU:           model.MTU.ValueInt64(),
+			VLANID:        model.VLANID.ValueInt64(),
+		}
+
+		if conn.Type == "" {
+			conn.Type = connTypeEthernet
+		}
+
+		for _, list := range []struct {
+			target *[]string
+			value  types.List
+		}{
+			{&conn.IPv4Addresses, model.IPv4Addresses},
+			{&conn.IPv4DNS, model.IPv4DNS},
+			{&conn.IPv6Addresses, model.IPv6Addresses},
+			{&conn.IPv6DNS, model.IPv6DNS},
+			{&conn.DNSSearch, model.DNSSearch},
+		} {
+			if !list.value.IsNull() {
+				diags.Append(list.value.ElementsAs(ctx, list.target, false)...)
+			}
+		}
+
+		if !model.BondOptions.IsNull() {
+			diags.Append(model.BondOptions.ElementsAs(ctx, &conn.BondOptions, false)...)
+		}
+
+		conns = append(conns, conn)
+	}
+
+	return conns, diags
+}
+
+// networkKeyfiles renders every connection to a keyfile below
+// /etc/NetworkManager/system-connections.
+func networkKeyfiles(conns []networkConnection) ([]injectedFile, error) {
+	controllers := map[string]string{}
+	for _, conn := range conns {
+		if conn.InterfaceName != "" {
+			controllers[conn.InterfaceName] = conn.Type
+		}
+	}
+
+	files := make([]injectedFile, 0, len(conns))
+
+	for _, conn := range conns {
+		portType := ""
+
+		if conn.Controller != "" {
+			portType = controllers[conn.Controller]
+			if portType != connTypeBond && portType != connTypeBridge {
+				return nil, fmt.Errorf("%s: controller %q is not a bond or bridge interface of the network block", conn.Name, conn.Controller)
+			}
+		}
+
+		files = append(files, injectedFile{
+			Path:    filepath.Join(nmConnectionsDir, conn.Name+".nmconnection"),
+			Content: []byte(renderKeyfile(conn, portType)),
+			Mode:    nmKeyfileMode,
+		})
+	}
+
+	return files, nil
+}
+
+// renderKeyfile renders a connection in NetworkManager's keyfile format.
+// Ports of a bond or bridge carry no IP configuration.
+func renderKeyfile(conn networkConnection, portType string) string {
+	connection := keyfileSection{Name: "connection"}
+	connection.Set("id", conn.Name)
+	connection.Set("uuid", deriveUUID(conn.Name, "nm-connection"))
+	connection.Set("type", conn.Type)
+	connection.Set("interface-name", conn.InterfaceName)
+	connection.Set("autoconnect", "true")
+	// master/slave-type are understood by every NetworkManager release,
+	// unlike their controller/port-type aliases.
+	connection.Set("master", conn.Controller)
+	connection.Set("slave-type", portType)
+
+	sections := []keyfileSection{connection}
+
+	ethernet := keyfileSection{Name: connTypeEthernet}
+	ethernet.Set("mac-address", conn.MACAddress)
+
+	if conn.MTU > 0 {
+		ethernet.Set("mtu", strconv.FormatInt(conn.MTU, 10))
+	}
+
+	switch conn.Type {
+	case connTypeBond:
+		bond := keyfileSection{Name: connTypeBond}
+		bond.Set("mode", conn.BondMode)
+
+		for _, key := range slices.Sorted(maps.Keys(conn.BondOptions)) {
+			bond.Set(key, conn.BondOptions[key])
+		}
+
+		sections = append(sections, bond)
+	case connTypeVLAN:
+		vlan := keyfileSection{Name: connTypeVLAN}
+		vlan.Set("id", strconv.FormatInt(conn.VLANID, 10))
+		vlan.Set("parent", conn.VLANParent)
+		sections = append(sections, vlan)
+	case connTypeBridge:
+		sections = append(sections, keyfileSection{Name: connTypeBridge})
+	}
+
+	if len(ethernet.Keys) > 0 {
+		sections = append(sections, ethernet)
+	}
+
+	if conn.Controller == "" {
+		sections = append(sections,
+			ipKeyfileSection("ipv4", conn.IPv4Method, conn.IPv4Addresses, conn.IPv4Gateway, conn.IPv4DNS, conn.DNSSearch),
+			ipKeyfileSection("ipv6", conn.IPv6Method, conn.IPv6Addresses, conn.IPv6Gateway, conn.IPv6DNS, conn.DNSSearch),
+		)
+	}
+
+	var out strings.Builder
+
+	for idx, section := range sections {
+		if idx > 0 {
+			out.WriteString("\n")
+		}
+
+		fmt.Fprintf(&out, "[%s]\n", section.Name)
+
+		for _, kv := range section.Keys {
+			fmt.Fprintf(&out, "%s=%s\n", kv[0], kv[1])
+		}
+	}
+
+	return out.String()
+}
+
+// ipKeyfileSection renders an [ipv4] or [ipv6] group. The method defaults
+// to manual when addresses are given and auto otherwise.
+func ipKeyfileSection(name, method string, addresses []string, gateway string, dns, search []string) keyfileSection {
+	if method == "" {
+		method = "auto"
+		if len(addresses) > 0 {
+			method = "manual"
+		}
+	}
+
+	section := keyfileSection{Name: name}
+	section.Set("method", method)
+
+	for idx, address := range addresses {
+		section.Set("address"+strconv.Itoa(idx+1), address)
+	}
+
+	section.Set("gateway", gateway)
+
+	if len(dns) > 0 {
+		section.Set("dns", strings.Join(dns, ";")+";")
+	}
+
+	if len(search) > 0 && method != "disabled" && method != "ignore" {
+		section.Set("dns-search", strings.Join(search, ";")+";")
+	}
+
+	return section
+}
+
+// validateNetworkConnection checks a network entry for settings that do
+// not apply to its type and for malformed addresses.
+func validateNetworkConnection(entryPath path.Path, model NetworkConnectionModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if !model.Name.IsUnknown() && strings.ContainsAny(model.Name.ValueString(), "/\x00") {
+		diags.AddAttributeError(entryPath.AtName("name"), "Invalid connection name",
+			"Connection names are used as file names and must not contain slashes.")
+	}
+
+	connType := model.Type.ValueString()
+	if connType == "" {
+		connType = connTypeEthernet
+	}
+
+	if model.InterfaceName.IsNull() && model.MACAddress.IsNull() && connType != connTypeVLAN {
+		diags.AddAttributeError(entryPath, "Missing interface match",
+			"Set interface_name, mac_address, or both.")
+	}
+
+	requireFor := func(attrName string, isSet bool, want string) {
+		if isSet && connType != want {
+			diags.AddAttributeError(entryPath.AtName(attrName), "Setting does not apply",
+				fmt.Sprintf("%s is only valid for %s connections.", attrName, want))
+		}
+	}
+
+	requireFor("mac_address", !model.MACAddress.IsNull(), connTypeEthernet)
+	requireFor("bond_mode", !model.BondMode.IsNull(), connTypeBond)
+	requireFor("bond_options", !model.BondOptions.IsNull(), connTypeBond)
+	requireFor("vlan_id", !model.VLANID.IsNull(), connTypeVLAN)
+	requireFor("vlan_parent", !model.VLANParent.IsNull(), connTypeVLAN)
+
+	if connType == connTypeVLAN && (model.VLANID.IsNull() || model.VLANParent.IsNull()) {
+		diags.AddAttributeError(entryPath, "Incomplete VLAN", "vlan connections require vlan_id and vlan_parent.")
+	}
+
+	if !model.VLANID.IsNull() && !model.VLANID.IsUnknown() && (model.VLANID.ValueInt64() < 0 || model.VLANID.ValueInt64() > 4094) {
+		diags.AddAttributeError(entryPath.AtName("vlan_id"), "Invalid VLAN ID", "vlan_id must be between 0 and 4094.")
+	}
+
+	if !model.MACAddress.IsNull() && !model.MACAddress.IsUnknown() {
+		if _, err := net.ParseMAC(model.MACAddress.ValueString()); err != nil {
+			diags.AddAttributeError(entryPath.AtName("mac_address"), "Invalid MAC address", err.Error())
+		}
+	}
+
+	if !model.Controller.IsNull() {
+		for _, setting := range []struct {
+			name  string
+			isSet bool
+		}{
+			{"ipv4_addresses", !model.IPv4Addresses.IsNull()},
+			{"ipv6_addresses", !model.IPv6Addresses.IsNull()},
+			{"ipv4_gateway", !model.IPv4Gateway.IsNull()},
+			{"ipv6_gateway", !model.IPv6Gateway.IsNull()},
+		} {
+			if setting.isSet {
+				diags.AddAttributeError(entryPath.AtName(setting.name), "Setting does not apply",
+					"Ports of a bond or bridge carry no IP configuration.")
+			}
+		}
+	}
+
+	diags.Append(validateIPSettings(entryPath, "ipv4", model.IPv4Addresses, model.IPv4Gateway, model.IPv4DNS, false)...)
+	diags.Append(validateIPSettings(entryPath, "ipv6", model.IPv6Addresses, model.IPv6Gateway, model.IPv6DNS, true)...)
+
+	return diags
+}
+
+func validateIPSettings(
+	entryPath path.Path,
+	family string,
+	addresses types.List,
+	gateway types.String,
+	dns types.List,
+	wantIPv6 bool,
+) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	for _, value := range knownStrings(addresses) {
+		parsed, err := netip.ParsePrefix(value)
+		if err != nil || parsed.Addr().Is6() != wantIPv6 {
+			diags.AddAttributeError(entryPath.AtName(family+"_addresses"), "Invalid address",
+				fmt.Sprintf("Expected an %s address in CIDR notation, got: %s", family, value))
+		}
+	}
+
+	hosts := knownStrings(dns)
+	if !gateway.IsNull() && !gateway.IsUnknown() {
+		hosts = append(hosts, gateway.ValueString())
+	}
+
+	for _, value := range hosts {
+		parsed, err := netip.ParseAddr(value)
+		if err != nil || parsed.Is6() != wantIPv6 {
+			diags.AddAttributeError(entryPath, "Invalid "+family+" address",
+				fmt.Sprintf("Expected an %s address for the gateway or DNS servers, got: %s", family, value))
+		}
+	}
+
+	return diags
+}
+
+// knownStrings returns the known, non-null elements of a string list.
+func knownStrings(list types.List) []string {
+	var values []string
+
+	for _, elem := range list.Elements() {
+		str, ok := elem.(types.String)
+		if ok && !str.IsNull() && !str.IsUnknown() {
+			values = append(values, str.ValueString())
+		}
+	}
+
+	return values
+}
+
+// knownString reports whether a string value is known and not null.
+func knownString(value types.String) bool {
+	return !value.IsNull() && !value.IsUnknown()
+}
@@ -0,0 +1,189 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestRenderKeyfile_Static(t *testing.T) {
+	got := renderKeyfile(networkConnection{
+		Name:          "uplink",
+		Type:          connTypeEthernet,
+		InterfaceName: "eno1",
+		MACAddress:    "52:54:00:12:34:56",
+		MTU:           9000,
+		IPv4Addresses: []string{"192.0.2.10/24", "192.0.2.11/24"},
+		IPv4Gateway:   "192.0.2.1",
+		IPv4DNS:       []string{"192.0.2.53", "198.51.100.53"},
+		IPv6Method:    "disabled",
+		DNSSearch:     []string{"example.com"},
+	}, "")
+
+	want := `[connection]
+id=uplink
+uuid=` + deriveUUID("uplink", "nm-connection") + `
+type=ethernet
+interface-name=eno1
+autoconnect=true
+
+[ethernet]
+mac-address=52:54:00:12:34:56
+mtu=9000
+
+[ipv4]
+method=manual
+address1=192.0.2.10/24
+address2=192.0.2.11/24
+gateway=192.0.2.1
+dns=192.0.2.53;198.51.100.53;
+dns-search=example.com;
+
+[ipv6]
+method=disabled
+`
+
+	if got != want {
+		t.Errorf("renderKeyfile =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestNetworkKeyfiles_BondVLAN(t *testing.T) {
+	conns := []networkConnection{
+		{Name: "bond0", Type: connTypeBond, InterfaceName: "bond0", BondMode: "802.3ad", BondOptions: map[string]string{"miimon": "100", "lacp_rate": "fast"}},
+		{Name: "bond0-port-eno1", Type: connTypeEthernet, InterfaceName: "eno1", Controller: "bond0"},
+		{Name: "bond0.100", Type: connTypeVLAN, VLANID: 100, VLANParent: "bond0", IPv4Addresses: []string{"10.0.100.5/24"}},
+	}
+
+	files, err := networkKeyfiles(conns)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 3 {
+		t.Fatalf("files = %d, want 3", len(files))
+	}
+
+	if files[0].Path != "/etc/NetworkManager/system-connections/bond0.nmconnection" || files[0].Mode != nmKeyfileMode {
+		t.Errorf("files[0] = %s %o", files[0].Path, files[0].Mode)
+	}
+
+	for _, check := range []struct {
+		file int
+		want string
+	}{
+		{0, "[bond]\nmode=802.3ad\nlacp_rate=fast\nmiimon=100\n"},
+		{1, "master=bond0\nslave-type=bond\n"},
+		{2, "[vlan]\nid=100\nparent=bond0\n"},
+		{2, "method=manual\naddress1=10.0.100.5/24\n"},
+	} {
+		if !strings.Contains(string(files[check.file].Content), check.want) {
+			t.Errorf("files[%d] missing %q:\n%s", check.file, check.want, files[check.file].Content)
+		}
+	}
+
+	if strings.Contains(string(files[1].Content), "[ipv4]") {
+		t.Errorf("port should carry no IP configuration:\n%s", files[1].Content)
+	}
+
+	_, err = networkKeyfiles([]networkConnection{{Name: "orphan", Type: connTypeEthernet, InterfaceName: "eno2", Controller: "br9"}})
+	if err == nil {
+		t.Error("expected error for unknown controller")
+	}
+}
+
+func TestValidateNetworkConnection(t *testing.T) {
+	strs := func(values ...string) types.List {
+		elems := make([]attr.Value, 0, len(values))
+		for _, value := range values {
+			elems = append(elems, types.StringValue(value))
+		}
+
+		return types.ListValueMust(types.StringType, elems)
+	}
+
+	base := NetworkConnectionModel{
+		IPv4Addresses: types.ListNull(types.StringType),
+		IPv4DNS:       types.ListNull(types.StringType),
+		IPv6Addresses: types.ListNull(types.StringType),
+		IPv6DNS:       types.ListNull(types.StringType),
+		DNSSearch:     types.ListNull(types.StringType),
+		BondOptions:   types.MapNull(types.StringType),
+		Name:          types.StringValue("uplink"),
+		InterfaceName: types.StringValue("eno1"),
+	}
+
+	tests := []struct {
+		modify  func(*NetworkConnectionModel)
+		name    string
+		wantErr bool
+	}{
+		{func(*NetworkConnectionModel) {}, "minimal", false},
+		{func(c *NetworkConnectionModel) {
+			c.IPv4Addresses = strs("192.0.2.10/24")
+			c.IPv4Gateway = types.StringValue("192.0.2.1")
+			c.IPv6Addresses = strs("2001:db8::10/64")
+			c.IPv6DNS = strs("2001:db8::53")
+		}, "static_dual_stack", false},
+		{func(c *NetworkConnectionModel) { c.IPv4Addresses = strs("192.0.2.10") }, "address_without_prefix", true},
+		{func(c *NetworkConnectionModel) { c.IPv4Addresses = strs("2001:db8::10/64") }, "ipv6_in_ipv4", true},
+		{func(c *NetworkConnectionModel) { c.IPv4Gateway = types.StringValue("gateway") }, "bad_gateway", true},
+		{func(c *NetworkConnectionModel) { c.IPv4DNS = types.ListValueMust(types.StringType, []attr.Value{types.StringUnknown()}) }, "unknown_dns", false},
+		{func(c *NetworkConnectionModel) { c.InterfaceName = types.StringNull() }, "no_match", true},
+		{func(c *NetworkConnectionModel) {
+			c.InterfaceName = types.StringNull()
+			c.MACAddress = types.StringValue("52:54:00:12:34:56")
+		}, "mac_match", false},
+		{func(c *NetworkConnectionModel) { c.MACAddress = types.StringValue("52:54") }, "bad_mac", true},
+		{func(c *NetworkConnectionModel) { c.Name = types.StringValue("../uplink") }, "bad_name", true},
+		{func(c *NetworkConnectionModel) { c.BondMode = types.StringValue("802.3ad") }, "bond_mode_on_ethernet", true},
+		{func(c *NetworkConnectionModel) {
+			c.Type = types.StringValue(connTypeVLAN)
+			c.InterfaceName = types.StringNull()
+			c.VLANID = types.Int64Value(100)
+			c.VLANParent = types.StringValue("eno1")
+		}, "vlan", false},
+		{func(c *NetworkConnectionModel) { c.Type = types.StringValue(connTypeVLAN) }, "vlan_incomplete", true},
+		{func(c *NetworkConnectionModel) {
+			c.Type = types.StringValue(connTypeVLAN)
+			c.VLANID = types.Int64Value(5000)
+			c.VLANParent = types.StringValue("eno1")
+		}, "vlan_id_range", true},
+		{func(c *NetworkConnectionModel) {
+			c.Controller = types.StringValue("bond0")
+			c.IPv4Addresses = strs("192.0.2.10/24")
+		}, "port_with_address", true},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			conn := base
+			testCase.modify(&conn)
+
+			diags := validateNetworkConnection(path.Root("network").AtListIndex(0), conn)
+			if diags.HasError() != testCase.wantErr {
+				t.Errorf("HasError = %v, want %v: %v", diags.HasError(), testCase.wantErr, diags)
+			}
+		})
+	}
+}
@@ -0,0 +1,297 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	packagingGCE     = "gce"
+	packagingAzure   = "azure"
+	packagingAWSRaw  = "aws-raw"
+	packagingAWSVMDK = "aws-vmdk"
+
+	// gceDiskName is the only file name the GCE image importer accepts
+	// inside the tarball.
+	gceDiskName = "disk.raw"
+)
+
+// packagings lists the packaging values.
+var packagings = []string{
+	packagingGCE, packagingAzure, packagingAWSRaw, packagingAWSVMDK,
+	packagingOVA, packagingVagrantLibvirt, packagingVagrantVirtualBox, packagingISO,
+}
+
+// packagingAlignments are the disk size multiples the cloud importers
+// require: GCE images are a whole number of GiB and Azure VHDs a whole
+// number of MiB.
+var packagingAlignments = map[string]int64{
+	packagingGCE:   gibibyte,
+	packagingAzure: mebibyte,
+}
+
+// imageArtifactAttrTypes describes the elements of the artifacts attribute.
+var imageArtifactAttrTypes = map[string]attr.Type{
+	"path":   types.StringType,
+	"sha256": types.StringType,
+}
+
+// packagings returns the known packaging entries.
+func (m *ImageResourceModel) packagings() []string {
+	var values []string
+
+	for _, elem := range m.Packaging.Elements() {
+		str, ok := elem.(types.String)
+		if !ok || str.IsNull() || str.IsUnknown() {
+			continue
+		}
+
+		values = append(values, str.ValueString())
+	}
+
+	return values
+}
+
+// hasPackaging reports whether packaging lists value.
+func (m *ImageResourceModel) hasPackaging(value string) bool {
+	return slices.Contains(m.packagings(), value)
+}
+
+// diskAlignment returns the multiple the disk size is rounded up to for the
+// platform and every packaging. The alignments are powers of two, so the
+// largest is a multiple of all.
+func (m *ImageResourceModel) diskAlignment() int64 {
+	alignment := m.profile().Alignment
+
+	for _, packaging := range m.packagings() {
+		alignment = max(alignment, packagingAlignments[packaging])
+	}
+
+	return alignment
+}
+
+// outputBasename returns output_filename without its extension, after
+// which the packaging artifacts are named.
+func outputBasename(outputFilename string) string {
+	return strings.TrimSuffix(outputFilename, filepath.Ext(outputFilename))
+}
+
+// artifactFilename returns the filename of a packaging artifact.
+func artifactFilename(outputFilename, packaging string) string {
+	base := outputBasename(outputFilename)
+
+	switch packaging {
+	case packagingGCE:
+		return base + "-gce.tar.gz"
+	case packagingAzure:
+		return base + "-azure.vhd"
+	case packagingAWSRaw:
+		return base + "-aws.raw"
+	case packagingOVA:
+		return base + ".ova"
+	case packagingVagrantLibvirt:
+		return base + "-libvirt.box"
+	case packagingVagrantVirtualBox:
+		return base + "-virtualbox.box"
+	case packagingISO:
+		return base + "-installer.iso"
+	default:
+		return base + "-aws.vmdk"
+	}
+}
+
+// packageSource is what the packaging artifacts are built from.
+type packageSource struct {
+	// Installer is set when packaging lists iso.
+	Installer *isoInstaller
+	Mtime     *time.Time
+	RawPath   string
+	// Name is the virtual machine name of the OVF descriptors.
+	Name     string
+	Hardware virtualHardware
+}
+
+// packageImage writes the artifact of packaging to output.
+func packageImage(ctx context.Context, src packageSource, packaging, output string) error {
+	switch packaging {
+	case packagingGCE:
+		return writeGCETarball(src.RawPath, output, src.Mtime)
+	case packagingAzure:
+		return convertDisk(ctx, formatVHD, src.RawPath, output)
+	case packagingAWSRaw:
+		return convertDisk(ctx, formatRaw, src.RawPath, output)
+	case packagingOVA:
+		return writeOVA(ctx, src.RawPath, output, src.Name, src.Hardware, src.Mtime)
+	case packagingVagrantLibvirt, packagingVagrantVirtualBox:
+		return writeVagrantBox(ctx, src.RawPath, output, src.Name, packaging, src.Hardware, src.Mtime)
+	case packagingISO:
+		return writeInstallerISO(ctx, *src.Installer, output, src.Mtime)
+	default:
+		return convertDisk(ctx, formatVMDK, src.RawPath, output)
+	}
+}
+
+// writeGCETarball writes the raw disk as disk.raw into a gzipped tarball in
+// the GNU format GCE imports. archive/tar cannot write sparse entries, so
+// the holes are stored as zeros and left to gzip, unlike tar -S.
+func writeGCETarball(rawPath, output string, mtime *time.Time) error {
+	return writeTarball(output, []tarEntry{{Name: gceDiskName, Path: rawPath}}, tar.FormatGNU, true, mtime)
+}
+
+// tarEntry is a file of a tarball, read from Path or taken from Content.
+type tarEntry struct {
+	Name    string
+	Path    string
+	Content []byte
+}
+
+// writeTarball writes entries in order into a tarball, gzipped when
+// compress is set. Entries are stamped with mtime, or the current time.
+// FormatUnknown lets archive/tar pick ustar and fall back for large files.
+func writeTarball(output string, entries []tarEntry, format tar.Format, compress bool, mtime *time.Time) (err error) {
+	modTime := time.Now()
+	if mtime != nil {
+		modTime = *mtime
+	}
+
+	//nolint:gosec // G304: the output path is built from the resource's output_path
+	file, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	var (
+		out io.Writer = file
+		gz  *gzip.Writer
+	)
+
+	if compress {
+		gz = gzip.NewWriter(file)
+		out = gz
+	}
+
+	archive := tar.NewWriter(out)
+
+	for _, entry := range entries {
+		err = writeTarEntry(archive, entry, format, modTime)
+		if err != nil {
+			return fmt.Errorf("%s: %w", entry.Name, err)
+		}
+	}
+
+	if err = archive.Close(); err != nil {
+		return err
+	}
+
+	if gz != nil {
+		return gz.Close()
+	}
+
+	return nil
+}
+
+func writeTarEntry(archive *tar.Writer, entry tarEntry, format tar.Format, modTime time.Time) error {
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     entry.Name,
+		Size:     int64(len(entry.Content)),
+		Mode:     0o644,
+		ModTime:  modTime,
+		Format:   format,
+	}
+
+	if entry.Path == "" {
+		if err := archive.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		_, err := archive.Write(entry.Content)
+
+		return err
+	}
+
+	src, err := os.Open(entry.Path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	hdr.Size = info.Size()
+
+	if err := archive.WriteHeader(hdr); err != nil {
+		return err
+	}
+
+	_, err = io.Copy(archive, src)
+
+	return err
+}
+
+// validatePackaging checks that each packaging is known and listed once.
+func validatePackaging(list types.List) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	seen := make([]string, 0, len(list.Elements()))
+
+	for idx, elem := range list.Elements() {
+		str, ok := elem.(types.String)
+		if !ok || str.IsNull() || str.IsUnknown() {
+			continue
+		}
+
+		elemPath := path.Root("packaging").AtListIndex(idx)
+		packaging := str.ValueString()
+
+		switch {
+		case !slices.Contains(packagings, packaging):
+			diags.AddAttributeError(elemPath, "Invalid packaging",
+				fmt.Sprintf("Expected one of: %s, got: %s", strings.Join(packagings, ", "), packaging))
+		case slices.Contains(seen, packaging):
+			diags.AddAttributeError(elemPath, "Duplicate packaging", packaging+" is listed more than once.")
+		}
+
+		seen = append(seen, packaging)
+	}
+
+	return diags
+}
@@ -0,0 +1,217 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// packagingList returns a packaging list of the given values.
+func packagingList(values ...string) types.List {
+	elems := make([]attr.Value, 0, len(values))
+	for _, value := range values {
+		elems = append(elems, types.StringValue(value))
+	}
+
+	return types.ListValueMust(types.StringType, elems)
+}
+
+func TestArtifactFilename(t *testing.T) {
+	for packaging, want := range map[string]string{
+		packagingGCE:               "server-gce.tar.gz",
+		packagingAzure:             "server-azure.vhd",
+		packagingAWSRaw:            "server-aws.raw",
+		packagingAWSVMDK:           "server-aws.vmdk",
+		packagingOVA:               "server.ova",
+		packagingVagrantLibvirt:    "server-libvirt.box",
+		packagingVagrantVirtualBox: "server-virtualbox.box",
+		packagingISO:               "server-installer.iso",
+	} {
+		if got := artifactFilename("server.qcow2", packaging); got != want {
+			t.Errorf("artifactFilename(%s) = %q, want %q", packaging, got, want)
+		}
+	}
+}
+
+func TestImageResourceModel_DiskAlignment(t *testing.T) {
+	tests := []struct {
+		name string
+		data ImageResourceModel
+		want int64
+	}{
+		{"none", ImageResourceModel{}, 0},
+		{"aws", ImageResourceModel{Packaging: packagingList(packagingAWSRaw, packagingAWSVMDK)}, 0},
+		{"azure", ImageResourceModel{Packaging: packagingList(packagingAzure)}, mebibyte},
+		{"gce_azure", ImageResourceModel{Packaging: packagingList(packagingAzure, packagingGCE)}, gibibyte},
+		{"platform", ImageResourceModel{
+			Platform:  types.StringValue(platformGCP),
+			Packaging: packagingList(packagingAzure),
+		}, gibibyte},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := testCase.data.diskAlignment(); got != testCase.want {
+				t.Errorf("diskAlignment() = %d, want %d", got, testCase.want)
+			}
+		})
+	}
+
+	data := ImageResourceModel{Packaging: packagingList(packagingGCE), DiskSize: types.StringValue("1500M")}
+	if got := data.alignedDiskSize(); got != "2G" {
+		t.Errorf("alignedDiskSize() = %q, want 2G", got)
+	}
+}
+
+func TestWriteGCETarball(t *testing.T) {
+	dir := t.TempDir()
+	rawPath := filepath.Join(dir, "disk.raw")
+	tarPath := filepath.Join(dir, "disk-gce.tar.gz")
+	content := bytes.Repeat([]byte("bootc"), 4096)
+
+	err := os.WriteFile(rawPath, content, testSecureFilePerms)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mtime := time.Unix(1700000000, 0)
+
+	err = writeGCETarball(rawPath, tarPath, &mtime)
+	if err != nil {
+		t.Fatalf("writeGCETarball() error = %v", err)
+	}
+
+	file, err := os.Open(tarPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+
+	archive := tar.NewReader(gz)
+
+	hdr, err := archive.Next()
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+
+	if hdr.Name != gceDiskName || hdr.Format != tar.FormatGNU || !hdr.ModTime.Equal(mtime) || hdr.Size != int64(len(content)) {
+		t.Errorf("header = %+v", hdr)
+	}
+
+	got, err := io.ReadAll(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, content) {
+		t.Error("tarball content differs from the raw disk")
+	}
+
+	if _, err := archive.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("Next() error = %v, want a single entry", err)
+	}
+}
+
+func TestWriteTarball(t *testing.T) {
+	dir := t.TempDir()
+	diskPath := filepath.Join(dir, "disk.vmdk")
+	tarPath := filepath.Join(dir, "disk.ova")
+
+	err := os.WriteFile(diskPath, []byte("vmdk"), testSecureFilePerms)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := []tarEntry{
+		{Name: "disk.ovf", Content: []byte("<Envelope/>")},
+		{Name: "disk.mf", Content: []byte("manifest")},
+		{Name: "disk-disk1.vmdk", Path: diskPath},
+	}
+
+	err = writeTarball(tarPath, entries, tar.FormatUnknown, false, nil)
+	if err != nil {
+		t.Fatalf("writeTarball() error = %v", err)
+	}
+
+	file, err := os.Open(tarPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	archive := tar.NewReader(file)
+
+	for _, want := range []string{"disk.ovf", "disk.mf", "disk-disk1.vmdk"} {
+		hdr, err := archive.Next()
+		if err != nil {
+			t.Fatalf("Next() error = %v, want %s", err, want)
+		}
+
+		if hdr.Name != want || hdr.Format != tar.FormatUSTAR {
+			t.Errorf("entry = %s (%v), want %s in ustar", hdr.Name, hdr.Format, want)
+		}
+	}
+}
+
+func TestValidatePackaging(t *testing.T) {
+	tests := []struct {
+		name    string
+		list    types.List
+		wantErr string
+	}{
+		{"null", types.ListNull(types.StringType), ""},
+		{"all", packagingList(packagings...), ""},
+		{"unknown", packagingList("oci"), "Invalid packaging"},
+		{"duplicate", packagingList(packagingGCE, packagingAzure, packagingGCE), "Duplicate packaging"},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			diags := validatePackaging(testCase.list)
+
+			if testCase.wantErr == "" {
+				if diags.HasError() {
+					t.Errorf("unexpected diagnostics: %v", diags)
+				}
+
+				return
+			}
+
+			if !diags.HasError() || diags.Errors()[0].Summary() != testCase.wantErr {
+				t.Errorf("diagnostics = %v, want %q", diags, testCase.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"slices"
+	"strconv"
+)
+
+const (
+	platformQEMU      = "qemu"
+	platformAWS       = "aws"
+	platformAzure     = "azure"
+	platformGCP       = "gcp"
+	platformVSphere   = "vsphere"
+	platformOpenStack = "openstack"
+	platformMetal     = "metal"
+
+	formatQCOW2 = "qcow2"
+	formatRaw   = "raw"
+	formatVMDK  = "vmdk"
+	formatVHD   = "vhd"
+	formatVHDX  = "vhdx"
+
+	defaultOutputBasename = "disk"
+
+	mebibyte = 1 << 20
+	gibibyte = 1 << 30
+)
+
+// serialConsoleKargs send the kernel console to the first serial port,
+// which every platform exposes as its serial log.
+var serialConsoleKargs = []string{"console=tty0", "console=ttyS0,115200n8"}
+
+// platformProfile holds the defaults a platform applies to bootc_image.
+type platformProfile struct {
+	// IgnitionPlatform is the ignition.platform.id of the platform.
+	IgnitionPlatform string
+	OutputFormat     string
+	// GuestAgent is enabled when the image ships it.
+	GuestAgent string
+	Kargs      []string
+	// Alignment rounds the disk size up to a multiple of it.
+	Alignment int64
+}
+
+// platformProfiles are the known-good defaults of each platform.
+// Azure requires fixed VHDs whose virtual size is a whole number of MiB,
+// and GCE disks whose size is a whole number of GiB.
+var platformProfiles = map[string]platformProfile{
+	platformQEMU: {
+		Kargs: serialConsoleKargs, IgnitionPlatform: "qemu", OutputFormat: formatQCOW2,
+		GuestAgent: "qemu-guest-agent.service",
+	},
+	platformAWS: {
+		Kargs:            append(slices.Clone(serialConsoleKargs), "nvme_core.io_timeout=4294967295"),
+		IgnitionPlatform: "aws", OutputFormat: formatVMDK, GuestAgent: "amazon-ssm-agent.service",
+	},
+	platformAzure: {
+		Kargs:            []string{"console=tty1", "console=ttyS0,115200n8", "earlyprintk=ttyS0", "rootdelay=300"},
+		IgnitionPlatform: "azure", OutputFormat: formatVHD, GuestAgent: "waagent.service", Alignment: mebibyte,
+	},
+	platformGCP: {
+		Kargs: []string{"console=ttyS0,115200n8"}, IgnitionPlatform: "gcp", OutputFormat: formatRaw,
+		GuestAgent: "google-guest-agent.service", Alignment: gibibyte,
+	},
+	platformVSphere: {
+		Kargs: serialConsoleKargs, IgnitionPlatform: "vmware", OutputFormat: formatVMDK,
+		GuestAgent: "vmtoolsd.service",
+	},
+	platformOpenStack: {
+		Kargs: serialConsoleKargs, IgnitionPlatform: "openstack", OutputFormat: formatQCOW2,
+		GuestAgent: "qemu-guest-agent.service",
+	},
+	platformMetal: {
+		Kargs: serialConsoleKargs, IgnitionPlatform: "metal", OutputFormat: formatRaw,
+	},
+}
+
+// platformNames lists the platform values in a stable order.
+var platformNames = []string{
+	platformQEMU, platformAWS, platformAzure, platformGCP, platformVSphere, platformOpenStack, platformMetal,
+}
+
+// outputFormats lists the output_format values.
+var outputFormats = []string{formatQCOW2, formatRaw, formatVMDK, formatVHD, formatVHDX}
+
+// profile returns the platform profile, or an empty one without platform.
+func (m *ImageResourceModel) profile() platformProfile {
+	return platformProfiles[m.Platform.ValueString()]
+}
+
+// outputFormat returns output_format, the platform's format, or qcow2.
+func (m *ImageResourceModel) outputFormat() string {
+	switch {
+	case !m.OutputFormat.IsNull():
+		return m.OutputFormat.ValueString()
+	case m.profile().OutputFormat != "":
+		return m.profile().OutputFormat
+	default:
+		return formatQCOW2
+	}
+}
+
+// platformKargs returns the platform's kernel arguments whose name is not
+// set by kargs, so an explicit console= replaces every console of the
+// profile.
+func (m *ImageResourceModel) platformKargs(kargs []string) []string {
+	var platformKargs []string
+
+	for _, karg := range m.profile().Kargs {
+		if !slices.ContainsFunc(kargs, func(explicit string) bool { return kargKey(explicit) == kargKey(karg) }) {
+			platformKargs = append(platformKargs, karg)
+		}
+	}
+
+	return platformKargs
+}
+
+// ignitionPlatformID returns ignition.platform set in the ignition block,
+// the platform's ID, or metal.
+func (m *ImageResourceModel) ignitionPlatformID() string {
+	explicit := m.Ignition != nil && m.Ignition.Platform.ValueString() != ""
+	if id := m.profile().IgnitionPlatform; id != "" && !explicit {
+		return id
+	}
+
+	if m.Ignition == nil {
+		return defaultIgnitionPlatform
+	}
+
+	return ignitionPlatform(*m.Ignition)
+}
+
+// guestAgent returns the platform's guest agent unit, unless systemd_units
+// configures it or the composefs backend leaves no deployment to enable it
+// in.
+func (m *ImageResourceModel) guestAgent() string {
+	agent := m.profile().GuestAgent
+	if agent == "" || m.ComposefsBackend.ValueBool() {
+		return ""
+	}
+
+	for _, unit := range m.SystemdUnits {
+		if unit.Name.ValueString() == agent {
+			return ""
+		}
+	}
+
+	return agent
+}
+
+// alignedDiskSize rounds disk_size up to the platform's and the
+// packagings' alignment. Sizes parseSize does not understand are passed to
+// truncate unchanged.
+func (m *ImageResourceModel) alignedDiskSize() string {
+	alignment := m.diskAlignment()
+
+	size, err := parseSize(m.DiskSize.ValueString())
+	if alignment == 0 || err != nil || size%alignment == 0 {
+		return m.DiskSize.ValueString()
+	}
+
+	return formatSize((size/alignment + 1) * alignment)
+}
+
+// formatSize renders a byte count with the largest binary suffix that
+// divides it, as parseSize reads it.
+func formatSize(size int64) string {
+	suffix := ""
+
+	for _, next := range []string{"K", "M", "G", "T"} {
+		if size == 0 || size%1024 != 0 {
+			break
+		}
+
+		size /= 1024
+		suffix = next
+	}
+
+	return strconv.FormatInt(size, 10) + suffix
+}
+
+// outputFilename returns the default output_filename of a format.
+func outputFilename(format string) string {
+	return defaultOutputBasename + "." + format
+}
+
+// convertArgs returns the qemu-img convert arguments writing the raw disk
+// in format. VMDKs are stream-optimized and VHDs fixed with the exact raw
+// size, as the AWS, vSphere and Azure importers expect.
+func convertArgs(format, rawPath, output string) []string {
+	args := []string{"convert", "-f", "raw"}
+
+	switch format {
+	case formatVMDK:
+		args = append(args, "-O", "vmdk", "-o", "subformat=streamOptimized")
+	case formatVHD:
+		args = append(args, "-O", "vpc", "-o", "subformat=fixed,force_size=on")
+	case formatVHDX:
+		args = append(args, "-O", "vhdx", "-o", "subformat=dynamic")
+	default:
+		args = append(args, "-O", format)
+	}
+
+	return append(args, rawPath, output)
+}
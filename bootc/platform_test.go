@@ -0,0 +1,213 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestPlatformProfiles(t *testing.T) {
+	if len(platformProfiles) != len(platformNames) {
+		t.Errorf("platformProfiles has %d entries, platformNames %d", len(platformProfiles), len(platformNames))
+	}
+
+	for _, name := range platformNames {
+		profile, ok := platformProfiles[name]
+		if !ok {
+			t.Errorf("platform %s has no profile", name)
+
+			continue
+		}
+
+		if !slices.Contains(outputFormats, profile.OutputFormat) || profile.IgnitionPlatform == "" || len(profile.Kargs) == 0 {
+			t.Errorf("platform %s profile = %+v", name, profile)
+		}
+	}
+}
+
+func TestImageResourceModel_OutputFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		data ImageResourceModel
+		want string
+	}{
+		{"default", ImageResourceModel{}, formatQCOW2},
+		{"platform", ImageResourceModel{Platform: types.StringValue(platformAzure)}, formatVHD},
+		{"explicit", ImageResourceModel{
+			Platform:     types.StringValue(platformAzure),
+			OutputFormat: types.StringValue(formatVHDX),
+		}, formatVHDX},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := testCase.data.outputFormat(); got != testCase.want {
+				t.Errorf("outputFormat() = %q, want %q", got, testCase.want)
+			}
+
+			if got, want := outputFilename(testCase.data.outputFormat()), "disk."+testCase.want; got != want {
+				t.Errorf("outputFilename() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestImageResourceModel_PlatformKargs(t *testing.T) {
+	data := ImageResourceModel{Platform: types.StringValue(platformAWS)}
+
+	got := data.platformKargs(nil)
+	if !slices.Equal(got, []string{"console=tty0", "console=ttyS0,115200n8", "nvme_core.io_timeout=4294967295"}) {
+		t.Errorf("platformKargs() = %v", got)
+	}
+
+	got = data.platformKargs([]string{"console=ttyS1,9600"})
+	if !slices.Equal(got, []string{"nvme_core.io_timeout=4294967295"}) {
+		t.Errorf("platformKargs() with console = %v, want the explicit console to replace the profile's", got)
+	}
+
+	if got := (&ImageResourceModel{}).platformKargs(nil); got != nil {
+		t.Errorf("platformKargs() without platform = %v", got)
+	}
+}
+
+func TestImageResourceModel_IgnitionPlatformID(t *testing.T) {
+	gcp := types.StringValue(platformGCP)
+
+	tests := []struct {
+		name string
+		data ImageResourceModel
+		want string
+	}{
+		{"default", ImageResourceModel{Ignition: &IgnitionModel{}}, defaultIgnitionPlatform},
+		{"platform", ImageResourceModel{Platform: gcp, Ignition: &IgnitionModel{}}, "gcp"},
+		{"vsphere", ImageResourceModel{Platform: types.StringValue(platformVSphere)}, "vmware"},
+		{"explicit", ImageResourceModel{
+			Platform: gcp,
+			Ignition: &IgnitionModel{Platform: types.StringValue("qemu")},
+		}, "qemu"},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := testCase.data.ignitionPlatformID(); got != testCase.want {
+				t.Errorf("ignitionPlatformID() = %q, want %q", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestImageResourceModel_GuestAgent(t *testing.T) {
+	qemu := types.StringValue(platformQEMU)
+
+	tests := []struct {
+		name string
+		data ImageResourceModel
+		want string
+	}{
+		{"none", ImageResourceModel{}, ""},
+		{"qemu", ImageResourceModel{Platform: qemu}, "qemu-guest-agent.service"},
+		{"metal", ImageResourceModel{Platform: types.StringValue(platformMetal)}, ""},
+		{"composefs", ImageResourceModel{Platform: qemu, ComposefsBackend: types.BoolValue(true)}, ""},
+		{"explicit_unit", ImageResourceModel{Platform: qemu, SystemdUnits: []SystemdUnitModel{
+			{Name: types.StringValue("qemu-guest-agent.service"), Mask: types.BoolValue(true)},
+		}}, ""},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := testCase.data.guestAgent(); got != testCase.want {
+				t.Errorf("guestAgent() = %q, want %q", got, testCase.want)
+			}
+
+			if testCase.want != "" && !testCase.data.customizesDeployment() {
+				t.Error("customizesDeployment() = false with a guest agent")
+			}
+		})
+	}
+}
+
+func TestImageResourceModel_AlignedDiskSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		platform string
+		size     string
+		want     string
+	}{
+		{"unaligned_platform", platformQEMU, "1001K", "1001K"},
+		{"azure_aligned", platformAzure, "10G", "10G"},
+		{"azure_rounded", platformAzure, "1000K", "1M"},
+		{"azure_mib", platformAzure, "1537K", "2M"},
+		{"gcp_rounded", platformGCP, "1500M", "2G"},
+		{"unparsed", platformGCP, "10GB", "10GB"},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			data := ImageResourceModel{Platform: types.StringValue(testCase.platform), DiskSize: types.StringValue(testCase.size)}
+			if got := data.alignedDiskSize(); got != testCase.want {
+				t.Errorf("alignedDiskSize() = %q, want %q", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	for size, want := range map[int64]string{
+		0:            "0",
+		1000:         "1000",
+		2048:         "2K",
+		3 * mebibyte: "3M",
+		gibibyte + 1: "1073741825",
+		5 * gibibyte: "5G",
+		1 << 50:      "1024T",
+	} {
+		if got := formatSize(size); got != want {
+			t.Errorf("formatSize(%d) = %q, want %q", size, got, want)
+		}
+	}
+}
+
+func TestConvertArgs(t *testing.T) {
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{formatQCOW2, []string{"-O", "qcow2"}},
+		{formatVMDK, []string{"-O", "vmdk", "-o", "subformat=streamOptimized"}},
+		{formatVHD, []string{"-O", "vpc", "-o", "subformat=fixed,force_size=on"}},
+		{formatVHDX, []string{"-O", "vhdx", "-o", "subformat=dynamic"}},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.format, func(t *testing.T) {
+			got := convertArgs(testCase.format, "/out/disk.raw", "/out/disk."+testCase.format)
+
+			want := append(append([]string{"convert", "-f", "raw"}, testCase.want...), "/out/disk.raw", "/out/disk."+testCase.format)
+			if !slices.Equal(got, want) {
+				t.Errorf("convertArgs() = %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -70,9 +70,16 @@ func (*BootcProvider) Configure(
 func (*BootcProvider) Resources(_ context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		NewImageResource,
+		NewCloudInitSeedResource,
+		NewPXEArtifactsResource,
 	}
 }
 
 func (*BootcProvider) DataSources(_ context.Context) []func() datasource.DataSource {
-	return nil
+	return []func() datasource.DataSource{
+		NewContainerImageDataSource,
+		NewRegistryTagDataSource,
+		NewDiskImageInfoDataSource,
+		NewInstallConfigDataSource,
+	}
 }
@@ -19,6 +19,7 @@ package bootc
 import (
 	"testing"
 
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 )
@@ -105,25 +106,41 @@ func TestBootcProvider_Resources(t *testing.T) {
 	prov := &BootcProvider{}
 	resources := prov.Resources(t.Context())
 
-	if len(resources) != 1 {
-		t.Fatalf("expected 1 resource, got %d", len(resources))
+	want := []string{"bootc_image", "bootc_cloudinit_seed", "bootc_pxe_artifacts"}
+	if len(resources) != len(want) {
+		t.Fatalf("expected %d resources, got %d", len(want), len(resources))
 	}
 
-	r := resources[0]()
-	resp := &resource.MetadataResponse{}
-	r.Metadata(t.Context(), resource.MetadataRequest{ProviderTypeName: providerTypeName}, resp)
+	for idx, factory := range resources {
+		resp := &resource.MetadataResponse{}
+		factory().Metadata(t.Context(), resource.MetadataRequest{ProviderTypeName: providerTypeName}, resp)
 
-	if resp.TypeName != "bootc_image" {
-		t.Errorf("resource type = %q, want %q", resp.TypeName, "bootc_image")
+		if resp.TypeName != want[idx] {
+			t.Errorf("resource type = %q, want %q", resp.TypeName, want[idx])
+		}
 	}
 }
 
 func TestBootcProvider_DataSources(t *testing.T) {
 	prov := &BootcProvider{}
-	ds := prov.DataSources(t.Context())
+	dataSources := prov.DataSources(t.Context())
 
-	if ds != nil {
-		t.Errorf("expected nil data sources, got %d", len(ds))
+	want := []string{"bootc_container_image", "bootc_registry_tag", "bootc_disk_image_info", "bootc_install_config"}
+	if len(dataSources) != len(want) {
+		t.Fatalf("expected %d data sources, got %d", len(want), len(dataSources))
+	}
+
+	for idx, factory := range dataSources {
+		resp := &datasource.MetadataResponse{}
+		factory().Metadata(
+			t.Context(),
+			datasource.MetadataRequest{ProviderTypeName: providerTypeName},
+			resp,
+		)
+
+		if resp.TypeName != want[idx] {
+			t.Errorf("data source type = %q, want %q", resp.TypeName, want[idx])
+		}
 	}
 }
 
@@ -153,8 +170,8 @@ func TestBootcProvider_Implements(t *testing.T) {
 			_ = prov.Resources(t.Context())
 
 			ds := prov.DataSources(t.Context())
-			if ds != nil {
-				t.Error("expected nil data sources")
+			if len(ds) == 0 {
+				t.Error("expected data sources")
 			}
 		})
 	}
@@ -0,0 +1,93 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+)
+
+// pxeDracutModule fetches the live root over the network in the initramfs.
+const pxeDracutModule = "livenet"
+
+// pxeArtifacts are the files of a live PXE boot.
+type pxeArtifacts struct {
+	Kernel     string
+	Initramfs  string
+	Rootfs     string
+	IPXEScript string
+}
+
+// newPXEArtifacts returns the artifact paths in dir for the given file
+// name prefix.
+func newPXEArtifacts(dir, prefix string) pxeArtifacts {
+	return pxeArtifacts{
+		Kernel:     filepath.Join(dir, prefix+"-vmlinuz"),
+		Initramfs:  filepath.Join(dir, prefix+"-initrd.img"),
+		Rootfs:     filepath.Join(dir, prefix+"-rootfs.img"),
+		IPXEScript: filepath.Join(dir, prefix+".ipxe"),
+	}
+}
+
+// Paths returns every artifact path.
+func (a pxeArtifacts) Paths() []string {
+	return []string{a.Kernel, a.Initramfs, a.Rootfs, a.IPXEScript}
+}
+
+// artifactURL returns the URL of an artifact served from baseURL.
+func artifactURL(baseURL, artifactPath string) string {
+	return strings.TrimSuffix(baseURL, "/") + "/" + filepath.Base(artifactPath)
+}
+
+// pxeKargs returns the kernel arguments booting the rootfs served from
+// baseURL live, followed by kargs. dmsquash-live downloads the rootfs into
+// memory once DHCP configured the network.
+func pxeKargs(baseURL string, artifacts pxeArtifacts, kargs []string) []string {
+	return append(append(liveKargs(artifactURL(baseURL, artifacts.Rootfs)), "rd.neednet=1", "ip=dhcp"), kargs...)
+}
+
+// ipxeScript renders an iPXE script booting the artifacts served from
+// baseURL. The initrd= argument lets the kernel's EFI stub find the
+// initramfs iPXE registered.
+func ipxeScript(baseURL string, artifacts pxeArtifacts, kargs []string) string {
+	initramfs := filepath.Base(artifacts.Initramfs)
+
+	return "#!ipxe\n" +
+		"kernel " + artifactURL(baseURL, artifacts.Kernel) + " initrd=" + initramfs + " " + strings.Join(kargs, " ") + "\n" +
+		"initrd " + artifactURL(baseURL, artifacts.Initramfs) + "\n" +
+		"boot\n"
+}
+
+// writePXEArtifacts extracts the kernel of image, a network-enabled live
+// initramfs and the image root filesystem as a squashfs, and writes the
+// iPXE script.
+func writePXEArtifacts(ctx context.Context, image string, artifacts pxeArtifacts, script string) error {
+	err := withImageMount(ctx, image, func(imageID, rootfs string) error {
+		err := writeLiveBoot(ctx, imageID, rootfs, artifacts.Kernel, artifacts.Initramfs, pxeDracutModule)
+		if err != nil {
+			return err
+		}
+
+		return writeLiveRootfs(ctx, nil, rootfs, artifacts.Rootfs)
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeFileMode(artifacts.IPXEScript, []byte(script), 0o644)
+}
@@ -0,0 +1,64 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNewPXEArtifacts(t *testing.T) {
+	artifacts := newPXEArtifacts("/srv/tftp/edge", "node-1.4")
+
+	want := []string{
+		"/srv/tftp/edge/node-1.4-vmlinuz",
+		"/srv/tftp/edge/node-1.4-initrd.img",
+		"/srv/tftp/edge/node-1.4-rootfs.img",
+		"/srv/tftp/edge/node-1.4.ipxe",
+	}
+	if got := artifacts.Paths(); !slices.Equal(got, want) {
+		t.Errorf("Paths() = %q, want %q", got, want)
+	}
+}
+
+func TestPXEKargs(t *testing.T) {
+	artifacts := newPXEArtifacts("/srv/http", "bootc")
+
+	got := pxeKargs("http://boot.example.com/edge/", artifacts, []string{"console=ttyS0"})
+	want := []string{
+		"root=live:http://boot.example.com/edge/bootc-rootfs.img", "rd.live.image", "rd.live.overlay.overlayfs=1",
+		"enforcing=0", "rd.neednet=1", "ip=dhcp", "console=ttyS0",
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("pxeKargs() = %q, want %q", got, want)
+	}
+}
+
+func TestIPXEScript(t *testing.T) {
+	artifacts := newPXEArtifacts("/srv/http", "bootc")
+
+	got := ipxeScript("https://boot.example.com", artifacts, []string{"rd.live.image", "ip=dhcp"})
+	want := "#!ipxe\n" +
+		"kernel https://boot.example.com/bootc-vmlinuz initrd=bootc-initrd.img rd.live.image ip=dhcp\n" +
+		"initrd https://boot.example.com/bootc-initrd.img\n" +
+		"boot\n"
+
+	if got != want {
+		t.Errorf("ipxeScript() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,679 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	dockerHubDomain   = "docker.io"
+	dockerHubEndpoint = "registry-1.docker.io"
+
+	mediaTypeOCIIndex          = "application/vnd.oci.image.index.v1+json"
+	mediaTypeOCIManifest       = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeDockerList        = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeDockerManifest    = "application/vnd.docker.distribution.manifest.v2+json"
+	maxManifestBytes           = 4 << 20
+	systemRegistriesConfPath   = "/etc/containers/registries.conf"
+	systemRegistriesConfDir    = "/etc/containers/registries.conf.d"
+	registryAuthFileEnv        = "REGISTRY_AUTH_FILE"
+	registryAuthorizationBasic = "Basic"
+)
+
+var (
+	ErrInvalidReference = errors.New("invalid image reference")
+	ErrRegistryBlocked  = errors.New("registry is blocked by registries.conf")
+	ErrRegistryResponse = errors.New("unexpected registry response")
+)
+
+// imageReference is a parsed container image reference.
+type imageReference struct {
+	Domain     string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// Name returns the fully qualified repository name (domain/repository).
+func (r imageReference) Name() string {
+	return r.Domain + "/" + r.Repository
+}
+
+// Reference returns the tag or digest the manifest is looked up by.
+func (r imageReference) Reference() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+
+	return r.Tag
+}
+
+// parseImageReference splits an image reference into its parts. Unqualified
+// references resolve against the first unqualified-search registry, falling
+// back to docker.io.
+func parseImageReference(ref string, searchRegistries []string) (imageReference, error) {
+	var parsed imageReference
+
+	name := ref
+	if before, after, ok := strings.Cut(name, "@"); ok {
+		name, parsed.Digest = before, after
+	}
+
+	if idx := strings.LastIndex(name, ":"); idx > strings.LastIndex(name, "/") {
+		name, parsed.Tag = name[:idx], name[idx+1:]
+	}
+
+	if name == "" || strings.HasSuffix(name, "/") {
+		return parsed, fmt.Errorf("%w: %q", ErrInvalidReference, ref)
+	}
+
+	first, rest, hasSlash := strings.Cut(name, "/")
+	if hasSlash && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		parsed.Domain, parsed.Repository = first, rest
+	} else {
+		parsed.Domain, parsed.Repository = dockerHubDomain, name
+		if len(searchRegistries) > 0 {
+			parsed.Domain = searchRegistries[0]
+		}
+	}
+
+	if parsed.Domain == dockerHubDomain && !strings.Contains(parsed.Repository, "/") {
+		parsed.Repository = "library/" + parsed.Repository
+	}
+
+	if parsed.Tag == "" && parsed.Digest == "" {
+		parsed.Tag = "latest"
+	}
+
+	return parsed, nil
+}
+
+// registriesConf is the subset of containers-registries.conf(5) honored when
+// talking to registries.
+//
+//nolint:tagliatelle // keys follow containers-registries.conf(5)
+type registriesConf struct {
+	UnqualifiedSearchRegistries []string           `toml:"unqualified-search-registries"`
+	Registries                  []registryConfItem `toml:"registry"`
+}
+
+type registryConfItem struct {
+	Prefix   string             `toml:"prefix"`
+	Location string             `toml:"location"`
+	Mirrors  []registryEndpoint `toml:"mirror"`
+	Insecure bool               `toml:"insecure"`
+	Blocked  bool               `toml:"blocked"`
+}
+
+type registryEndpoint struct {
+	Location string `toml:"location"`
+	Insecure bool   `toml:"insecure"`
+}
+
+// loadRegistriesConf reads registries.conf. An explicit path is read on its
+// own; otherwise the per-user file wins over the system file, and drop-ins
+// from registries.conf.d are appended.
+func loadRegistriesConf(explicit string) (*registriesConf, error) {
+	conf := &registriesConf{}
+
+	if explicit != "" {
+		_, err := toml.DecodeFile(explicit, conf)
+
+		return conf, err
+	}
+
+	main := systemRegistriesConfPath
+	if configDir, err := os.UserConfigDir(); err == nil {
+		userConf := filepath.Join(configDir, "containers", "registries.conf")
+		if _, statErr := os.Stat(userConf); statErr == nil {
+			main = userConf
+		}
+	}
+
+	if _, err := os.Stat(main); err == nil {
+		if _, decodeErr := toml.DecodeFile(main, conf); decodeErr != nil {
+			return nil, fmt.Errorf("%s: %w", main, decodeErr)
+		}
+	}
+
+	dropIns, _ := filepath.Glob(filepath.Join(systemRegistriesConfDir, "*.conf"))
+	slices.Sort(dropIns)
+
+	for _, dropIn := range dropIns {
+		var extra registriesConf
+		if _, err := toml.DecodeFile(dropIn, &extra); err != nil {
+			return nil, fmt.Errorf("%s: %w", dropIn, err)
+		}
+
+		if len(extra.UnqualifiedSearchRegistries) > 0 {
+			conf.UnqualifiedSearchRegistries = extra.UnqualifiedSearchRegistries
+		}
+
+		conf.Registries = append(conf.Registries, extra.Registries...)
+	}
+
+	return conf, nil
+}
+
+// endpoints returns the locations to query for name, mirrors first, after
+// applying the longest matching [[registry]] prefix.
+func (c *registriesConf) endpoints(name string) ([]registryEndpoint, string, error) {
+	var (
+		match  *registryConfItem
+		prefix string
+	)
+
+	for idx := range c.Registries {
+		item := &c.Registries[idx]
+
+		itemPrefix := item.Prefix
+		if itemPrefix == "" {
+			itemPrefix = item.Location
+		}
+
+		if name != itemPrefix && !strings.HasPrefix(name, itemPrefix+"/") {
+			continue
+		}
+
+		if match == nil || len(itemPrefix) > len(prefix) {
+			match, prefix = item, itemPrefix
+		}
+	}
+
+	if match == nil {
+		return []registryEndpoint{{Location: name}}, name, nil
+	}
+
+	if match.Blocked {
+		return nil, "", fmt.Errorf("%w: %s", ErrRegistryBlocked, name)
+	}
+
+	suffix := strings.TrimPrefix(name, prefix)
+
+	endpoints := make([]registryEndpoint, 0, len(match.Mirrors)+1)
+	for _, mirror := range match.Mirrors {
+		endpoints = append(endpoints, registryEndpoint{
+			Location: mirror.Location + suffix,
+			Insecure: mirror.Insecure,
+		})
+	}
+
+	location := match.Location
+	if location == "" {
+		location = prefix
+	}
+
+	endpoints = append(endpoints, registryEndpoint{Location: location + suffix, Insecure: match.Insecure})
+
+	return endpoints, location + suffix, nil
+}
+
+// registryAuthFile is the containers-auth.json(5) / docker config.json format.
+type registryAuthFile struct {
+	Auths map[string]struct {
+		Auth string `json:"auth"`
+	} `json:"auths"`
+}
+
+// registryCredentials holds basic-auth credentials for a registry.
+type registryCredentials struct {
+	Username string
+	Password string
+}
+
+// authFilePaths lists the auth files consulted, in containers-auth.json(5) order.
+func authFilePaths(explicit string) []string {
+	if explicit != "" {
+		return []string{explicit}
+	}
+
+	var paths []string
+	if env := os.Getenv(registryAuthFileEnv); env != "" {
+		paths = append(paths, env)
+	}
+
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		paths = append(paths, filepath.Join(runtimeDir, "containers", "auth.json"))
+	}
+
+	if configDir, err := os.UserConfigDir(); err == nil {
+		paths = append(paths, filepath.Join(configDir, "containers", "auth.json"))
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".docker", "config.json"))
+	}
+
+	return paths
+}
+
+// lookupCredentials returns the credentials for the given domain/repository
+// from the first auth file that has a matching entry. Repository-scoped
+// entries win over registry-wide ones.
+func lookupCredentials(paths []string, name string) (registryCredentials, bool, error) {
+	keys := []string{}
+	for key := name; key != ""; {
+		keys = append(keys, key)
+
+		idx := strings.LastIndex(key, "/")
+		if idx < 0 {
+			break
+		}
+
+		key = key[:idx]
+	}
+
+	if strings.HasPrefix(name, dockerHubDomain+"/") || name == dockerHubDomain {
+		keys = append(keys, "https://index.docker.io/v1/", "index.docker.io")
+	}
+
+	for _, authPath := range paths {
+		raw, err := os.ReadFile(authPath)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return registryCredentials{}, false, err
+		}
+
+		var file registryAuthFile
+		if err := json.Unmarshal(raw, &file); err != nil {
+			return registryCredentials{}, false, fmt.Errorf("%s: %w", authPath, err)
+		}
+
+		for _, key := range keys {
+			entry, ok := file.Auths[key]
+			if !ok || entry.Auth == "" {
+				continue
+			}
+
+			decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+			if err != nil {
+				return registryCredentials{}, false, fmt.Errorf("%s: %s: %w", authPath, key, err)
+			}
+
+			user, pass, _ := strings.Cut(string(decoded), ":")
+
+			return registryCredentials{Username: user, Password: pass}, true, nil
+		}
+	}
+
+	return registryCredentials{}, false, nil
+}
+
+// ociManifest covers the fields of OCI and Docker v2 manifests and indexes
+// needed to resolve tags.
+type ociManifest struct {
+	Annotations map[string]string    `json:"annotations"`
+	MediaType   string               `json:"mediaType"` //nolint:tagliatelle // OCI field name
+	Manifests   []ociManifestElement `json:"manifests"`
+}
+
+type ociManifestElement struct {
+	Platform    *ociPlatform      `json:"platform"`
+	Annotations map[string]string `json:"annotations"`
+	MediaType   string            `json:"mediaType"` //nolint:tagliatelle // OCI field name
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+}
+
+type ociPlatform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant"`
+}
+
+// String formats the platform as os/architecture[/variant].
+func (p ociPlatform) String() string {
+	if p.OS == "" && p.Architecture == "" {
+		return ""
+	}
+
+	s := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+
+	return s
+}
+
+// resolvedManifest is the result of resolving a reference against a registry.
+type resolvedManifest struct {
+	Manifest  ociManifest
+	Digest    string
+	MediaType string
+	// Name is the repository the manifest was served for, after registries.conf rewrites.
+	Name string
+}
+
+// registryClient resolves manifests over the OCI distribution API.
+type registryClient struct {
+	HTTP      *http.Client
+	Conf      *registriesConf
+	AuthFiles []string
+}
+
+// Resolve fetches the manifest for ref without pulling any layers. Mirrors
+// are tried first; the error from the last endpoint is returned if all fail.
+func (c *registryClient) Resolve(ctx context.Context, ref imageReference) (*resolvedManifest, error) {
+	endpoints, primary, err := c.Conf.endpoints(ref.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+
+	for _, endpoint := range endpoints {
+		resolved, fetchErr := c.fetchManifest(ctx, endpoint, ref.Reference())
+		if fetchErr == nil {
+			resolved.Name = primary
+
+			return resolved, nil
+		}
+
+		lastErr = fetchErr
+	}
+
+	return nil, lastErr
+}
+
+func (c *registryClient) fetchManifest(
+	ctx context.Context,
+	endpoint registryEndpoint,
+	reference string,
+) (*resolvedManifest, error) {
+	host, repository, ok := strings.Cut(endpoint.Location, "/")
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidReference, endpoint.Location)
+	}
+
+	creds, hasCreds, credsErr := lookupCredentials(c.AuthFiles, endpoint.Location)
+	if credsErr != nil {
+		return nil, credsErr
+	}
+
+	apiHost := host
+	if host == dockerHubDomain {
+		apiHost = dockerHubEndpoint
+	}
+
+	client := c
+	if endpoint.Insecure {
+		client = c.insecure()
+	}
+
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", apiHost, repository, reference)
+
+	resp, err := client.get(ctx, manifestURL, "")
+	if err != nil && endpoint.Insecure {
+		// Insecure registries may not speak TLS at all, as with podman.
+		manifestURL = fmt.Sprintf("http://%s/v2/%s/manifests/%s", apiHost, repository, reference)
+		resp, err = client.get(ctx, manifestURL, "")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		_ = resp.Body.Close()
+
+		var credsPtr *registryCredentials
+		if hasCreds {
+			credsPtr = &creds
+		}
+
+		authorization, authErr := client.authorize(ctx, challenge, repository, credsPtr)
+		if authErr != nil {
+			return nil, authErr
+		}
+
+		resp, err = client.get(ctx, manifestURL, authorization)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: GET %s: %s", ErrRegistryResponse, manifestURL, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := &resolvedManifest{
+		Digest:    resp.Header.Get("Docker-Content-Digest"),
+		MediaType: resp.Header.Get("Content-Type"),
+	}
+
+	if err := json.Unmarshal(body, &resolved.Manifest); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrRegistryResponse, manifestURL, err)
+	}
+
+	sum := sha256.Sum256(body)
+	if resolved.Digest == "" {
+		resolved.Digest = "sha256:" + hex.EncodeToString(sum[:])
+	}
+
+	if err := verifyDigest(body, resolved.Digest); err != nil {
+		return nil, fmt.Errorf("%s: %w", manifestURL, err)
+	}
+
+	if strings.Contains(reference, ":") && reference != resolved.Digest {
+		if err := verifyDigest(body, reference); err != nil {
+			return nil, fmt.Errorf("%s: %w", manifestURL, err)
+		}
+	}
+
+	if resolved.Manifest.MediaType != "" {
+		resolved.MediaType = resolved.Manifest.MediaType
+	}
+
+	return resolved, nil
+}
+
+// insecure returns a copy of the client that skips TLS verification, for
+// registries registries.conf marks insecure.
+func (c *registryClient) insecure() *registryClient {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if base, ok := c.HTTP.Transport.(*http.Transport); ok {
+		transport = base.Clone()
+	}
+
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	transport.TLSClientConfig.InsecureSkipVerify = true
+
+	httpClient := *c.HTTP
+	httpClient.Transport = transport
+
+	insecure := *c
+	insecure.HTTP = &httpClient
+
+	return &insecure
+}
+
+// verifyDigest checks that body hashes to a sha256 or sha512 digest.
+func verifyDigest(body []byte, digest string) error {
+	algorithm, encoded, _ := strings.Cut(digest, ":")
+
+	var sum []byte
+
+	switch algorithm {
+	case "sha256":
+		digestSum := sha256.Sum256(body)
+		sum = digestSum[:]
+	case "sha512":
+		digestSum := sha512.Sum512(body)
+		sum = digestSum[:]
+	default:
+		return fmt.Errorf("%w: unsupported digest %q", ErrRegistryResponse, digest)
+	}
+
+	if hex.EncodeToString(sum) != encoded {
+		return fmt.Errorf("%w: manifest does not match digest %s", ErrRegistryResponse, digest)
+	}
+
+	return nil
+}
+
+func (c *registryClient) get(ctx context.Context, target, authorization string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", strings.Join([]string{
+		mediaTypeOCIIndex, mediaTypeOCIManifest, mediaTypeDockerList, mediaTypeDockerManifest,
+	}, ", "))
+
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	return c.HTTP.Do(req)
+}
+
+// authorize answers a WWW-Authenticate challenge, fetching a bearer token
+// when the registry asks for one.
+func (c *registryClient) authorize(
+	ctx context.Context,
+	challenge, repository string,
+	creds *registryCredentials,
+) (string, error) {
+	scheme, params := parseAuthChallenge(challenge)
+
+	if strings.EqualFold(scheme, registryAuthorizationBasic) {
+		if creds == nil {
+			return "", fmt.Errorf("%w: registry requires credentials", ErrRegistryResponse)
+		}
+
+		return basicAuthorization(creds), nil
+	}
+
+	realm := params["realm"]
+	if !strings.EqualFold(scheme, "Bearer") || realm == "" {
+		return "", fmt.Errorf("%w: unsupported challenge %q", ErrRegistryResponse, challenge)
+	}
+
+	tokenURL, err := url.Parse(realm)
+	if err != nil {
+		return "", err
+	}
+
+	query := tokenURL.Query()
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+
+	scope := params["scope"]
+	if scope == "" {
+		scope = "repository:" + repository + ":pull"
+	}
+
+	query.Set("scope", scope)
+	tokenURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL.String(), http.NoBody)
+	if err != nil {
+		return "", err
+	}
+
+	if creds != nil {
+		req.SetBasicAuth(creds.Username, creds.Password)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w: token request: %s", ErrRegistryResponse, resp.Status)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", err
+	}
+
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+
+	return "Bearer " + token.Token, nil
+}
+
+func basicAuthorization(creds *registryCredentials) string {
+	return registryAuthorizationBasic + " " +
+		base64.StdEncoding.EncodeToString([]byte(creds.Username+":"+creds.Password))
+}
+
+// parseAuthChallenge splits a WWW-Authenticate header into its scheme and
+// key="value" parameters.
+func parseAuthChallenge(header string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	params := map[string]string{}
+
+	for rest != "" {
+		var key, value string
+
+		key, rest, _ = strings.Cut(strings.TrimLeft(rest, " ,"), "=")
+		if strings.HasPrefix(rest, `"`) {
+			value, rest, _ = strings.Cut(rest[1:], `"`)
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+
+		if key != "" {
+			params[strings.ToLower(strings.TrimSpace(key))] = value
+		}
+	}
+
+	return scheme, params
+}
@@ -0,0 +1,453 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testManifestList = `{
+  "schemaVersion": 2,
+  "mediaType": "application/vnd.oci.image.index.v1+json",
+  "manifests": [
+    {
+      "mediaType": "application/vnd.oci.image.manifest.v1+json",
+      "digest": "sha256:1111111111111111111111111111111111111111111111111111111111111111",
+      "size": 1234,
+      "platform": {"architecture": "amd64", "os": "linux"}
+    },
+    {
+      "mediaType": "application/vnd.oci.image.manifest.v1+json",
+      "digest": "sha256:2222222222222222222222222222222222222222222222222222222222222222",
+      "size": 1235,
+      "platform": {"architecture": "arm64", "os": "linux", "variant": "v8"}
+    }
+  ],
+  "annotations": {"org.opencontainers.image.version": "42.20260301.0"}
+}`
+
+// newTestRegistry starts a registry:2-style stand-in that serves
+// testManifestList for repo:tag behind bearer-token auth.
+func newTestRegistry(t *testing.T, repo, tag, user, pass string) *httptest.Server {
+	t.Helper()
+
+	const token = "test-token"
+
+	mux := http.NewServeMux()
+
+	var server *httptest.Server
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		gotUser, gotPass, ok := r.BasicAuth()
+		if !ok || gotUser != user || gotPass != pass {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		if r.URL.Query().Get("scope") != "repository:"+repo+":pull" {
+			w.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		_, _ = fmt.Fprintf(w, `{"token": %q}`, token)
+	})
+
+	mux.HandleFunc("/v2/"+repo+"/manifests/"+tag, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(
+				`Bearer realm="%s/token",service="test-registry",scope="repository:%s:pull"`,
+				server.URL, repo))
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		if !strings.Contains(r.Header.Get("Accept"), mediaTypeOCIIndex) {
+			w.WriteHeader(http.StatusNotAcceptable)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", mediaTypeOCIIndex)
+		_, _ = w.Write([]byte(testManifestList))
+	})
+
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func writeTestAuthFile(t *testing.T, registry, user, pass string) string {
+	t.Helper()
+
+	authFile := filepath.Join(t.TempDir(), "auth.json")
+	encoded := base64.StdEncoding.EncodeToString([]byte(user + ":" + pass))
+
+	content := fmt.Sprintf(`{"auths": {%q: {"auth": %q}}}`, registry, encoded)
+	if err := os.WriteFile(authFile, []byte(content), testSecureFilePerms); err != nil {
+		t.Fatal(err)
+	}
+
+	return authFile
+}
+
+func TestParseImageReference(t *testing.T) {
+	tests := []struct {
+		name     string
+		ref      string
+		search   []string
+		wantName string
+		wantRef  string
+		wantErr  bool
+	}{
+		{"qualified_tag", testSourceImage, nil, "quay.io/fedora/fedora-bootc", "41", false},
+		{"no_tag", "quay.io/fedora/fedora-bootc", nil, "quay.io/fedora/fedora-bootc", "latest", false},
+		{
+			"digest",
+			"quay.io/fedora/fedora-bootc@sha256:00",
+			nil,
+			"quay.io/fedora/fedora-bootc",
+			"sha256:00",
+			false,
+		},
+		{"port", "localhost:5000/os:1", nil, "localhost:5000/os", "1", false},
+		{"localhost", "localhost/os", nil, "localhost/os", "latest", false},
+		{"docker_hub_library", "fedora:41", nil, "docker.io/library/fedora", "41", false},
+		{"docker_hub_user", "someone/os:1", nil, "docker.io/someone/os", "1", false},
+		{"search_registry", "fedora/fedora-bootc:42", []string{"quay.io"}, "quay.io/fedora/fedora-bootc", "42", false},
+		{"empty", "", nil, "", "", true},
+		{"trailing_slash", "quay.io/", nil, "", "", true},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			ref, err := parseImageReference(testCase.ref, testCase.search)
+			if testCase.wantErr {
+				if !errors.Is(err, ErrInvalidReference) {
+					t.Errorf("expected ErrInvalidReference, got %v", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if ref.Name() != testCase.wantName {
+				t.Errorf("Name() = %q, want %q", ref.Name(), testCase.wantName)
+			}
+
+			if ref.Reference() != testCase.wantRef {
+				t.Errorf("Reference() = %q, want %q", ref.Reference(), testCase.wantRef)
+			}
+		})
+	}
+}
+
+func TestRegistriesConfEndpoints(t *testing.T) {
+	confPath := filepath.Join(t.TempDir(), "registries.conf")
+
+	err := os.WriteFile(confPath, []byte(`
+unqualified-search-registries = ["registry.example.com"]
+
+[[registry]]
+prefix = "quay.io"
+location = "quay.io"
+
+[[registry.mirror]]
+location = "mirror.example.com/quay"
+insecure = true
+
+[[registry]]
+prefix = "quay.io/fedora"
+location = "internal.example.com/fedora"
+
+[[registry]]
+location = "blocked.example.com"
+blocked = true
+`), testSecureFilePerms)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf, err := loadRegistriesConf(confPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(conf.UnqualifiedSearchRegistries) != 1 {
+		t.Errorf("search registries = %v", conf.UnqualifiedSearchRegistries)
+	}
+
+	t.Run("longest_prefix", func(t *testing.T) {
+		endpoints, primary, err := conf.endpoints("quay.io/fedora/fedora-bootc")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if primary != "internal.example.com/fedora/fedora-bootc" || len(endpoints) != 1 {
+			t.Errorf("primary = %q, endpoints = %v", primary, endpoints)
+		}
+	})
+
+	t.Run("mirror_first", func(t *testing.T) {
+		endpoints, primary, err := conf.endpoints("quay.io/centos-bootc/centos-bootc")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(endpoints) != 2 {
+			t.Fatalf("endpoints = %v", endpoints)
+		}
+
+		if endpoints[0].Location != "mirror.example.com/quay/centos-bootc/centos-bootc" ||
+			!endpoints[0].Insecure {
+			t.Errorf("mirror = %+v", endpoints[0])
+		}
+
+		if primary != "quay.io/centos-bootc/centos-bootc" {
+			t.Errorf("primary = %q", primary)
+		}
+	})
+
+	t.Run("prefix_boundary", func(t *testing.T) {
+		_, primary, err := conf.endpoints("quay.io/fedorax/os")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if primary != "quay.io/fedorax/os" {
+			t.Errorf("primary = %q", primary)
+		}
+	})
+
+	t.Run("blocked", func(t *testing.T) {
+		_, _, err := conf.endpoints("blocked.example.com/os")
+		if !errors.Is(err, ErrRegistryBlocked) {
+			t.Errorf("expected ErrRegistryBlocked, got %v", err)
+		}
+	})
+
+	t.Run("unmatched", func(t *testing.T) {
+		endpoints, primary, err := conf.endpoints("ghcr.io/org/os")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if primary != "ghcr.io/org/os" || len(endpoints) != 1 || endpoints[0].Insecure {
+			t.Errorf("primary = %q, endpoints = %v", primary, endpoints)
+		}
+	})
+}
+
+func TestLookupCredentials(t *testing.T) {
+	authFile := writeTestAuthFile(t, "quay.io", "robot", "s3cret")
+	missing := filepath.Join(t.TempDir(), "missing.json")
+
+	creds, ok, err := lookupCredentials([]string{missing, authFile}, "quay.io/fedora/fedora-bootc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !ok || creds.Username != "robot" || creds.Password != "s3cret" {
+		t.Errorf("creds = %+v, ok = %v", creds, ok)
+	}
+
+	_, ok, err = lookupCredentials([]string{authFile}, "ghcr.io/org/os")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ok {
+		t.Error("expected no credentials for unknown registry")
+	}
+}
+
+func TestParseAuthChallenge(t *testing.T) {
+	scheme, params := parseAuthChallenge(
+		`Bearer realm="https://auth.example.com/token",service="registry.example.com",scope="repository:os:pull"`)
+
+	if scheme != "Bearer" {
+		t.Errorf("scheme = %q", scheme)
+	}
+
+	want := map[string]string{
+		"realm":   "https://auth.example.com/token",
+		"service": "registry.example.com",
+		"scope":   "repository:os:pull",
+	}
+	for key, value := range want {
+		if params[key] != value {
+			t.Errorf("%s = %q, want %q", key, params[key], value)
+		}
+	}
+}
+
+func TestRegistryClient_Resolve(t *testing.T) {
+	const (
+		repo = "fedora/fedora-bootc"
+		tag  = "42"
+	)
+
+	server := newTestRegistry(t, repo, tag, "robot", "s3cret")
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	conf := &registriesConf{Registries: []registryConfItem{{Location: host, Insecure: true}}}
+
+	ref, err := parseImageReference(host+"/"+repo+":"+tag, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("authenticated", func(t *testing.T) {
+		client := &registryClient{
+			HTTP:      server.Client(),
+			Conf:      conf,
+			AuthFiles: []string{writeTestAuthFile(t, host, "robot", "s3cret")},
+		}
+
+		resolved, err := client.Resolve(t.Context(), ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sum := sha256.Sum256([]byte(testManifestList))
+		if want := "sha256:" + hex.EncodeToString(sum[:]); resolved.Digest != want {
+			t.Errorf("digest = %q, want %q", resolved.Digest, want)
+		}
+
+		if resolved.MediaType != mediaTypeOCIIndex {
+			t.Errorf("media type = %q", resolved.MediaType)
+		}
+
+		if len(resolved.Manifest.Manifests) != 2 {
+			t.Fatalf("manifests = %d, want 2", len(resolved.Manifest.Manifests))
+		}
+
+		if got := resolved.Manifest.Manifests[1].Platform.String(); got != "linux/arm64/v8" {
+			t.Errorf("platform = %q", got)
+		}
+
+		if resolved.Manifest.Annotations["org.opencontainers.image.version"] != "42.20260301.0" {
+			t.Errorf("annotations = %v", resolved.Manifest.Annotations)
+		}
+	})
+
+	t.Run("wrong_credentials", func(t *testing.T) {
+		client := &registryClient{
+			HTTP:      server.Client(),
+			Conf:      conf,
+			AuthFiles: []string{writeTestAuthFile(t, host, "robot", "wrong")},
+		}
+
+		if _, err := client.Resolve(t.Context(), ref); !errors.Is(err, ErrRegistryResponse) {
+			t.Errorf("expected ErrRegistryResponse, got %v", err)
+		}
+	})
+
+	t.Run("unknown_tag", func(t *testing.T) {
+		client := &registryClient{
+			HTTP:      server.Client(),
+			Conf:      conf,
+			AuthFiles: []string{writeTestAuthFile(t, host, "robot", "s3cret")},
+		}
+
+		missing := ref
+		missing.Tag = "missing"
+
+		if _, err := client.Resolve(t.Context(), missing); !errors.Is(err, ErrRegistryResponse) {
+			t.Errorf("expected ErrRegistryResponse, got %v", err)
+		}
+	})
+}
+
+func TestRegistryClient_ResolveInsecureTLS(t *testing.T) {
+	const repo = "fedora/fedora-bootc"
+
+	sum := sha256.Sum256([]byte(testManifestList))
+	digests := map[string]string{
+		"42":       "sha256:" + hex.EncodeToString(sum[:]),
+		"tampered": "sha256:" + strings.Repeat("0", 64),
+	}
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", mediaTypeOCIIndex)
+		w.Header().Set("Docker-Content-Digest", digests[r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]])
+		_, _ = w.Write([]byte(testManifestList))
+	}))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "https://")
+
+	tests := []struct {
+		name     string
+		tag      string
+		insecure bool
+		wantErr  bool
+	}{
+		{"self_signed_insecure", "42", true, false},
+		{"self_signed_verified", "42", false, true},
+		{"digest_mismatch", "tampered", true, true},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			ref, err := parseImageReference(host+"/"+repo+":"+testCase.tag, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			client := &registryClient{
+				HTTP: &http.Client{},
+				Conf: &registriesConf{Registries: []registryConfItem{{Location: host, Insecure: testCase.insecure}}},
+			}
+
+			resolved, err := client.Resolve(t.Context(), ref)
+			if testCase.wantErr {
+				if err == nil {
+					t.Errorf("Resolve() = %q, want an error", resolved.Digest)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resolved.Digest != digests[testCase.tag] {
+				t.Errorf("digest = %q, want %q", resolved.Digest, digests[testCase.tag])
+			}
+		})
+	}
+}
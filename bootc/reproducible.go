@@ -0,0 +1,590 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	sourceDateEpochEnv = "SOURCE_DATE_EPOCH"
+
+	// maxRewriteBytes bounds the files scanned for UUID references; kernels
+	// and initramfs images on /boot are skipped.
+	maxRewriteBytes = 1 << 20
+
+	fatBPBBytesPerSector  = 11
+	fatBPBSectorsPerFAT16 = 22
+	fat32BackupBootSector = 50
+	fat16SerialOffset     = 39
+	fat32SerialOffset     = 67
+
+	// vhdFooterSize, vhdTimestampEpoch and the footer offsets describe the
+	// footer at the end of a fixed VHD.
+	vhdFooterSize      = 512
+	vhdTimestampEpoch  = 946684800
+	vhdTimestampOffset = 24
+	vhdChecksumOffset  = 64
+	vhdUUIDOffset      = 68
+
+	// vmdkDescriptorBytes bounds the search for the embedded descriptor
+	// of a stream-optimized VMDK, which starts in its second sector.
+	vmdkDescriptorBytes = 64 << 10
+)
+
+// e2fsckCorrected is e2fsck's exit status when it fixed minor issues, such
+// as a stale free-block count, without user intervention.
+const e2fsckCorrected = 1
+
+var (
+	ErrMissingVMDKCID        = errors.New("VMDK descriptor has no CID")
+	ErrUnsupportedFilesystem = errors.New("unsupported filesystem")
+	ErrSourceDateEpoch       = errors.New("source_date_epoch is not set")
+)
+
+// ReproducibleModel is the reproducible block of bootc_image.
+type ReproducibleModel struct {
+	Seed            types.String `tfsdk:"seed"`
+	SourceDateEpoch types.Int64  `tfsdk:"source_date_epoch"`
+}
+
+// reproducibleBuild is the seed and epoch of a reproducible build.
+type reproducibleBuild struct {
+	Seed  string
+	Epoch int64
+}
+
+// reproducibleBuildKey is the context key of the reproducible build whose
+// converted disks are pinned.
+type reproducibleBuildKey struct{}
+
+// withReproducibleBuild returns a context whose convertDisk calls replace
+// the random identifiers qemu-img writes with ones derived from the seed.
+func withReproducibleBuild(ctx context.Context, seed string, epoch int64) context.Context {
+	return context.WithValue(ctx, reproducibleBuildKey{}, reproducibleBuild{Seed: seed, Epoch: epoch})
+}
+
+// resolveSourceDateEpoch returns the configured epoch, falling back to the
+// SOURCE_DATE_EPOCH environment variable.
+func resolveSourceDateEpoch(configured types.Int64) (int64, error) {
+	if !configured.IsNull() && !configured.IsUnknown() {
+		return configured.ValueInt64(), nil
+	}
+
+	env := os.Getenv(sourceDateEpochEnv)
+	if env == "" {
+		return 0, fmt.Errorf("%w: set reproducible.source_date_epoch or export %s", ErrSourceDateEpoch, sourceDateEpochEnv)
+	}
+
+	epoch, err := strconv.ParseInt(env, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", sourceDateEpochEnv, err)
+	}
+
+	return epoch, nil
+}
+
+// validateReproducible rejects output formats whose identifiers the
+// reproducible block cannot pin.
+func validateReproducible(data *ImageResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if data.Reproducible != nil && !data.OutputFormat.IsUnknown() && data.outputFormat() == formatVHDX {
+		diags.AddAttributeError(path.Root("output_format"), "Conflicting reproducible options",
+			"qemu-img writes random GUIDs into the checksummed VHDX headers, so vhdx cannot be reproducible. "+
+				"Use vhd or qcow2 instead.")
+	}
+
+	return diags
+}
+
+// reproducibleEnv returns the KEY=value entries that pin build timestamps
+// for bootc, ostree and the mkfs tools it runs.
+func reproducibleEnv(epoch int64) []string {
+	value := strconv.FormatInt(epoch, 10)
+
+	return []string{sourceDateEpochEnv + "=" + value, "E2FSPROGS_FAKE_TIME=" + value}
+}
+
+// deriveUUID derives a random-looking but stable version 4 UUID from the
+// seed and a purpose string.
+func deriveUUID(seed, purpose string) string {
+	sum := sha256.Sum256([]byte(seed + "\x00" + purpose))
+	sum[6] = (sum[6] & 0x0f) | 0x40
+	sum[8] = (sum[8] & 0x3f) | 0x80
+
+	h := hex.EncodeToString(sum[:16])
+
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
+
+// deriveFATSerial derives a stable FAT volume serial from the seed. MBR
+// disk identifiers have the same 32-bit form.
+func deriveFATSerial(seed, purpose string) uint32 {
+	sum := sha256.Sum256([]byte(seed + "\x00" + purpose))
+
+	return binary.LittleEndian.Uint32(sum[:4])
+}
+
+// formatFATSerial renders a FAT volume serial the way blkid reports it.
+func formatFATSerial(serial uint32) string {
+	return fmt.Sprintf("%04X-%04X", serial>>16, serial&0xffff)
+}
+
+// makeReproducible replaces every GUID, MBR disk identifier and filesystem
+// UUID on an installed raw disk with values derived from seed. References
+// to the old UUIDs in the boot configuration and fstab are rewritten and
+// stamped with the epoch first, so no filesystem is mounted read-write once
+// its identifiers are set. It returns the old→new identifiers.
+func makeReproducible(ctx context.Context, rawPath, seed string, epoch int64) (map[string]string, error) {
+	partitions, err := readInstalledPartitions(ctx, rawPath)
+	if err != nil {
+		return nil, err
+	}
+
+	replacements := derivedIdentifiers(partitions, seed)
+
+	mounts, err := newDiskMounts(rawPath)
+	if err != nil {
+		return nil, err
+	}
+
+	err = rewriteUUIDReferences(ctx, mounts, partitions, replacements, time.Unix(epoch, 0))
+	if err = errors.Join(err, mounts.Close(ctx)); err != nil {
+		return nil, err
+	}
+
+	mounts, err = newDiskMounts(rawPath)
+	if err != nil {
+		return nil, err
+	}
+
+	err = reassignFilesystemUUIDs(ctx, mounts, partitions, seed, epoch)
+	if err = errors.Join(err, mounts.Close(ctx)); err != nil {
+		return nil, err
+	}
+
+	return replacements, rewriteDiskIdentifiers(rawPath, partitions, seed)
+}
+
+// derivedIdentifiers maps the UUID or FAT serial of each filesystem to the
+// one derived from seed.
+func derivedIdentifiers(partitions []installedPartition, seed string) map[string]string {
+	replacements := map[string]string{}
+
+	for _, part := range partitions {
+		purpose := "filesystem-" + strconv.Itoa(part.Number)
+
+		switch {
+		case part.UUID == "" || part.Filesystem == "":
+			// Nothing refers to the partition by UUID.
+		case part.Filesystem == "vfat":
+			replacements[part.UUID] = formatFATSerial(deriveFATSerial(seed, purpose))
+		default:
+			replacements[part.UUID] = deriveUUID(seed, purpose)
+		}
+	}
+
+	return replacements
+}
+
+// rewriteDiskIdentifiers patches the derived GPT GUIDs or MBR disk
+// identifier and the FAT volume serials into the raw disk.
+func rewriteDiskIdentifiers(rawPath string, partitions []installedPartition, seed string) (err error) {
+	disk, err := os.OpenFile(rawPath, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if closeErr := disk.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	partGUIDs := make(map[int]string, len(partitions))
+	for _, part := range partitions {
+		partGUIDs[part.Number] = deriveUUID(seed, "gpt-partition-"+strconv.Itoa(part.Number))
+	}
+
+	tableType, err := detectPartitionTable(disk)
+	if err == nil && tableType == partitionTableMBR {
+		err = setMBRDiskID(disk, deriveFATSerial(seed, "mbr-disk"))
+	} else if err == nil {
+		err = rewriteGPTGUIDs(disk, deriveUUID(seed, "gpt-disk"), partGUIDs)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	for _, part := range partitions {
+		if part.Filesystem != "vfat" {
+			continue
+		}
+
+		err = setFATSerial(disk, part.Start, deriveFATSerial(seed, "filesystem-"+strconv.Itoa(part.Number)))
+		if err != nil {
+			return fmt.Errorf("partition %d: %w", part.Number, err)
+		}
+	}
+
+	return nil
+}
+
+// reassignFilesystemUUIDs sets the derived UUIDs, and for ext filesystems a
+// derived directory hash seed, on the unmounted Linux filesystems of the
+// disk.
+func reassignFilesystemUUIDs(
+	ctx context.Context,
+	mounts *diskMounts,
+	partitions []installedPartition,
+	seed string,
+	epoch int64,
+) error {
+	env := reproducibleEnv(epoch)
+
+	for _, part := range partitions {
+		if part.Filesystem == "" || part.Filesystem == "vfat" {
+			continue
+		}
+
+		dev, err := mounts.Attach(ctx, part)
+		if err != nil {
+			return err
+		}
+
+		uuid := deriveUUID(seed, "filesystem-"+strconv.Itoa(part.Number))
+		hashSeed := deriveUUID(seed, "hash-seed-"+strconv.Itoa(part.Number))
+
+		err = setFilesystemUUID(ctx, env, part.Filesystem, dev, uuid, hashSeed)
+		if err != nil {
+			return fmt.Errorf("partition %d: %w", part.Number, err)
+		}
+	}
+
+	return nil
+}
+
+// setFilesystemUUID sets the UUID of a filesystem. The hash seed of ext
+// filesystems is set too, and their directory indexes are rebuilt with it.
+func setFilesystemUUID(ctx context.Context, env []string, fsType, dev, uuid, hashSeed string) error {
+	var err error
+
+	switch fsType {
+	case "xfs":
+		_, err = runCommandEnv(ctx, env, "xfs_admin", "-U", uuid, dev)
+	case "ext2", "ext3", "ext4":
+		err = e2fsck(ctx, env, "-f", "-p", dev)
+		if err == nil {
+			_, err = runCommandEnv(ctx, env, "tune2fs", "-U", uuid, "-E", "hash_seed="+hashSeed, dev)
+		}
+
+		if err == nil {
+			err = e2fsck(ctx, env, "-f", "-y", "-D", dev)
+		}
+	case "btrfs":
+		_, err = runCommandEnv(ctx, env, "btrfstune", "-f", "-U", uuid, dev)
+	case "swap":
+		_, err = runCommandEnv(ctx, env, "swaplabel", "-U", uuid, dev)
+	default:
+		err = fmt.Errorf("%w: %s", ErrUnsupportedFilesystem, fsType)
+	}
+
+	return err
+}
+
+// e2fsck runs e2fsck, accepting the exit status of fixed minor issues.
+func e2fsck(ctx context.Context, env []string, args ...string) error {
+	_, err := runCommandEnv(ctx, env, "e2fsck", args...)
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == e2fsckCorrected {
+		return nil
+	}
+
+	return err
+}
+
+// setFATSerial patches the volume serial in the FAT boot sector at offset
+// and, for FAT32, in its backup boot sector.
+func setFATSerial(disk readWriterAt, offset int64, serial uint32) error {
+	bpb := make([]byte, 512)
+	if _, err := disk.ReadAt(bpb, offset); err != nil {
+		return err
+	}
+
+	value := make([]byte, 4)
+	binary.LittleEndian.PutUint32(value, serial)
+
+	if binary.LittleEndian.Uint16(bpb[fatBPBSectorsPerFAT16:]) != 0 {
+		_, err := disk.WriteAt(value, offset+fat16SerialOffset)
+
+		return err
+	}
+
+	bootSectors := []int64{offset}
+
+	backup := binary.LittleEndian.Uint16(bpb[fat32BackupBootSector:])
+	if backup != 0 && backup != 0xffff {
+		bootSectors = append(bootSectors,
+			offset+int64(backup)*int64(binary.LittleEndian.Uint16(bpb[fatBPBBytesPerSector:])))
+	}
+
+	for _, bootSector := range bootSectors {
+		if _, err := disk.WriteAt(value, bootSector+fat32SerialOffset); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rewriteUUIDReferences mounts the boot, ESP and root filesystems and
+// replaces old UUIDs in the bootloader configuration, BLS entries and the
+// deployments' /etc/fstab. Without a separate boot partition the boot
+// configuration lives in /boot of the root filesystem.
+func rewriteUUIDReferences(
+	ctx context.Context,
+	mounts *diskMounts,
+	partitions []installedPartition,
+	replacements map[string]string,
+	mtime time.Time,
+) error {
+	for _, part := range partitions {
+		if part.Filesystem == "" || part.Filesystem == "swap" {
+			continue
+		}
+
+		root, err := mounts.Mount(ctx, part)
+		if err != nil {
+			return fmt.Errorf("partition %d: %w", part.Number, err)
+		}
+
+		if !part.IsRoot() {
+			err = replaceInTextFiles(root, replacements, mtime)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		err = replaceInTextFiles(filepath.Join(root, "boot"), replacements, mtime)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+
+		fstabs, err := filepath.Glob(filepath.Join(root, "ostree", "deploy", "*", "deploy", "*", "etc", "fstab"))
+		if err != nil {
+			return err
+		}
+
+		for _, fstab := range fstabs {
+			err = replaceInFile(fstab, replacements, mtime)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// replaceInTextFiles applies replaceInFile to every small regular file
+// below root.
+func replaceInTextFiles(root string, replacements map[string]string, mtime time.Time) error {
+	return filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil || info.Size() > maxRewriteBytes {
+			return err
+		}
+
+		return replaceInFile(path, replacements, mtime)
+	})
+}
+
+// replaceInFile substitutes old→new strings in a text file, leaving binary
+// files and files without matches untouched.
+func replaceInFile(path string, replacements map[string]string, mtime time.Time) error {
+	content, err := os.ReadFile(path)
+	if err != nil || bytes.IndexByte(content, 0) >= 0 {
+		return err
+	}
+
+	updated := replaceUUIDs(string(content), replacements)
+	if updated == string(content) {
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(path, []byte(updated), info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	return os.Chtimes(path, mtime, mtime)
+}
+
+// replaceUUIDs substitutes old→new identifiers in text.
+func replaceUUIDs(text string, replacements map[string]string) string {
+	for old, replacement := range replacements {
+		if old != "" {
+			text = strings.ReplaceAll(text, old, replacement)
+		}
+	}
+
+	return text
+}
+
+// pinImageIdentifiers replaces the identifiers qemu-img convert takes from
+// the clock or the random generator: the CID of a VMDK, and the timestamp
+// and unique ID in the footer of a fixed VHD. qcow2 and raw images carry
+// none.
+func pinImageIdentifiers(imagePath, format string, build reproducibleBuild) error {
+	switch format {
+	case formatVMDK:
+		return pinVMDKCID(imagePath, deriveFATSerial(build.Seed, "vmdk-cid"))
+	case formatVHD:
+		guid, err := parseGUID(deriveUUID(build.Seed, "vhd-unique-id"))
+		if err != nil {
+			return err
+		}
+
+		return pinVHDFooter(imagePath, uint32(max(build.Epoch-vhdTimestampEpoch, 0)), guid)
+	default:
+		return nil
+	}
+}
+
+// pinVMDKCID overwrites the CID of the embedded VMDK descriptor in place,
+// keeping its length so the descriptor does not move.
+func pinVMDKCID(imagePath string, cid uint32) (err error) {
+	file, err := os.OpenFile(imagePath, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	head := make([]byte, vmdkDescriptorBytes)
+
+	n, err := file.ReadAt(head, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	start := bytes.Index(head[:n], []byte("\nCID="))
+	if start < 0 {
+		return ErrMissingVMDKCID
+	}
+
+	start += len("\nCID=")
+
+	end := start
+	for end < n && strings.IndexByte("0123456789abcdefABCDEF", head[end]) >= 0 {
+		end++
+	}
+
+	if end == start {
+		return ErrMissingVMDKCID
+	}
+
+	digits := fmt.Sprintf("%08x", cid)
+	_, err = file.WriteAt([]byte(digits[len(digits)-min(end-start, len(digits)):]), int64(start))
+
+	return err
+}
+
+// pinVHDFooter sets the timestamp and unique ID of the footer at the end of
+// a fixed VHD and updates its checksum.
+func pinVHDFooter(imagePath string, timestamp uint32, uniqueID []byte) (err error) {
+	file, err := os.OpenFile(imagePath, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	offset := info.Size() - vhdFooterSize
+	footer := make([]byte, vhdFooterSize)
+
+	if _, err := file.ReadAt(footer, offset); err != nil {
+		return err
+	}
+
+	binary.BigEndian.PutUint32(footer[vhdTimestampOffset:], timestamp)
+	copy(footer[vhdUUIDOffset:vhdUUIDOffset+16], uniqueID)
+	binary.BigEndian.PutUint32(footer[vhdChecksumOffset:], vhdChecksum(footer))
+
+	_, err = file.WriteAt(footer, offset)
+
+	return err
+}
+
+// vhdChecksum is the one's complement of the byte sum of a VHD footer,
+// without its checksum field.
+func vhdChecksum(footer []byte) uint32 {
+	var sum uint32
+
+	for idx, b := range footer {
+		if idx < vhdChecksumOffset || idx >= vhdChecksumOffset+4 {
+			sum += uint32(b)
+		}
+	}
+
+	return ^sum
+}
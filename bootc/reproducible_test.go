@@ -0,0 +1,368 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const testSeed = "release-2026.10"
+
+func TestDeriveUUID(t *testing.T) {
+	uuidPattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	first := deriveUUID(testSeed, "gpt-disk")
+	if !uuidPattern.MatchString(first) {
+		t.Errorf("deriveUUID = %q, not a version 4 UUID", first)
+	}
+
+	if again := deriveUUID(testSeed, "gpt-disk"); again != first {
+		t.Errorf("deriveUUID not stable: %q != %q", again, first)
+	}
+
+	for _, other := range []string{
+		deriveUUID(testSeed, "gpt-partition-1"),
+		deriveUUID("other-seed", "gpt-disk"),
+	} {
+		if other == first {
+			t.Errorf("deriveUUID collision: %q", other)
+		}
+	}
+
+	if _, err := parseGUID(first); err != nil {
+		t.Errorf("derived UUID is not a valid GUID: %v", err)
+	}
+}
+
+func TestSetFATSerial(t *testing.T) {
+	tests := []struct {
+		name          string
+		sectorsPerFAT uint16
+		backupSector  uint16
+		wantOffsets   []int
+	}{
+		{"fat16", 32, 0, []int{fat16SerialOffset}},
+		{"fat32", 0, 0, []int{fat32SerialOffset}},
+		{"fat32_backup", 0, 6, []int{fat32SerialOffset, 6*512 + fat32SerialOffset}},
+	}
+
+	const partOffset = 1024
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			rawPath := filepath.Join(t.TempDir(), "esp.raw")
+			raw := make([]byte, partOffset+8*512)
+			binary.LittleEndian.PutUint16(raw[partOffset+fatBPBBytesPerSector:], 512)
+			binary.LittleEndian.PutUint16(raw[partOffset+fatBPBSectorsPerFAT16:], testCase.sectorsPerFAT)
+			binary.LittleEndian.PutUint16(raw[partOffset+fat32BackupBootSector:], testCase.backupSector)
+
+			if err := os.WriteFile(rawPath, raw, testSecureFilePerms); err != nil {
+				t.Fatal(err)
+			}
+
+			disk, err := os.OpenFile(rawPath, os.O_RDWR, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer disk.Close()
+
+			if err := setFATSerial(disk, partOffset, 0x1234ABCD); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := os.ReadFile(rawPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, offset := range testCase.wantOffsets {
+				serial := binary.LittleEndian.Uint32(got[partOffset+offset:])
+				if serial != 0x1234ABCD {
+					t.Errorf("serial at %d = %08X, want 1234ABCD", offset, serial)
+				}
+			}
+		})
+	}
+
+	if got := formatFATSerial(0x1234ABCD); got != "1234-ABCD" {
+		t.Errorf("formatFATSerial = %q, want 1234-ABCD", got)
+	}
+}
+
+func TestReplaceInTextFiles(t *testing.T) {
+	root := t.TempDir()
+	mtime := time.Unix(1700000000, 0)
+	replacements := map[string]string{
+		"0b7c6a1e-0000-4000-8000-000000000001": "new-root",
+		"ABCD-1234":                            "BEEF-0001",
+	}
+
+	entry := filepath.Join(root, "loader", "entries", "ostree-1.conf")
+	binaryFile := filepath.Join(root, "vmlinuz")
+	untouched := filepath.Join(root, "grub.cfg")
+
+	files := map[string]string{
+		entry:      "options root=UUID=0b7c6a1e-0000-4000-8000-000000000001 rw\n",
+		binaryFile: "\x00ABCD-1234",
+		untouched:  "set timeout=1\n",
+	}
+
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(name), testDefaultDirPerms); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(name, []byte(content), testSecureFilePerms); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := replaceInTextFiles(root, replacements, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(got) != "options root=UUID=new-root rw\n" {
+		t.Errorf("entry = %q", got)
+	}
+
+	info, err := os.Stat(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !info.ModTime().Equal(mtime) {
+		t.Errorf("mtime = %v, want %v", info.ModTime(), mtime)
+	}
+
+	if got, _ := os.ReadFile(binaryFile); string(got) != files[binaryFile] {
+		t.Errorf("binary file was rewritten: %q", got)
+	}
+
+	if info, _ := os.Stat(untouched); info.ModTime().Equal(mtime) {
+		t.Error("file without matches should keep its mtime")
+	}
+}
+
+func TestResolveSourceDateEpoch(t *testing.T) {
+	t.Setenv(sourceDateEpochEnv, "")
+
+	if _, err := resolveSourceDateEpoch(types.Int64Null()); !errors.Is(err, ErrSourceDateEpoch) {
+		t.Errorf("expected ErrSourceDateEpoch, got %v", err)
+	}
+
+	t.Setenv(sourceDateEpochEnv, "1700000000")
+
+	got, err := resolveSourceDateEpoch(types.Int64Null())
+	if err != nil || got != 1700000000 {
+		t.Errorf("from env = %d, %v", got, err)
+	}
+
+	got, err = resolveSourceDateEpoch(types.Int64Value(42))
+	if err != nil || got != 42 {
+		t.Errorf("configured = %d, %v", got, err)
+	}
+
+	t.Setenv(sourceDateEpochEnv, "yesterday")
+
+	if _, err := resolveSourceDateEpoch(types.Int64Null()); err == nil {
+		t.Error("expected error for malformed SOURCE_DATE_EPOCH")
+	}
+}
+
+func TestReproducibleEnv(t *testing.T) {
+	want := []string{"SOURCE_DATE_EPOCH=1700000000", "E2FSPROGS_FAKE_TIME=1700000000"}
+	if got := reproducibleEnv(1700000000); !slices.Equal(got, want) {
+		t.Errorf("reproducibleEnv() = %q, want %q", got, want)
+	}
+}
+
+func TestWithCommandEnv(t *testing.T) {
+	t.Setenv(sourceDateEpochEnv, "1")
+
+	ctx := withCommandEnv(t.Context(), reproducibleEnv(1700000000))
+
+	out, err := runCommandEnv(ctx, []string{"E2FSPROGS_FAKE_TIME=42"}, "sh", "-c", "echo $SOURCE_DATE_EPOCH $E2FSPROGS_FAKE_TIME")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.TrimSpace(string(out)); got != "1700000000 42" {
+		t.Errorf("command environment = %q, want the context entries overridden by the explicit ones", got)
+	}
+
+	if got := os.Getenv(sourceDateEpochEnv); got != "1" {
+		t.Errorf("%s of the provider process = %q, want it untouched", sourceDateEpochEnv, got)
+	}
+
+	out, err = runCommand(t.Context(), "sh", "-c", "echo $SOURCE_DATE_EPOCH")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.TrimSpace(string(out)); got != "1" {
+		t.Errorf("command without the context environment saw %s = %q", sourceDateEpochEnv, got)
+	}
+}
+
+func TestDerivedIdentifiers(t *testing.T) {
+	partitions := []installedPartition{
+		{Number: 1},
+		{Number: 2, Filesystem: "vfat", UUID: "ABCD-1234"},
+		{Number: 3, Filesystem: "xfs", UUID: "0b7c6a1e-0000-4000-8000-000000000001"},
+		{Number: 4, Filesystem: "swap", UUID: "0b7c6a1e-0000-4000-8000-000000000002"},
+	}
+
+	want := map[string]string{
+		"ABCD-1234":                            formatFATSerial(deriveFATSerial(testSeed, "filesystem-2")),
+		"0b7c6a1e-0000-4000-8000-000000000001": deriveUUID(testSeed, "filesystem-3"),
+		"0b7c6a1e-0000-4000-8000-000000000002": deriveUUID(testSeed, "filesystem-4"),
+	}
+
+	if got := derivedIdentifiers(partitions, testSeed); !maps.Equal(got, want) {
+		t.Errorf("derivedIdentifiers() = %v, want %v", got, want)
+	}
+
+	if got := replaceUUIDs("root=UUID=0b7c6a1e-0000-4000-8000-000000000001 rw", want); got !=
+		"root=UUID="+deriveUUID(testSeed, "filesystem-3")+" rw" {
+		t.Errorf("replaceUUIDs() = %q", got)
+	}
+}
+
+func TestPinImageIdentifiers(t *testing.T) {
+	build := reproducibleBuild{Seed: testSeed, Epoch: 1700000000}
+
+	t.Run("vmdk", func(t *testing.T) {
+		imagePath := filepath.Join(t.TempDir(), "disk.vmdk")
+		descriptor := "# Disk DescriptorFile\nversion=1\nCID=1a2b3c\nparentCID=ffffffff\n"
+		image := append(make([]byte, 512), descriptor...)
+
+		if err := os.WriteFile(imagePath, image, testSecureFilePerms); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := pinImageIdentifiers(imagePath, formatVMDK, build); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := os.ReadFile(imagePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		digits := fmt.Sprintf("%08x", deriveFATSerial(testSeed, "vmdk-cid"))
+		want := strings.Replace(descriptor, "CID=1a2b3c", "CID="+digits[2:], 1)
+
+		if string(got[512:]) != want || len(got) != len(image) {
+			t.Errorf("descriptor = %q, want %q", got[512:], want)
+		}
+
+		if err := pinImageIdentifiers(filepath.Join(t.TempDir(), "empty.vmdk"), formatVMDK, build); err == nil {
+			t.Error("expected an error for a missing image")
+		}
+	})
+
+	t.Run("vhd", func(t *testing.T) {
+		imagePath := filepath.Join(t.TempDir(), "disk.vhd")
+		image := make([]byte, 4096+vhdFooterSize)
+		copy(image[4096:], "conectix")
+		binary.BigEndian.PutUint32(image[4096+vhdTimestampOffset:], 0x2ead5a6b)
+
+		if err := os.WriteFile(imagePath, image, testSecureFilePerms); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := pinImageIdentifiers(imagePath, formatVHD, build); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := os.ReadFile(imagePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		footer := got[4096:]
+
+		if stamp := binary.BigEndian.Uint32(footer[vhdTimestampOffset:]); stamp != 1700000000-vhdTimestampEpoch {
+			t.Errorf("timestamp = %d", stamp)
+		}
+
+		wantID, _ := parseGUID(deriveUUID(testSeed, "vhd-unique-id"))
+		if !slices.Equal(footer[vhdUUIDOffset:vhdUUIDOffset+16], wantID) {
+			t.Errorf("unique ID = %x, want %x", footer[vhdUUIDOffset:vhdUUIDOffset+16], wantID)
+		}
+
+		if checksum := binary.BigEndian.Uint32(footer[vhdChecksumOffset:]); checksum != vhdChecksum(footer) {
+			t.Errorf("checksum = %08x, want %08x", checksum, vhdChecksum(footer))
+		}
+	})
+
+	t.Run("qcow2", func(t *testing.T) {
+		if err := pinImageIdentifiers(filepath.Join(t.TempDir(), "missing.qcow2"), formatQCOW2, build); err != nil {
+			t.Errorf("qcow2 should be left alone, got %v", err)
+		}
+	})
+}
+
+func TestValidateReproducible(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    ImageResourceModel
+		wantErr string
+	}{
+		{"qcow2", ImageResourceModel{Reproducible: &ReproducibleModel{}, OutputFormat: types.StringNull()}, ""},
+		{"vhdx", ImageResourceModel{Reproducible: &ReproducibleModel{}, OutputFormat: types.StringValue(formatVHDX)},
+			"Conflicting reproducible options"},
+		{"vhdx_not_reproducible", ImageResourceModel{OutputFormat: types.StringValue(formatVHDX)}, ""},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			diags := validateReproducible(&testCase.data)
+
+			if testCase.wantErr == "" {
+				if diags.HasError() {
+					t.Errorf("unexpected errors: %v", diags.Errors())
+				}
+
+				return
+			}
+
+			if !diags.HasError() || diags.Errors()[0].Summary() != testCase.wantErr {
+				t.Errorf("expected %q, got %v", testCase.wantErr, diags.Errors())
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	seedFormatISO9660 = "iso9660"
+	seedFormatVFAT    = "vfat"
+)
+
+var _ resource.Resource = &CloudInitSeedResource{}
+
+// CloudInitSeedResource implements the bootc_cloudinit_seed Terraform
+// resource.
+type CloudInitSeedResource struct{}
+
+type CloudInitSeedResourceModel struct {
+	OutputPath     types.String `tfsdk:"output_path"`
+	OutputFilename types.String `tfsdk:"output_filename"`
+	Format         types.String `tfsdk:"format"`
+	UserData       types.String `tfsdk:"user_data"`
+	MetaData       types.String `tfsdk:"meta_data"`
+	NetworkConfig  types.String `tfsdk:"network_config"`
+	VendorData     types.String `tfsdk:"vendor_data"`
+	Path           types.String `tfsdk:"path"`
+	SHA256         types.String `tfsdk:"sha256"`
+}
+
+func NewCloudInitSeedResource() resource.Resource {
+	return &CloudInitSeedResource{}
+}
+
+func (*CloudInitSeedResource) Metadata(
+	_ context.Context,
+	req resource.MetadataRequest,
+	resp *resource.MetadataResponse,
+) {
+	resp.TypeName = req.ProviderTypeName + "_cloudinit_seed"
+}
+
+func (*CloudInitSeedResource) Schema(
+	_ context.Context,
+	_ resource.SchemaRequest,
+	resp *resource.SchemaResponse,
+) {
+	replace := []planmodifier.String{stringplanmodifier.RequiresReplace()}
+
+	resp.Schema = schema.Schema{
+		Description: "Writes a cloud-init NoCloud seed image (volume label cidata) to attach next to a disk image.",
+		Attributes: map[string]schema.Attribute{
+			"output_path": schema.StringAttribute{
+				Description:   "Directory where the seed image will be written.",
+				Required:      true,
+				PlanModifiers: replace,
+			},
+			"output_filename": schema.StringAttribute{
+				Description:   "Filename of the seed image within output_path. Defaults to cidata.iso, or cidata.img for vfat.",
+				Optional:      true,
+				Computed:      true,
+				PlanModifiers: replace,
+			},
+			"format": schema.StringAttribute{
+				Description:   "Seed image format: iso9660 (with Joliet names) or vfat (FAT12).",
+				Optional:      true,
+				Computed:      true,
+				Default:       stringdefault.StaticString(seedFormatISO9660),
+				PlanModifiers: replace,
+				Validators: []validator.String{
+					stringOneOf(seedFormatISO9660, seedFormatVFAT),
+				},
+			},
+			"user_data": schema.StringAttribute{
+				Description:   "Content of the user-data file. Empty when unset.",
+				Optional:      true,
+				Sensitive:     true,
+				PlanModifiers: replace,
+			},
+			"meta_data": schema.StringAttribute{
+				Description:   "Content of the meta-data file. When unset, an instance-id derived from the other files is written.",
+				Optional:      true,
+				PlanModifiers: replace,
+			},
+			"network_config": schema.StringAttribute{
+				Description:   "Content of the network-config file (network config version 1 or 2).",
+				Optional:      true,
+				PlanModifiers: replace,
+			},
+			"vendor_data": schema.StringAttribute{
+				Description:   "Content of the vendor-data file.",
+				Optional:      true,
+				Sensitive:     true,
+				PlanModifiers: replace,
+			},
+			"path": schema.StringAttribute{
+				Description: "Full path to the seed image.",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"sha256": schema.StringAttribute{
+				Description: "SHA-256 digest of the seed image.",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+func (*CloudInitSeedResource) Create(
+	ctx context.Context,
+	req resource.CreateRequest,
+	resp *resource.CreateResponse,
+) {
+	var data CloudInitSeedResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	outDir := data.OutputPath.ValueString()
+
+	mkdirErr := os.MkdirAll(outDir, 0o755)
+	if mkdirErr != nil {
+		resp.Diagnostics.AddError("Failed to create output directory", mkdirErr.Error())
+
+		return
+	}
+
+	if data.OutputFilename.IsNull() || data.OutputFilename.IsUnknown() {
+		data.OutputFilename = types.StringValue(defaultSeedFilename(data.Format.ValueString()))
+	}
+
+	seedPath := filepath.Join(outDir, data.OutputFilename.ValueString())
+
+	writeErr := writeSeedImage(seedPath, data.Format.ValueString(), seedFiles(data))
+	if writeErr != nil {
+		resp.Diagnostics.AddError("Failed to write cloud-init seed image", writeErr.Error())
+
+		return
+	}
+
+	digest, digestErr := fileSHA256(seedPath)
+	if digestErr != nil {
+		resp.Diagnostics.AddError("Failed to hash cloud-init seed image", digestErr.Error())
+
+		return
+	}
+
+	data.Path = types.StringValue(seedPath)
+	data.SHA256 = types.StringValue(digest)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// defaultSeedFilename returns the seed image name used when
+// output_filename is unset.
+func defaultSeedFilename(format string) string {
+	if format == seedFormatVFAT {
+		return "cidata.img"
+	}
+
+	return "cidata.iso"
+}
+
+// seedFiles returns the NoCloud files of the resource. user-data and
+// meta-data are always present; meta-data defaults to an instance-id that
+// changes with the other files so cloud-init treats new content as a new
+// instance.
+func seedFiles(data CloudInitSeedResourceModel) []seedFile {
+	userData := data.UserData.ValueString()
+	networkConfig := data.NetworkConfig.ValueString()
+	vendorData := data.VendorData.ValueString()
+
+	metaData := data.MetaData.ValueString()
+	if data.MetaData.IsNull() {
+		metaData = "instance-id: " + deriveUUID(userData+"\x00"+networkConfig+"\x00"+vendorData, "cidata-instance-id") + "\n"
+	}
+
+	files := []seedFile{
+		{Name: "user-data", Content: []byte(userData)},
+		{Name: "meta-data", Content: []byte(metaData)},
+	}
+
+	if !data.NetworkConfig.IsNull() {
+		files = append(files, seedFile{Name: "network-config", Content: []byte(networkConfig)})
+	}
+
+	if !data.VendorData.IsNull() {
+		files = append(files, seedFile{Name: "vendor-data", Content: []byte(vendorData)})
+	}
+
+	return files
+}
+
+// writeSeedImage writes files to target as a cidata volume of the given
+// format. The FAT volume serial is derived from the files so identical
+// content yields an identical image.
+func writeSeedImage(target, format string, files []seedFile) error {
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+
+	if format == seedFormatVFAT {
+		var seed strings.Builder
+		for _, file := range files {
+			seed.WriteString(file.Name + "\x00" + string(file.Content) + "\x00")
+		}
+
+		err = writeVFAT(out, cidataLabel, deriveFATSerial(seed.String(), "cidata"), files)
+	} else {
+		err = writeISO9660(out, cidataLabel, files)
+	}
+
+	err = errors.Join(err, out.Close())
+	if err != nil {
+		_ = os.Remove(target)
+	}
+
+	return err
+}
+
+func (*CloudInitSeedResource) Read(_ context.Context, _ resource.ReadRequest, _ *resource.ReadResponse) {
+}
+
+func (*CloudInitSeedResource) Update(
+	_ context.Context,
+	_ resource.UpdateRequest,
+	resp *resource.UpdateResponse,
+) {
+	resp.Diagnostics.AddError("Update not supported",
+		"bootc_cloudinit_seed is immutable. Changes require replacement.")
+}
+
+func (*CloudInitSeedResource) Delete(
+	ctx context.Context,
+	req resource.DeleteRequest,
+	resp *resource.DeleteResponse,
+) {
+	var data CloudInitSeedResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !data.Path.IsNull() {
+		_ = os.Remove(data.Path.ValueString())
+	}
+}
@@ -0,0 +1,127 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestCloudInitSeedResource_Metadata(t *testing.T) {
+	resp := &resource.MetadataResponse{}
+	NewCloudInitSeedResource().Metadata(t.Context(), resource.MetadataRequest{ProviderTypeName: providerTypeName}, resp)
+
+	if resp.TypeName != "bootc_cloudinit_seed" {
+		t.Errorf("TypeName = %q, want %q", resp.TypeName, "bootc_cloudinit_seed")
+	}
+}
+
+func TestCloudInitSeedResource_Schema(t *testing.T) {
+	resp := &resource.SchemaResponse{}
+	NewCloudInitSeedResource().Schema(t.Context(), resource.SchemaRequest{}, resp)
+
+	tests := []struct {
+		name      string
+		required  bool
+		computed  bool
+		sensitive bool
+	}{
+		{"output_path", true, false, false},
+		{"output_filename", false, true, false},
+		{"format", false, true, false},
+		{"user_data", false, false, true},
+		{"meta_data", false, false, false},
+		{"network_config", false, false, false},
+		{"vendor_data", false, false, true},
+		{"path", false, true, false},
+		{"sha256", false, true, false},
+	}
+
+	if len(resp.Schema.Attributes) != len(tests) {
+		t.Errorf("attributes = %d, want %d", len(resp.Schema.Attributes), len(tests))
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			sa, ok := resp.Schema.Attributes[testCase.name].(schema.StringAttribute)
+			if !ok {
+				t.Fatalf("attribute %q is not StringAttribute", testCase.name)
+			}
+
+			if sa.Required != testCase.required || sa.Computed != testCase.computed || sa.Sensitive != testCase.sensitive {
+				t.Errorf("required=%v computed=%v sensitive=%v", sa.Required, sa.Computed, sa.Sensitive)
+			}
+		})
+	}
+}
+
+func TestCloudInitSeedResource_Update(t *testing.T) {
+	resp := &resource.UpdateResponse{}
+	NewCloudInitSeedResource().Update(t.Context(), resource.UpdateRequest{}, resp)
+
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected error from Update")
+	}
+}
+
+func TestSeedFiles(t *testing.T) {
+	data := CloudInitSeedResourceModel{
+		UserData:      types.StringValue("#cloud-config\n"),
+		MetaData:      types.StringNull(),
+		NetworkConfig: types.StringNull(),
+		VendorData:    types.StringNull(),
+	}
+
+	files := seedFiles(data)
+	if len(files) != 2 || files[0].Name != "user-data" || files[1].Name != "meta-data" {
+		t.Fatalf("files = %v", files)
+	}
+
+	metaData := string(files[1].Content)
+	if !strings.HasPrefix(metaData, "instance-id: ") {
+		t.Errorf("default meta-data = %q", metaData)
+	}
+
+	data.UserData = types.StringValue("#cloud-config\nhostname: node2\n")
+	if string(seedFiles(data)[1].Content) == metaData {
+		t.Error("instance-id should change with user-data")
+	}
+
+	data.MetaData = types.StringValue("instance-id: fixed\n")
+	data.NetworkConfig = types.StringValue("version: 2\n")
+	data.VendorData = types.StringValue("")
+
+	files = seedFiles(data)
+	if len(files) != 4 || string(files[1].Content) != "instance-id: fixed\n" {
+		t.Errorf("files = %v", files)
+	}
+}
+
+func TestDefaultSeedFilename(t *testing.T) {
+	if got := defaultSeedFilename(seedFormatISO9660); got != "cidata.iso" {
+		t.Errorf("iso9660 = %q", got)
+	}
+
+	if got := defaultSeedFilename(seedFormatVFAT); got != "cidata.img" {
+		t.Errorf("vfat = %q", got)
+	}
+}
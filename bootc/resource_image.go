@@ -18,14 +18,24 @@ package bootc
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/objectplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
@@ -33,25 +43,76 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
-var _ resource.Resource = &ImageResource{}
+var (
+	_ resource.Resource                   = &ImageResource{}
+	_ resource.ResourceWithValidateConfig = &ImageResource{}
+	_ resource.ResourceWithModifyPlan     = &ImageResource{}
+)
 
 // ImageResource implements the bootc_image Terraform resource.
 type ImageResource struct{}
 
 type ImageResourceModel struct {
-	Kargs                 types.List   `tfsdk:"kargs"`
-	OutputFilename        types.String `tfsdk:"output_filename"`
-	DiskSize              types.String `tfsdk:"disk_size"`
-	SourceImage           types.String `tfsdk:"source_image"`
-	Filesystem            types.String `tfsdk:"filesystem"`
-	RootSize              types.String `tfsdk:"root_size"`
-	OutputPath            types.String `tfsdk:"output_path"`
-	RootSSHAuthorizedKeys types.String `tfsdk:"root_ssh_authorized_keys"`
-	TargetImgref          types.String `tfsdk:"target_imgref"`
-	Bootloader            types.String `tfsdk:"bootloader"`
-	ImagePath             types.String `tfsdk:"image_path"`
-	DisableSELinux        types.Bool   `tfsdk:"disable_selinux"`
-	GenericImage          types.Bool   `tfsdk:"generic_image"`
+	Reproducible          *ReproducibleModel       `tfsdk:"reproducible"`
+	Files                 []FileModel              `tfsdk:"files"`
+	SystemdUnits          []SystemdUnitModel       `tfsdk:"systemd_units"`
+	Network               []NetworkConnectionModel `tfsdk:"network"`
+	BoundImages           []BoundImageModel        `tfsdk:"bound_images"`
+	Ignition              *IgnitionModel           `tfsdk:"ignition"`
+	InstallConfig         *InstallConfigModel      `tfsdk:"install_config"`
+	PartitionLayout       *PartitionLayoutModel    `tfsdk:"partition_layout"`
+	FilesystemOptions     *FilesystemOptionsModel  `tfsdk:"filesystem_options"`
+	AutoUpdate            *AutoUpdateModel         `tfsdk:"auto_update"`
+	SecureBoot            *SecureBootModel         `tfsdk:"secure_boot"`
+	VirtualHardware       *VirtualHardwareModel    `tfsdk:"virtual_hardware"`
+	Installer             *InstallerModel          `tfsdk:"installer"`
+	Kargs                 types.List               `tfsdk:"kargs"`
+	KargsRemove           types.List               `tfsdk:"kargs_remove"`
+	EffectiveKargs        types.List               `tfsdk:"effective_kargs"`
+	Partitions            types.List               `tfsdk:"partitions"`
+	Packaging             types.List               `tfsdk:"packaging"`
+	Artifacts             types.Map                `tfsdk:"artifacts"`
+	OutputFilename        types.String             `tfsdk:"output_filename"`
+	DiskSize              types.String             `tfsdk:"disk_size"`
+	SourceImage           types.String             `tfsdk:"source_image"`
+	Filesystem            types.String             `tfsdk:"filesystem"`
+	RootSize              types.String             `tfsdk:"root_size"`
+	OutputPath            types.String             `tfsdk:"output_path"`
+	RootSSHAuthorizedKeys types.String             `tfsdk:"root_ssh_authorized_keys"`
+	TargetImgref          types.String             `tfsdk:"target_imgref"`
+	HostRegistryAuth      types.String             `tfsdk:"host_registry_auth"`
+	Stateroot             types.String             `tfsdk:"stateroot"`
+	Bootloader            types.String             `tfsdk:"bootloader"`
+	Platform              types.String             `tfsdk:"platform"`
+	OutputFormat          types.String             `tfsdk:"output_format"`
+	PartitionTable        types.String             `tfsdk:"partition_table"`
+	ImagePath             types.String             `tfsdk:"image_path"`
+	RootFilesystemUUID    types.String             `tfsdk:"root_filesystem_uuid"`
+	ImageSHA256           types.String             `tfsdk:"image_sha256"`
+	OVMFVarsPath          types.String             `tfsdk:"ovmf_vars_path"`
+	InstallConfigTOML     types.String             `tfsdk:"install_config_toml"`
+	BlockSetup            types.String             `tfsdk:"block_setup"`
+	LUKSPassphrase        types.String             `tfsdk:"luks_passphrase_wo"`
+	LUKSPassphraseVersion types.Int64              `tfsdk:"luks_passphrase_wo_version"`
+	SectorSize            types.Int64              `tfsdk:"sector_size"`
+	BIOSBoot              types.Bool               `tfsdk:"bios_boot"`
+	LUKSRekeyOnFirstBoot  types.Bool               `tfsdk:"luks_rekey_on_first_boot"`
+	ComposefsBackend      types.Bool               `tfsdk:"composefs_backend"`
+	EnforceFSVerity       types.Bool               `tfsdk:"enforce_fs_verity"`
+	DisableSELinux        types.Bool               `tfsdk:"disable_selinux"`
+	GenericImage          types.Bool               `tfsdk:"generic_image"`
+}
+
+// imagePartitionAttrTypes describes the elements of the partitions attribute.
+var imagePartitionAttrTypes = map[string]attr.Type{
+	"number":     types.Int64Type,
+	"label":      types.StringType,
+	"type":       types.StringType,
+	"partuuid":   types.StringType,
+	"filesystem": types.StringType,
+	"uuid":       types.StringType,
+	"start":      types.Int64Type,
+	"size":       types.Int64Type,
 }
 
 func NewImageResource() resource.Resource {
@@ -71,8 +132,15 @@ func (*ImageResource) Schema(
 	_ resource.SchemaRequest,
 	resp *resource.SchemaResponse,
 ) {
+	// Update is not supported, so every input added to the image rebuilds it.
+	replaceString := []planmodifier.String{stringplanmodifier.RequiresReplace()}
+	replaceBool := []planmodifier.Bool{boolplanmodifier.RequiresReplace()}
+	replaceInt64 := []planmodifier.Int64{int64planmodifier.RequiresReplace()}
+	replaceList := []planmodifier.List{listplanmodifier.RequiresReplace()}
+	replaceObject := []planmodifier.Object{objectplanmodifier.RequiresReplace()}
+
 	resp.Schema = schema.Schema{
-		Description: "Builds a qcow2 disk image from a bootc container image using bootc install to-disk --via-loopback.",
+		Description: "Builds a disk image from a bootc container image using bootc install to-disk --via-loopback.",
 		Attributes: map[string]schema.Attribute{
 			"source_image": schema.StringAttribute{
 				Description: "Container image reference (e.g. quay.io/fedora/fedora-coreos:stable).",
@@ -95,7 +163,7 @@ func (*ImageResource) Schema(
 				Default:     stringdefault.StaticString("1G"),
 			},
 			"output_filename": schema.StringAttribute{
-				Description: "Filename for the resulting qcow2 image within output_path.",
+				Description: "Filename for the resulting image within output_path. Defaults to disk.<output_format>.",
 				Optional:    true,
 				Computed:    true,
 				Default:     stringdefault.StaticString("disk.qcow2"),
@@ -116,6 +184,12 @@ func (*ImageResource) Schema(
 				Optional:    true,
 				ElementType: types.StringType,
 			},
+			"kargs_remove": schema.ListAttribute{
+				Description:   "Kernel arguments to remove from the installed boot entries, such as defaults from the image's /usr/lib/bootc/kargs.d. A bare name (quiet, console) removes every argument with that name; name=value removes the exact argument. Arguments listed in kargs are kept.",
+				Optional:      true,
+				ElementType:   types.StringType,
+				PlanModifiers: replaceList,
+			},
 			"root_ssh_authorized_keys": schema.StringAttribute{
 				Description: "Path to an authorized_keys file to inject into the root account via systemd tmpfiles.d.",
 				Optional:    true,
@@ -124,6 +198,12 @@ func (*ImageResource) Schema(
 				Description: "Container image reference for subsequent bootc upgrades. If unset, defaults to the source image.",
 				Optional:    true,
 			},
+			"host_registry_auth": schema.StringAttribute{
+				Description:   "containers auth.json content written to /etc/ostree/auth.json, used by bootc upgrade on the installed system.",
+				Optional:      true,
+				Sensitive:     true,
+				PlanModifiers: replaceString,
+			},
 			"disable_selinux": schema.BoolAttribute{
 				Description: "Disable SELinux in the installed system.",
 				Optional:    true,
@@ -143,17 +223,747 @@ func (*ImageResource) Schema(
 					stringOneOf("grub", "systemd", "none"),
 				},
 			},
+			"platform": schema.StringAttribute{
+				Description: "Target platform whose defaults are applied: qemu, aws, azure, gcp, vsphere, openstack, or metal. " +
+					"Presets console kargs, ignition.platform.id, output_format, disk size alignment and the guest agent. Explicit attributes take precedence.",
+				Optional: true,
+				Validators: []validator.String{
+					stringOneOf(platformNames...),
+				},
+				PlanModifiers: replaceString,
+			},
+			"output_format": schema.StringAttribute{
+				Description: "Format of the output image: qcow2, raw, vmdk (stream-optimized), vhd (fixed), or vhdx. Defaults to the platform's format, or qcow2.",
+				Optional:    true,
+				Validators: []validator.String{
+					stringOneOf(outputFormats...),
+				},
+				PlanModifiers: replaceString,
+			},
+			"packaging": schema.ListAttribute{
+				Description: "Artifacts to build next to the output image: gce (disk.raw in a gzipped GNU tarball), " +
+					"azure (fixed VHD), aws-raw (raw disk), aws-vmdk (stream-optimized VMDK), ova (OVF appliance), " +
+					"vagrant-libvirt or vagrant-virtualbox (Vagrant boxes), or iso (installer ISO, see the installer block). " +
+					"gce rounds disk_size up to a whole GiB and azure to a whole MiB.",
+				Optional:      true,
+				ElementType:   types.StringType,
+				PlanModifiers: replaceList,
+			},
+			"partition_table": schema.StringAttribute{
+				Description: "Partition table of the disk: gpt or mbr. Defaults to gpt. mbr partitions the disk with the provider's layout.",
+				Optional:    true,
+				Validators: []validator.String{
+					stringOneOf(partitionTableGPT, partitionTableMBR),
+				},
+				PlanModifiers: replaceString,
+			},
+			"bios_boot": schema.BoolAttribute{
+				Description: "Make the disk bootable from legacy BIOS with GRUB, independent of generic_image: a BIOS boot partition on gpt, " +
+					"an active /boot partition on mbr. Defaults to true on x86_64 build hosts. Setting it partitions the disk with the provider's layout.",
+				Optional:      true,
+				PlanModifiers: replaceBool,
+			},
+			"sector_size": schema.Int64Attribute{
+				Description:   "Logical sector size of the loop device and the output image: 512, or 4096 for 4K-native disks. Defaults to 512.",
+				Optional:      true,
+				PlanModifiers: replaceInt64,
+			},
+			"block_setup": schema.StringAttribute{
+				Description: "Root block setup: direct, tpm2-luks (LUKS bound to the build host's TPM2 by bootc, so only for images that boot on the build host), " +
+					"or luks-passphrase (LUKS unlocked with luks_passphrase_wo). Defaults to the image configuration.",
+				Optional: true,
+				Validators: []validator.String{
+					stringOneOf(blockSetupDirect, blockSetupTPM2LUKS, blockSetupLUKSPassphrase),
+				},
+				PlanModifiers: replaceString,
+			},
+			"luks_passphrase_wo": schema.StringAttribute{
+				Description: "Passphrase of the luks-passphrase root container. Write-only: never stored in state.",
+				Optional:    true,
+				Sensitive:   true,
+				WriteOnly:   true,
+			},
+			"luks_passphrase_wo_version": schema.Int64Attribute{
+				Description:   "Version of luks_passphrase_wo; change it to rebuild the image with a new passphrase.",
+				Optional:      true,
+				PlanModifiers: replaceInt64,
+			},
+			"luks_rekey_on_first_boot": schema.BoolAttribute{
+				Description:   "On first boot, bind the luks-passphrase root to the device's TPM2 and wipe the passphrase.",
+				Optional:      true,
+				Computed:      true,
+				Default:       booldefault.StaticBool(false),
+				PlanModifiers: replaceBool,
+			},
+			"stateroot": schema.StringAttribute{
+				Description:   "Name of the ostree stateroot (os name) the deployment is installed into. Defaults to default.",
+				Optional:      true,
+				PlanModifiers: replaceString,
+			},
+			"composefs_backend": schema.BoolAttribute{
+				Description:   "Install with bootc's native composefs backend instead of ostree.",
+				Optional:      true,
+				Computed:      true,
+				Default:       booldefault.StaticBool(false),
+				PlanModifiers: replaceBool,
+			},
+			"enforce_fs_verity": schema.BoolAttribute{
+				Description:   "Require fs-verity on the composefs repository. Defaults to true with composefs_backend; false passes --insecure.",
+				Optional:      true,
+				PlanModifiers: replaceBool,
+			},
 			"image_path": schema.StringAttribute{
-				Description: "Full path to the resulting qcow2 file.",
+				Description: "Full path to the resulting disk image.",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"image_sha256": schema.StringAttribute{
+				Description: "SHA-256 digest of the resulting disk image.",
 				Computed:    true,
 				PlanModifiers: []planmodifier.String{
 					stringplanmodifier.UseStateForUnknown(),
 				},
 			},
+			"ovmf_vars_path": schema.StringAttribute{
+				Description: "Full path to the OVMF variable store with the secure_boot keys enrolled, when ovmf_vars_template is set.",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"install_config_toml": schema.StringAttribute{
+				Description: "The install_config block rendered as a bootc install configuration file.",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"root_filesystem_uuid": schema.StringAttribute{
+				Description: "UUID of the installed root filesystem.",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"effective_kargs": schema.ListAttribute{
+				Description: "Kernel command line of the installed deployment, read from its boot loader entry.",
+				Computed:    true,
+				ElementType: types.StringType,
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"artifacts": schema.MapNestedAttribute{
+				Description: "Packaging artifacts keyed by packaging.",
+				Computed:    true,
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.UseStateForUnknown(),
+				},
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"path": schema.StringAttribute{
+							Description: "Full path to the artifact.",
+							Computed:    true,
+						},
+						"sha256": schema.StringAttribute{
+							Description: "SHA-256 digest of the artifact.",
+							Computed:    true,
+						},
+					},
+				},
+			},
+			"partitions": schema.ListNestedAttribute{
+				Description: "Partition layout of the installed disk, read before conversion.",
+				Computed:    true,
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.UseStateForUnknown(),
+				},
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"number": schema.Int64Attribute{
+							Description: "Partition number.",
+							Computed:    true,
+						},
+						"label": schema.StringAttribute{
+							Description: "GPT partition label (e.g. EFI-SYSTEM, boot, root).",
+							Computed:    true,
+						},
+						"type": schema.StringAttribute{
+							Description: "Partition type GUID, or the hex MBR type (e.g. ef) on an mbr disk.",
+							Computed:    true,
+						},
+						"partuuid": schema.StringAttribute{
+							Description: "Unique partition GUID (PARTUUID).",
+							Computed:    true,
+						},
+						"filesystem": schema.StringAttribute{
+							Description: "Filesystem type, empty when the partition holds none (e.g. BIOS boot).",
+							Computed:    true,
+						},
+						"uuid": schema.StringAttribute{
+							Description: "Filesystem UUID.",
+							Computed:    true,
+						},
+						"start": schema.Int64Attribute{
+							Description: "Partition offset in bytes.",
+							Computed:    true,
+						},
+						"size": schema.Int64Attribute{
+							Description: "Partition size in bytes.",
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"files": schema.ListNestedBlock{
+				Description: "Files written into the installed deployment's /etc or /var after installation. Files in /etc are treated as local modifications by the ostree 3-way merge and survive upgrades.",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"path": schema.StringAttribute{
+							Description: "Absolute path in the installed system, below /etc or /var.",
+							Required:    true,
+							Validators: []validator.String{
+								stringPathPrefix("/etc", "/var"),
+							},
+						},
+						"content": schema.StringAttribute{
+							Description: "File content. Exactly one of content or source must be set.",
+							Optional:    true,
+						},
+						"source": schema.StringAttribute{
+							Description: "Path to a local file whose content is copied. Exactly one of content or source must be set.",
+							Optional:    true,
+						},
+						"mode": schema.StringAttribute{
+							Description: "Octal file mode, including the setuid, setgid and sticky bits (e.g. 4755). Defaults to 0644.",
+							Optional:    true,
+							Validators: []validator.String{
+								stringFileMode(),
+							},
+						},
+						"owner": schema.StringAttribute{
+							Description: "Owning user name or UID, resolved against the installed system. Defaults to root.",
+							Optional:    true,
+						},
+						"group": schema.StringAttribute{
+							Description: "Owning group name or GID, resolved against the installed system. Defaults to root.",
+							Optional:    true,
+						},
+					},
+				},
+				PlanModifiers: replaceList,
+			},
+			"systemd_units": schema.ListNestedBlock{
+				Description: "Systemd units written to /etc/systemd/system of the installed system, with optional drop-ins, enablement or masking.",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							Description: "Unit name including its suffix (e.g. agent.service, getty@ttyS0.service).",
+							Required:    true,
+						},
+						"content": schema.StringAttribute{
+							Description: "Unit file content. Omit to add drop-ins to or enable a unit shipped in the image.",
+							Optional:    true,
+						},
+						"dropins": schema.MapAttribute{
+							Description: "Drop-in files keyed by file name (e.g. 10-env.conf), written to <name>.d/.",
+							Optional:    true,
+							ElementType: types.StringType,
+						},
+						"enabled": schema.BoolAttribute{
+							Description: "Create the enablement symlinks from the unit's [Install] section.",
+							Optional:    true,
+						},
+						"wanted_by": schema.ListAttribute{
+							Description: "Targets to enable the unit for, instead of the [Install] section (e.g. [\"multi-user.target\"]).",
+							Optional:    true,
+							ElementType: types.StringType,
+						},
+						"mask": schema.BoolAttribute{
+							Description: "Mask the unit by linking it to /dev/null.",
+							Optional:    true,
+						},
+					},
+				},
+				PlanModifiers: replaceList,
+			},
+			"network": schema.ListNestedBlock{
+				Description: "NetworkManager connections rendered as keyfiles into /etc/NetworkManager/system-connections of the installed system.",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							Description: "Connection name, also used as the keyfile name.",
+							Required:    true,
+						},
+						"type": schema.StringAttribute{
+							Description: "Connection type: ethernet, bond, vlan, or bridge. Defaults to ethernet.",
+							Optional:    true,
+							Validators: []validator.String{
+								stringOneOf(connTypeEthernet, connTypeBond, connTypeVLAN, connTypeBridge),
+							},
+						},
+						"interface_name": schema.StringAttribute{
+							Description: "Interface name to match or create (e.g. eno1, bond0).",
+							Optional:    true,
+						},
+						"mac_address": schema.StringAttribute{
+							Description: "MAC address to match (ethernet only).",
+							Optional:    true,
+						},
+						"controller": schema.StringAttribute{
+							Description: "Interface name of the bond or bridge this connection is a port of.",
+							Optional:    true,
+						},
+						"mtu": schema.Int64Attribute{
+							Description: "Interface MTU.",
+							Optional:    true,
+						},
+						"bond_mode": schema.StringAttribute{
+							Description: "Bonding mode (e.g. 802.3ad, active-backup).",
+							Optional:    true,
+						},
+						"bond_options": schema.MapAttribute{
+							Description: "Additional bonding options (e.g. miimon, lacp_rate).",
+							Optional:    true,
+							ElementType: types.StringType,
+						},
+						"vlan_id": schema.Int64Attribute{
+							Description: "VLAN ID.",
+							Optional:    true,
+						},
+						"vlan_parent": schema.StringAttribute{
+							Description: "Parent interface of the VLAN.",
+							Optional:    true,
+						},
+						"ipv4_method": schema.StringAttribute{
+							Description: "IPv4 method: auto, manual, link-local, or disabled. Defaults to manual when addresses are set, auto otherwise.",
+							Optional:    true,
+							Validators: []validator.String{
+								stringOneOf("auto", "manual", "link-local", "disabled"),
+							},
+						},
+						"ipv4_addresses": schema.ListAttribute{
+							Description: "Static IPv4 addresses in CIDR notation.",
+							Optional:    true,
+							ElementType: types.StringType,
+						},
+						"ipv4_gateway": schema.StringAttribute{
+							Description: "IPv4 default gateway.",
+							Optional:    true,
+						},
+						"ipv4_dns": schema.ListAttribute{
+							Description: "IPv4 DNS servers.",
+							Optional:    true,
+							ElementType: types.StringType,
+						},
+						"ipv6_method": schema.StringAttribute{
+							Description: "IPv6 method: auto, dhcp, manual, link-local, ignore, or disabled. Defaults to manual when addresses are set, auto otherwise.",
+							Optional:    true,
+							Validators: []validator.String{
+								stringOneOf("auto", "dhcp", "manual", "link-local", "ignore", "disabled"),
+							},
+						},
+						"ipv6_addresses": schema.ListAttribute{
+							Description: "Static IPv6 addresses in CIDR notation.",
+							Optional:    true,
+							ElementType: types.StringType,
+						},
+						"ipv6_gateway": schema.StringAttribute{
+							Description: "IPv6 default gateway.",
+							Optional:    true,
+						},
+						"ipv6_dns": schema.ListAttribute{
+							Description: "IPv6 DNS servers.",
+							Optional:    true,
+							ElementType: types.StringType,
+						},
+						"dns_search": schema.ListAttribute{
+							Description: "DNS search domains.",
+							Optional:    true,
+							ElementType: types.StringType,
+						},
+					},
+				},
+				PlanModifiers: replaceList,
+			},
+			"bound_images": schema.ListNestedBlock{
+				Description: "Application container images preloaded into /var/lib/containers/storage of the installed system, " +
+					"so they run without network on first boot.",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"image": schema.StringAttribute{
+							Description: "Container image reference (e.g. quay.io/example/app:1.4).",
+							Required:    true,
+						},
+						"pull_policy": schema.StringAttribute{
+							Description: "Pull policy on the build host: always, missing, newer, or never. Defaults to missing.",
+							Optional:    true,
+							Validators: []validator.String{
+								stringOneOf(pullPolicyAlways, pullPolicyMissing, pullPolicyNewer, pullPolicyNever),
+							},
+						},
+						"auth_file": schema.StringAttribute{
+							Description: "Path to a containers auth.json file used for the pull. By default podman's auth files are consulted.",
+							Optional:    true,
+						},
+						"digest": schema.StringAttribute{
+							Description: "Manifest digest of the preloaded image.",
+							Computed:    true,
+						},
+					},
+				},
+				PlanModifiers: replaceList,
+			},
+			"auto_update": schema.SingleNestedBlock{
+				Description: "Configures bootc-fetch-apply-updates.timer of the installed system.",
+				Attributes: map[string]schema.Attribute{
+					"enabled": schema.BoolAttribute{
+						Description: "Enable the update timer. Defaults to true; false masks it.",
+						Optional:    true,
+					},
+					"schedule": schema.StringAttribute{
+						Description: "systemd calendar expression (e.g. Sun *-*-* 03:00:00) replacing the default interval.",
+						Optional:    true,
+					},
+					"mode": schema.StringAttribute{
+						Description: "apply reboots into fetched updates; stage only stages them for the next reboot. Defaults to apply.",
+						Optional:    true,
+						Validators: []validator.String{
+							stringOneOf(updateModeApply, updateModeStage),
+						},
+					},
+				},
+				PlanModifiers: replaceObject,
+			},
+			"virtual_hardware": schema.SingleNestedBlock{
+				Description: "Virtual machine settings of the ova and vagrant packaging descriptors.",
+				Attributes: map[string]schema.Attribute{
+					"cpus": schema.Int64Attribute{
+						Description: "Number of virtual CPUs. Defaults to 2.",
+						Optional:    true,
+					},
+					"memory": schema.Int64Attribute{
+						Description: "Memory size in MiB. Defaults to 2048.",
+						Optional:    true,
+					},
+					"network_adapter": schema.StringAttribute{
+						Description: "Emulated NIC: e1000, e1000e, or vmxnet3. Defaults to e1000, which every hypervisor emulates.",
+						Optional:    true,
+						Validators: []validator.String{
+							stringOneOf(nicE1000, nicE1000e, nicVMXNet3),
+						},
+					},
+					"firmware": schema.StringAttribute{
+						Description: "Virtual machine firmware: efi or bios. Defaults to efi.",
+						Optional:    true,
+						Validators: []validator.String{
+							stringOneOf(firmwareEFI, firmwareBIOS),
+						},
+					},
+				},
+				PlanModifiers: replaceObject,
+			},
+			"installer": schema.SingleNestedBlock{
+				Description: "Automated install of the iso packaging: the ISO boots the source image live and runs bootc install to-disk " +
+					"from the image layout it embeds, with the install options of the disk image.",
+				Attributes: map[string]schema.Attribute{
+					"target_disk": schema.StringAttribute{
+						Description: "Device the ISO installs onto and wipes (e.g. /dev/nvme0n1 or /dev/disk/by-path/...). Required with iso.",
+						Optional:    true,
+					},
+					"after_install": schema.StringAttribute{
+						Description: "Action once the install succeeded: reboot, poweroff, or none. Defaults to reboot.",
+						Optional:    true,
+						Validators: []validator.String{
+							stringOneOf(afterInstallReboot, afterInstallPoweroff, afterInstallNone),
+						},
+					},
+					"kargs": schema.ListAttribute{
+						Description: "Kernel arguments of the installer environment (e.g. console=ttyS0,115200n8).",
+						Optional:    true,
+						ElementType: types.StringType,
+					},
+				},
+				PlanModifiers: replaceObject,
+			},
+			"secure_boot": schema.SingleNestedBlock{
+				Description: "Signs the EFI binaries with the db key, builds signed UKIs and prepares systemd-boot key enrollment. Requires bootloader = \"systemd\". Signed images cannot be upgraded in place and conflict with auto_update.",
+				Attributes: map[string]schema.Attribute{
+					"uki": schema.BoolAttribute{
+						Description: "Boot each boot loader entry through a signed unified kernel image written next to its kernel. Defaults to true. When false, the kernels of the entries are signed instead.",
+						Optional:    true,
+					},
+					"enroll": schema.StringAttribute{
+						Description: "systemd-boot secure-boot-enroll mode for the pk, kek and db keys: manual, if-safe, or force. Defaults to if-safe.",
+						Optional:    true,
+						Validators: []validator.String{
+							stringOneOf(enrollManual, enrollIfSafe, enrollForce),
+						},
+					},
+					"owner_guid": schema.StringAttribute{
+						Description: "Owner GUID of the enrolled signature lists. Defaults to a GUID derived from the db certificate.",
+						Optional:    true,
+					},
+					"ovmf_vars_template": schema.StringAttribute{
+						Description: "Path to an OVMF_VARS.fd template. A copy with the keys enrolled and Secure Boot enabled is written next to the image.",
+						Optional:    true,
+					},
+				},
+				Blocks: map[string]schema.Block{
+					"pk":  secureBootKeyBlock("Platform key, which signs the PK and KEK enrollment variables."),
+					"kek": secureBootKeyBlock("Key exchange key, which signs the db enrollment variable."),
+					"db":  secureBootKeyBlock("Signature database key, which signs the EFI binaries and UKIs."),
+				},
+				PlanModifiers: replaceObject,
+			},
+			"ignition": schema.SingleNestedBlock{
+				Description: "Ignition config for CoreOS-derived images, placed on the boot filesystem so it runs on first boot. Sets ignition.platform.id automatically; CoreOS's GRUB configuration adds ignition.firstboot until Ignition has run.",
+				Attributes: map[string]schema.Attribute{
+					"config": schema.StringAttribute{
+						Description: "Ignition config as JSON. Exactly one of config or butane must be set.",
+						Optional:    true,
+						Sensitive:   true,
+					},
+					"butane": schema.StringAttribute{
+						Description: "Butane config as YAML, translated to Ignition. Exactly one of config or butane must be set.",
+						Optional:    true,
+						Sensitive:   true,
+					},
+					"files_dir": schema.StringAttribute{
+						Description: "Directory that local file references in the Butane config are resolved against.",
+						Optional:    true,
+					},
+					"platform": schema.StringAttribute{
+						Description: "Ignition platform ID passed as ignition.platform.id (e.g. metal, qemu, aws). Defaults to metal.",
+						Optional:    true,
+					},
+				},
+				PlanModifiers: replaceObject,
+			},
+			"install_config": schema.SingleNestedBlock{
+				Description: "bootc install configuration applied on top of the image's /usr/lib/bootc/install/*.toml for this install.",
+				Attributes: map[string]schema.Attribute{
+					"root_fs_type": schema.StringAttribute{
+						Description: "Root filesystem type: xfs, ext4, or btrfs. Conflicts with filesystem.",
+						Optional:    true,
+						Validators: []validator.String{
+							stringOneOf("xfs", "ext4", "btrfs"),
+						},
+					},
+					"block": schema.ListAttribute{
+						Description: "Allowed block setups (direct, tpm2-luks); the first is used for this install.",
+						Optional:    true,
+						ElementType: types.StringType,
+					},
+					"kargs": schema.ListAttribute{
+						Description: "Kernel arguments appended to those of the image configuration.",
+						Optional:    true,
+						ElementType: types.StringType,
+					},
+					"match_architectures": schema.ListAttribute{
+						Description: "Apply the block only on these architectures (e.g. x86_64, aarch64).",
+						Optional:    true,
+						ElementType: types.StringType,
+					},
+				},
+				PlanModifiers: replaceObject,
+			},
+			"partition_layout": schema.SingleNestedBlock{
+				Description: "Partition the disk with the provider and install with bootc install to-filesystem instead of to-disk. " +
+					"Root takes the space left after all other partitions, or root_size.",
+				Attributes: map[string]schema.Attribute{
+					"esp_size": schema.StringAttribute{
+						Description: "Size of the EFI system partition (e.g. 512M). Defaults to 512M.",
+						Optional:    true,
+					},
+					"boot_size": schema.StringAttribute{
+						Description: "Size of the /boot partition (e.g. 1G). Defaults to 1G.",
+						Optional:    true,
+					},
+					"boot_filesystem": schema.StringAttribute{
+						Description: "Filesystem of the /boot partition: ext4 or xfs. Defaults to ext4.",
+						Optional:    true,
+						Validators: []validator.String{
+							stringOneOf("ext4", "xfs"),
+						},
+					},
+					"root_mkfs_options": schema.ListAttribute{
+						Description: "Extra options passed to mkfs for the root filesystem.",
+						Optional:    true,
+						ElementType: types.StringType,
+					},
+				},
+				Blocks: map[string]schema.Block{
+					"partition": schema.ListNestedBlock{
+						Description: "Additional partition mounted at /var, below /var, or used as swap.",
+						NestedObject: schema.NestedBlockObject{
+							Attributes: map[string]schema.Attribute{
+								"mount_point": schema.StringAttribute{
+									Description: "Mount point: /var, a path below /var (e.g. /var/log/audit), or swap.",
+									Required:    true,
+								},
+								"size": schema.StringAttribute{
+									Description: "Partition size with an optional K, M, G or T suffix (e.g. 10G).",
+									Required:    true,
+								},
+								"filesystem": schema.StringAttribute{
+									Description: "Filesystem: xfs, ext4, or btrfs. Defaults to the root filesystem. Not allowed for swap.",
+									Optional:    true,
+									Validators: []validator.String{
+										stringOneOf("xfs", "ext4", "btrfs"),
+									},
+								},
+								"label": schema.StringAttribute{
+									Description: "Filesystem label, at most 12 characters.",
+									Optional:    true,
+								},
+								"mkfs_options": schema.ListAttribute{
+									Description: "Extra options passed to mkfs or mkswap.",
+									Optional:    true,
+									ElementType: types.StringType,
+								},
+							},
+						},
+					},
+				},
+				PlanModifiers: replaceObject,
+			},
+			"filesystem_options": schema.SingleNestedBlock{
+				Description: "Root filesystem tuning. The provider creates the root filesystem as with partition_layout.",
+				Attributes: map[string]schema.Attribute{
+					"mkfs_options": schema.ListAttribute{
+						Description: "Extra options passed to mkfs for the root filesystem.",
+						Optional:    true,
+						ElementType: types.StringType,
+					},
+					"mount_options": schema.ListAttribute{
+						Description: "Root filesystem mount options, passed as rootflags= and used during the install.",
+						Optional:    true,
+						ElementType: types.StringType,
+					},
+				},
+				Blocks: map[string]schema.Block{
+					"btrfs": schema.SingleNestedBlock{
+						Description: "btrfs compression and subvolume layout. Requires a btrfs root filesystem.",
+						Attributes: map[string]schema.Attribute{
+							"compression": schema.StringAttribute{
+								Description: "Compression as for the compress mount option: zlib[:1-9], lzo, or zstd[:1-15].",
+								Optional:    true,
+							},
+						},
+						Blocks: map[string]schema.Block{
+							"subvolume": schema.ListNestedBlock{
+								Description: "Top-level subvolume mounted at /var or below from /etc/fstab.",
+								NestedObject: schema.NestedBlockObject{
+									Attributes: map[string]schema.Attribute{
+										"name": schema.StringAttribute{
+											Description: "Subvolume name (e.g. @var).",
+											Required:    true,
+										},
+										"mount_point": schema.StringAttribute{
+											Description: "Mount point: /var or a path below it (e.g. /var/home for /home).",
+											Required:    true,
+										},
+										"snapshots": schema.BoolAttribute{
+											Description: "Create a nested .snapshots subvolume for snapper.",
+											Optional:    true,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				PlanModifiers: replaceObject,
+			},
+			"reproducible": schema.SingleNestedBlock{
+				Description: "Derive partition GUIDs, filesystem UUIDs and the identifiers qemu-img generates from a seed and pin build timestamps, " +
+					"so identical inputs produce stable identifiers. The image is not byte-identical across builds: the kernel records real " +
+					"inode change times and random inode generations during the install.",
+				Attributes: map[string]schema.Attribute{
+					"seed": schema.StringAttribute{
+						Description: "Seed from which all partition GUIDs and filesystem UUIDs are derived.",
+						Required:    true,
+					},
+					"source_date_epoch": schema.Int64Attribute{
+						Description: "Unix timestamp used for build timestamps. Defaults to the SOURCE_DATE_EPOCH environment variable.",
+						Optional:    true,
+					},
+				},
+				PlanModifiers: replaceObject,
+			},
 		},
 	}
 }
 
+func (*ImageResource) ValidateConfig(
+	ctx context.Context,
+	req resource.ValidateConfigRequest,
+	resp *resource.ValidateConfigResponse,
+) {
+	var data ImageResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(validateKargsRemove(data.KargsRemove)...)
+	resp.Diagnostics.Append(validatePackaging(data.Packaging)...)
+	resp.Diagnostics.Append(validateVirtualHardware(&data)...)
+	resp.Diagnostics.Append(validateInstaller(&data)...)
+	resp.Diagnostics.Append(validateInstallConfig(data.InstallConfig)...)
+
+	if data.InstallConfig != nil && !data.InstallConfig.RootFSType.IsNull() && !data.Filesystem.IsNull() {
+		resp.Diagnostics.AddAttributeError(path.Root("install_config").AtName("root_fs_type"),
+			"Conflicting filesystem options", "Set either filesystem or install_config.root_fs_type, not both.")
+	}
+
+	resp.Diagnostics.Append(validatePartitionLayout(data.PartitionLayout)...)
+	resp.Diagnostics.Append(validateDiskLabel(&data)...)
+	resp.Diagnostics.Append(validateBlockSetup(&data)...)
+	resp.Diagnostics.Append(validateFilesystemOptions(&data, configuredRootFilesystem(&data))...)
+	resp.Diagnostics.Append(validateBoundImages(&data)...)
+	resp.Diagnostics.Append(validateHostRegistryAuth(data.HostRegistryAuth)...)
+	resp.Diagnostics.Append(validateAutoUpdate(data.AutoUpdate)...)
+	resp.Diagnostics.Append(validateInstallBackend(&data)...)
+	resp.Diagnostics.Append(validateSecureBoot(&data)...)
+	resp.Diagnostics.Append(validateReproducible(&data)...)
+
+	if data.partitionsDisk() && data.InstallConfig != nil && len(data.InstallConfig.Block.Elements()) > 0 {
+		resp.Diagnostics.AddAttributeError(path.Root("install_config").AtName("block"), "Conflicting block options",
+			"install_config.block cannot be used when the provider partitions the disk for partition_layout, "+
+				"filesystem_options, luks-passphrase, partition_table, bios_boot or sector_size.")
+	}
+
+	for idx, file := range data.Files {
+		if file.Content.IsUnknown() || file.Source.IsUnknown() {
+			continue
+		}
+
+		if file.Content.IsNull() == file.Source.IsNull() {
+			resp.Diagnostics.AddAttributeError(path.Root("files").AtListIndex(idx),
+				"Invalid file entry", "Exactly one of content or source must be set.")
+		}
+	}
+
+	for idx, unit := range data.SystemdUnits {
+		resp.Diagnostics.Append(validateSystemdUnit(path.Root("systemd_units").AtListIndex(idx), unit)...)
+	}
+
+	for idx, conn := range data.Network {
+		resp.Diagnostics.Append(validateNetworkConnection(path.Root("network").AtListIndex(idx), conn)...)
+	}
+
+	if data.Ignition != nil {
+		resp.Diagnostics.Append(validateIgnition(*data.Ignition)...)
+	}
+}
+
 func (*ImageResource) Create(
 	ctx context.Context,
 	req resource.CreateRequest,
@@ -176,11 +986,76 @@ func (*ImageResource) Create(
 	}
 
 	rawPath := filepath.Join(outDir, "disk.raw")
-	qcow2Path := filepath.Join(outDir, data.OutputFilename.ValueString())
+	imagePath := filepath.Join(outDir, data.OutputFilename.ValueString())
+
+	var epoch int64
+
+	if data.Reproducible != nil {
+		if data.Reproducible.Seed.ValueString() == "" {
+			resp.Diagnostics.AddAttributeError(path.Root("reproducible").AtName("seed"),
+				"Missing reproducible seed", "reproducible.seed must be set to a non-empty string.")
+
+			return
+		}
+
+		var epochErr error
+
+		epoch, epochErr = resolveSourceDateEpoch(data.Reproducible.SourceDateEpoch)
+		if epochErr != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("reproducible").AtName("source_date_epoch"),
+				"Missing source date epoch", epochErr.Error())
+
+			return
+		}
+
+		ctx = withReproducibleBuild(ctx, data.Reproducible.Seed.ValueString(), epoch)
+	}
+
+	luks := luksSetup{BlockSetup: data.BlockSetup.ValueString(), Rekey: data.LUKSRekeyOnFirstBoot.ValueBool()}
+	layoutModel := data.PartitionLayout
+	label := data.diskLabel()
+	ctx = withSectorSize(ctx, label.SectorSize)
+
+	// bootc install to-disk cannot use a passphrase, filesystem tuning, an
+	// MBR or a sector size other than the loop device's 512 bytes, so the
+	// provider partitions the disk, with the default layout unless
+	// partition_layout is set.
+	if layoutModel == nil && data.partitionsDisk() {
+		layoutModel = &PartitionLayoutModel{}
+	}
+
+	tuning, tuningDiags := filesystemTuningFromModel(ctx, data.FilesystemOptions)
+	resp.Diagnostics.Append(tuningDiags...)
+
+	var secureBoot *secureBootSetup
+
+	if data.SecureBoot != nil {
+		var secureBootErr error
+
+		secureBoot, secureBootErr = secureBootSetupFromModel(*data.SecureBoot)
+		if secureBootErr != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("secure_boot"), "Invalid Secure Boot key", secureBootErr.Error())
+
+			return
+		}
+	}
+
+	if luks.BlockSetup == blockSetupLUKSPassphrase {
+		var passphrase types.String
+
+		resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("luks_passphrase_wo"), &passphrase)...)
+
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		luks.Passphrase = []byte(passphrase.ValueString())
+		ctx = withLUKSPassphrase(ctx, luks.Passphrase)
+	}
 
 	// 1. Create sparse raw file
 	//nolint:gosec // G204: truncate is a trusted system command with validated inputs
-	truncCmd := exec.CommandContext(ctx, "truncate", "-s", data.DiskSize.ValueString(), rawPath)
+	truncCmd := exec.CommandContext(ctx, "truncate", "-s", data.alignedDiskSize(), rawPath)
 
 	truncOut, truncErr := truncCmd.CombinedOutput()
 	if truncErr != nil {
@@ -191,25 +1066,38 @@ func (*ImageResource) Create(
 	}
 
 	// 2. Build bootc install args
-	args := []string{
-		"bootc", "install", "to-disk", "--via-loopback",
-		"--source-imgref", data.SourceImage.ValueString(),
+	args := []string{"bootc", "install"}
+
+	if layoutModel != nil {
+		args = append(args, "to-filesystem")
+	} else {
+		args = append(args, "to-disk", "--via-loopback")
 	}
 
+	args = append(args, "--source-imgref", data.SourceImage.ValueString())
+
 	if data.GenericImage.ValueBool() {
 		args = append(args, "--generic-image")
 	}
 
-	if !data.Filesystem.IsNull() {
+	if !data.Filesystem.IsNull() && layoutModel == nil {
 		args = append(args, "--filesystem", data.Filesystem.ValueString())
 	}
 
-	if !data.RootSize.IsNull() {
+	if !data.RootSize.IsNull() && layoutModel == nil {
 		args = append(args, "--root-size", data.RootSize.ValueString())
 	}
 
+	// The layout formats an unencrypted root itself, so direct is implied.
+	if (luks.BlockSetup == blockSetupDirect || luks.BlockSetup == blockSetupTPM2LUKS) && layoutModel == nil {
+		args = append(args, "--block-setup", luks.BlockSetup)
+	}
+
+	args = append(args, data.backendArgs()...)
+
+	var kargs []string
+
 	if !data.Kargs.IsNull() {
-		var kargs []string
 		resp.Diagnostics.Append(data.Kargs.ElementsAs(ctx, &kargs, false)...)
 
 		if resp.Diagnostics.HasError() {
@@ -223,6 +1111,60 @@ func (*ImageResource) Create(
 		}
 	}
 
+	for _, karg := range data.platformKargs(kargs) {
+		args = append(args, "--karg", karg)
+		kargs = append(kargs, karg)
+	}
+
+	installCfg, installDiags := installConfigFromModel(ctx, data.InstallConfig)
+	resp.Diagnostics.Append(installDiags...)
+
+	installTOML, tomlErr := installCfg.TOML()
+	if tomlErr != nil {
+		resp.Diagnostics.AddError("Failed to render install configuration", tomlErr.Error())
+	}
+
+	var layout []layoutPartition
+
+	if layoutModel != nil {
+		var layoutDiags diag.Diagnostics
+
+		layout, layoutDiags = partitionLayout(ctx, layoutModel, data.rootFilesystem(installCfg),
+			data.RootSize.ValueString(), hostArchitecture(), label)
+		resp.Diagnostics.Append(layoutDiags...)
+
+		// Root is always the last partition of the layout.
+		root := &layout[len(layout)-1]
+		root.Encrypted = luks.Passphrase != nil
+		root.MkfsOptions = append(root.MkfsOptions, tuning.MkfsOptions...)
+		root.MountOptions = tuning.RootMountOptions()
+
+		// The layout creates the filesystems, so only kargs are passed on.
+		installCfg.RootFSType, installCfg.Block = "", nil
+	}
+
+	if resp.Diagnostics.HasError() {
+		_ = os.Remove(rawPath)
+
+		return
+	}
+
+	if installCfg.AppliesTo(hostArchitecture()) {
+		args = append(args, installCfg.InstallArgs()...)
+		kargs = append(kargs, installCfg.Kargs...)
+	}
+
+	for _, karg := range tuning.Kargs() {
+		args = append(args, "--karg", karg)
+		kargs = append(kargs, karg)
+	}
+
+	if data.Ignition != nil {
+		platformKarg := "ignition.platform.id=" + data.ignitionPlatformID()
+		args = append(args, "--karg", platformKarg)
+		kargs = append(kargs, platformKarg)
+	}
+
 	if !data.RootSSHAuthorizedKeys.IsNull() {
 		args = append(args, "--root-ssh-authorized-keys", data.RootSSHAuthorizedKeys.ValueString())
 	}
@@ -239,10 +1181,45 @@ func (*ImageResource) Create(
 		args = append(args, "--bootloader", data.Bootloader.ValueString())
 	}
 
-	args = append(args, rawPath)
+	// 3. Run bootc install, onto the raw file or onto the partition_layout
+	// filesystems mounted from it. Reproducible builds pin the timestamps
+	// of bootc and the mkfs tools through the command environment.
+	installCtx := ctx
+	if data.Reproducible != nil {
+		installCtx = withCommandEnv(ctx, reproducibleEnv(epoch))
+	}
+
+	runInstall := func() error { return bootcRun(installCtx, append(args, rawPath)) }
+
+	if layout != nil {
+		runInstall = func() error {
+			err := createLayout(installCtx, rawPath, layout, label)
+			if err != nil {
+				return err
+			}
 
-	// 3. Run bootc install to-disk --via-loopback
-	bootcErr := BootcRun(args)
+			if luks.Passphrase != nil {
+				var luksKargs []string
+
+				luksKargs, err = encryptedRootKargs(installCtx, rawPath, luks)
+				if err != nil {
+					return err
+				}
+
+				for _, karg := range luksKargs {
+					args = append(args, "--karg", karg)
+				}
+
+				kargs = append(kargs, luksKargs...)
+			}
+
+			return mountLayoutTarget(installCtx, rawPath, layout, func(target string) error {
+				return bootcRun(installCtx, append(args, target))
+			})
+		}
+	}
+
+	bootcErr := runInstall()
 	if bootcErr != nil {
 		_ = os.Remove(rawPath)
 
@@ -251,26 +1228,480 @@ func (*ImageResource) Create(
 		return
 	}
 
-	// 4. Convert raw → qcow2
+	// 4. Write files, units, network and Ignition configuration
+	if data.hasCustomizations() {
+		resp.Diagnostics.Append(customizeImage(ctx, rawPath, data, epoch)...)
+
+		if resp.Diagnostics.HasError() {
+			_ = os.Remove(rawPath)
+
+			return
+		}
+	}
+
+	// 5. Preload bound_images into the container storage
+	if len(data.BoundImages) > 0 {
+		digests, preloadErr := preloadBoundImages(ctx, rawPath, boundImages(data.BoundImages))
+		if preloadErr != nil {
+			_ = os.Remove(rawPath)
+
+			resp.Diagnostics.AddError("Failed to preload bound images", preloadErr.Error())
+
+			return
+		}
+
+		for idx, digest := range digests {
+			data.BoundImages[idx].Digest = types.StringValue(digest)
+		}
+	}
+
+	// 6. Move /var content onto the partition_layout partitions and btrfs
+	// subvolumes
+	if layout != nil {
+		layoutErr := finalizeLayout(ctx, rawPath, layout, data.buildMtime(epoch))
+		if layoutErr != nil {
+			_ = os.Remove(rawPath)
 
-	convertCmd := exec.CommandContext(ctx, "qemu-img", "convert",
-		"-f", "raw", "-O", "qcow2", rawPath, qcow2Path)
+			resp.Diagnostics.AddError("Failed to set up partition layout", layoutErr.Error())
 
-	convertOut, convertErr := convertCmd.CombinedOutput()
-	if convertErr != nil {
-		resp.Diagnostics.AddError("qemu-img convert failed",
-			fmt.Sprintf("%v: %s", convertErr, string(convertOut)))
+			return
+		}
+	}
+
+	if len(tuning.Subvolumes) > 0 {
+		subvolumeErr := createSubvolumes(ctx, rawPath, tuning, data.buildMtime(epoch))
+		if subvolumeErr != nil {
+			_ = os.Remove(rawPath)
+
+			resp.Diagnostics.AddError("Failed to create btrfs subvolumes", subvolumeErr.Error())
 
+			return
+		}
+	}
+
+	// 7. Add the encrypted root to crypttab
+	if luks.Passphrase != nil || luks.BlockSetup == blockSetupTPM2LUKS {
+		luksErr := configureLUKS(ctx, rawPath, luks, data.buildMtime(epoch))
+		if luksErr != nil {
+			_ = os.Remove(rawPath)
+
+			resp.Diagnostics.AddError("Failed to configure encrypted root", luksErr.Error())
+
+			return
+		}
+	}
+
+	// 8. Remove kargs_remove and read back the effective kernel arguments
+	var kargsRemove []string
+
+	if !data.KargsRemove.IsNull() {
+		resp.Diagnostics.Append(data.KargsRemove.ElementsAs(ctx, &kargsRemove, false)...)
+
+		if resp.Diagnostics.HasError() {
+			_ = os.Remove(rawPath)
+
+			return
+		}
+	}
+
+	// composefs images may boot a UKI, whose command line has no boot
+	// loader entry to read back.
+	var effectiveKargs []string
+
+	if !data.ComposefsBackend.ValueBool() {
+		var kargsErr error
+
+		effectiveKargs, kargsErr = applyKargs(ctx, rawPath, kargsRemove, kargs, data.buildMtime(epoch))
+		if kargsErr != nil {
+			_ = os.Remove(rawPath)
+
+			resp.Diagnostics.AddError("Failed to update kernel arguments", kargsErr.Error())
+
+			return
+		}
+	}
+
+	// 9. Replace random identifiers with seed-derived ones once every
+	// step that mounts the deployment read-write has run, and map the
+	// effective kernel arguments onto the new UUIDs
+	if data.Reproducible != nil {
+		replacements, reproErr := makeReproducible(ctx, rawPath, data.Reproducible.Seed.ValueString(), epoch)
+		if reproErr != nil {
+			_ = os.Remove(rawPath)
+
+			resp.Diagnostics.AddError("Failed to make disk image reproducible", reproErr.Error())
+
+			return
+		}
+
+		for idx, karg := range effectiveKargs {
+			effectiveKargs[idx] = replaceUUIDs(karg, replacements)
+		}
+	}
+
+	// 10. Sign the EFI binaries, build UKIs and prepare key enrollment
+	if secureBoot != nil {
+		secureBootErr := secureBootImage(ctx, rawPath, *secureBoot, data.buildMtime(epoch))
+		if secureBootErr != nil {
+			_ = os.Remove(rawPath)
+
+			resp.Diagnostics.AddError("Failed to set up Secure Boot", secureBootErr.Error())
+
+			return
+		}
+	}
+
+	// 11. Read the installed partition layout
+	partitions, partitionsErr := readInstalledPartitions(ctx, rawPath)
+	if partitionsErr != nil {
+		_ = os.Remove(rawPath)
+
+		resp.Diagnostics.AddError("Failed to read installed partition layout", partitionsErr.Error())
+
+		return
+	}
+
+	// 12. Package the raw disk and the installer ISO
+	installer, installerDiags := installerFromModel(ctx, &data, args)
+	resp.Diagnostics.Append(installerDiags...)
+
+	if resp.Diagnostics.HasError() {
+		_ = os.Remove(rawPath)
+
+		return
+	}
+
+	src := packageSource{
+		Installer: installer,
+		Mtime:     data.buildMtime(epoch),
+		RawPath:   rawPath,
+		Name:      outputBasename(data.OutputFilename.ValueString()),
+		Hardware:  data.virtualHardware(),
+	}
+	artifactValues := make(map[string]attr.Value, len(data.packagings()))
+
+	for _, packaging := range data.packagings() {
+		artifactPath := filepath.Join(outDir, artifactFilename(data.OutputFilename.ValueString(), packaging))
+
+		packageErr := packageImage(ctx, src, packaging, artifactPath)
+		if packageErr != nil {
+			_ = os.Remove(rawPath)
+
+			resp.Diagnostics.AddError("Failed to package disk image for "+packaging, packageErr.Error())
+
+			return
+		}
+
+		artifactDigest, artifactErr := fileSHA256(artifactPath)
+		if artifactErr != nil {
+			_ = os.Remove(rawPath)
+
+			resp.Diagnostics.AddError("Failed to hash "+packaging+" artifact", artifactErr.Error())
+
+			return
+		}
+
+		artifact, artifactDiags := types.ObjectValue(imageArtifactAttrTypes, map[string]attr.Value{
+			"path":   types.StringValue(artifactPath),
+			"sha256": types.StringValue(artifactDigest),
+		})
+		resp.Diagnostics.Append(artifactDiags...)
+
+		artifactValues[packaging] = artifact
+	}
+
+	artifacts, artifactsDiags := types.MapValue(types.ObjectType{AttrTypes: imageArtifactAttrTypes}, artifactValues)
+	resp.Diagnostics.Append(artifactsDiags...)
+
+	if resp.Diagnostics.HasError() {
+		_ = os.Remove(rawPath)
+
+		return
+	}
+
+	// 13. Convert raw → output_format, keeping a raw output sparse
+	if data.outputFormat() == formatRaw {
+		renameErr := os.Rename(rawPath, imagePath)
+		if renameErr != nil {
+			resp.Diagnostics.AddError("Failed to move raw disk image", renameErr.Error())
+
+			return
+		}
+	} else {
+		convertErr := convertDisk(ctx, data.outputFormat(), rawPath, imagePath)
+		if convertErr != nil {
+			resp.Diagnostics.AddError("qemu-img convert failed", convertErr.Error())
+
+			return
+		}
+	}
+
+	// 14. Clean up raw file
+	if rawPath != imagePath {
+		_ = os.Remove(rawPath)
+	}
+
+	// 15. Write the OVMF variable store with the keys enrolled
+	data.OVMFVarsPath = types.StringNull()
+
+	if secureBoot != nil && !data.SecureBoot.OVMFVarsTemplate.IsNull() {
+		varsPath := filepath.Join(outDir, ovmfVarsFilename(data.OutputFilename.ValueString()))
+
+		varsErr := writeOVMFVars(ctx, data.SecureBoot.OVMFVarsTemplate.ValueString(), varsPath, *secureBoot)
+		if varsErr != nil {
+			resp.Diagnostics.AddError("Failed to write OVMF variable store", varsErr.Error())
+
+			return
+		}
+
+		data.OVMFVarsPath = types.StringValue(varsPath)
+	}
+
+	digest, digestErr := fileSHA256(imagePath)
+	if digestErr != nil {
+		resp.Diagnostics.AddError("Failed to hash disk image", digestErr.Error())
+
+		return
+	}
+
+	data.ImagePath = types.StringValue(imagePath)
+	data.ImageSHA256 = types.StringValue(digest)
+	data.InstallConfigTOML = types.StringNull()
+
+	if data.InstallConfig != nil {
+		data.InstallConfigTOML = types.StringValue(installTOML)
+	}
+
+	data.RootFilesystemUUID = types.StringNull()
+
+	partitionValues := make([]attr.Value, 0, len(partitions))
+	for _, part := range partitions {
+		if part.IsRoot() && data.RootFilesystemUUID.IsNull() {
+			data.RootFilesystemUUID = types.StringValue(part.UUID)
+		}
+
+		obj, objDiags := types.ObjectValue(imagePartitionAttrTypes, map[string]attr.Value{
+			"number":     types.Int64Value(int64(part.Number)),
+			"label":      types.StringValue(part.Label),
+			"type":       types.StringValue(part.TypeGUID),
+			"partuuid":   types.StringValue(part.PartUUID),
+			"filesystem": types.StringValue(part.Filesystem),
+			"uuid":       types.StringValue(part.UUID),
+			"start":      types.Int64Value(part.Start),
+			"size":       types.Int64Value(part.Size),
+		})
+		resp.Diagnostics.Append(objDiags...)
+
+		partitionValues = append(partitionValues, obj)
+	}
+
+	partitionList, partitionDiags := types.ListValue(
+		types.ObjectType{AttrTypes: imagePartitionAttrTypes},
+		partitionValues,
+	)
+	resp.Diagnostics.Append(partitionDiags...)
+
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	// 5. Clean up raw file
-	_ = os.Remove(rawPath)
+	effectiveKargsList, kargsDiags := types.ListValueFrom(ctx, types.StringType, effectiveKargs)
+	resp.Diagnostics.Append(kargsDiags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	data.ImagePath = types.StringValue(qcow2Path)
+	if data.ComposefsBackend.ValueBool() {
+		effectiveKargsList = types.ListNull(types.StringType)
+	}
+
+	data.Partitions = partitionList
+	data.Artifacts = artifacts
+	data.EffectiveKargs = effectiveKargsList
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
+// hasCustomizations reports whether the installed system is modified after
+// bootc install.
+func (m *ImageResourceModel) hasCustomizations() bool {
+	return m.customizesDeployment() || m.Ignition != nil
+}
+
+// customizesDeployment reports whether files are written into the
+// installed deployment.
+func (m *ImageResourceModel) customizesDeployment() bool {
+	return len(m.Files) > 0 || len(m.SystemdUnits) > 0 || len(m.Network) > 0 ||
+		!m.HostRegistryAuth.IsNull() || m.AutoUpdate != nil || m.guestAgent() != ""
+}
+
+// partitionsDisk reports whether the provider partitions the disk and runs
+// bootc install to-filesystem instead of to-disk.
+func (m *ImageResourceModel) partitionsDisk() bool {
+	return m.PartitionLayout != nil || m.FilesystemOptions != nil ||
+		m.BlockSetup.ValueString() == blockSetupLUKSPassphrase ||
+		m.PartitionTable.ValueString() == partitionTableMBR || !m.BIOSBoot.IsNull() ||
+		(!m.SectorSize.IsNull() && m.SectorSize.ValueInt64() != defaultSectorSize)
+}
+
+// rootFilesystem returns the root filesystem type of a partition_layout
+// install: install_config.root_fs_type, filesystem, or xfs.
+func (m *ImageResourceModel) rootFilesystem(installCfg installConfig) string {
+	switch {
+	case installCfg.RootFSType != "" && installCfg.AppliesTo(hostArchitecture()):
+		return installCfg.RootFSType
+	case !m.Filesystem.IsNull():
+		return m.Filesystem.ValueString()
+	default:
+		return defaultRootFilesystem
+	}
+}
+
+// buildMtime returns the modification time of files written after the
+// install: the epoch in reproducible mode, otherwise nil for the current
+// time.
+func (m *ImageResourceModel) buildMtime(epoch int64) *time.Time {
+	if m.Reproducible == nil {
+		return nil
+	}
+
+	stamp := time.Unix(epoch, 0)
+
+	return &stamp
+}
+
+// customizeImage writes the configured files, systemd units and network
+// keyfiles into the deployment on rawPath and places the Ignition config on
+// the boot filesystem. In reproducible mode everything written is stamped
+// with the epoch.
+func customizeImage(ctx context.Context, rawPath string, data ImageResourceModel, epoch int64) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	var ignitionConfig []byte
+
+	if data.Ignition != nil {
+		config, _, err := renderIgnition(*data.Ignition)
+		if err != nil {
+			diags.AddAttributeError(path.Root("ignition"), "Invalid Ignition config", err.Error())
+
+			return diags
+		}
+
+		ignitionConfig = config
+	}
+
+	files, err := injectedFiles(data.Files)
+	if err != nil {
+		diags.AddError("Failed to read file content", err.Error())
+
+		return diags
+	}
+
+	units, unitDiags := systemdUnits(ctx, data.SystemdUnits)
+	diags.Append(unitDiags...)
+
+	conns, connDiags := networkConnections(ctx, data.Network)
+	diags.Append(connDiags...)
+
+	if diags.HasError() {
+		return diags
+	}
+
+	keyfiles, err := networkKeyfiles(conns)
+	if err != nil {
+		diags.AddAttributeError(path.Root("network"), "Invalid network configuration", err.Error())
+
+		return diags
+	}
+
+	files = append(files, keyfiles...)
+
+	if !data.HostRegistryAuth.IsNull() {
+		files = append(files, hostRegistryAuthFile(data.HostRegistryAuth.ValueString()))
+	}
+
+	if data.AutoUpdate != nil {
+		units = append(units, autoUpdateUnits(*data.AutoUpdate)...)
+	}
+
+	mtime := data.buildMtime(epoch)
+
+	if data.customizesDeployment() {
+		err = customizeDeployment(ctx, rawPath, func(deployment installedDeployment) error {
+			for _, file := range files {
+				if err := deployment.WriteFile(file, mtime); err != nil {
+					return err
+				}
+			}
+
+			for _, unit := range units {
+				if err := deployment.InstallUnit(unit, mtime); err != nil {
+					return fmt.Errorf("unit %s: %w", unit.Name, err)
+				}
+			}
+
+			// The platform's guest agent is only a hint: images without it
+			// are left as they are.
+			if agent := data.guestAgent(); agent != "" {
+				err := deployment.InstallUnit(systemdUnit{Name: agent, Enabled: true}, mtime)
+				if err != nil && !errors.Is(err, ErrUnitNotFound) {
+					return fmt.Errorf("unit %s: %w", agent, err)
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			diags.AddError("Failed to customize installed deployment", err.Error())
+
+			return diags
+		}
+	}
+
+	if ignitionConfig != nil {
+		err = writeIgnition(ctx, rawPath, ignitionConfig, mtime)
+		if err != nil {
+			diags.AddError("Failed to write Ignition config", err.Error())
+		}
+	}
+
+	return diags
+}
+
+// ModifyPlan derives the default output_filename from the output format,
+// which output_format or platform may change from qcow2.
+func (*ImageResource) ModifyPlan(
+	ctx context.Context,
+	req resource.ModifyPlanRequest,
+	resp *resource.ModifyPlanResponse,
+) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var filename types.String
+
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("output_filename"), &filename)...)
+
+	if resp.Diagnostics.HasError() || !filename.IsNull() {
+		return
+	}
+
+	var data ImageResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	planned := types.StringValue(outputFilename(data.outputFormat()))
+	if data.OutputFormat.IsUnknown() || data.Platform.IsUnknown() {
+		planned = types.StringUnknown()
+	}
+
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("output_filename"), planned)...)
+}
+
 func (*ImageResource) Read(_ context.Context, _ resource.ReadRequest, _ *resource.ReadResponse) {
 }
 
@@ -298,4 +1729,45 @@ func (*ImageResource) Delete(
 	if !data.ImagePath.IsNull() {
 		_ = os.Remove(data.ImagePath.ValueString())
 	}
+
+	if !data.OVMFVarsPath.IsNull() {
+		_ = os.Remove(data.OVMFVarsPath.ValueString())
+	}
+
+	for _, elem := range data.Artifacts.Elements() {
+		artifact, ok := elem.(types.Object)
+		if !ok {
+			continue
+		}
+
+		if artifactPath, ok := artifact.Attributes()["path"].(types.String); ok && !artifactPath.IsNull() {
+			_ = os.Remove(artifactPath.ValueString())
+		}
+	}
+}
+
+// secureBootKeyBlock returns the schema of a secure_boot key block.
+func secureBootKeyBlock(description string) schema.SingleNestedBlock {
+	return schema.SingleNestedBlock{
+		Description: description + " Set exactly one of certificate or certificate_file, and of private_key or private_key_file.",
+		Attributes: map[string]schema.Attribute{
+			"certificate": schema.StringAttribute{
+				Description: "PEM X.509 certificate.",
+				Optional:    true,
+			},
+			"certificate_file": schema.StringAttribute{
+				Description: "Path to a local PEM X.509 certificate.",
+				Optional:    true,
+			},
+			"private_key": schema.StringAttribute{
+				Description: "PEM private key of the certificate.",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"private_key_file": schema.StringAttribute{
+				Description: "Path to a local PEM private key of the certificate.",
+				Optional:    true,
+			},
+		},
+	}
 }
@@ -114,7 +114,7 @@ func TestImageResource_Schema(t *testing.T) {
 	t.Run("optional_attributes", func(t *testing.T) {
 		optionalStrings := []string{
 			"disk_size", "output_filename", "filesystem", "root_size",
-			"root_ssh_authorized_keys", "target_imgref", "bootloader",
+			"root_ssh_authorized_keys", "target_imgref", "bootloader", "stateroot",
 		}
 		for name := range optionalStrings {
 			attr, ok := resp.Schema.Attributes[optionalStrings[name]]
@@ -136,7 +136,7 @@ func TestImageResource_Schema(t *testing.T) {
 	})
 
 	t.Run("optional_bool_attributes", func(t *testing.T) {
-		for _, name := range []string{"disable_selinux", "generic_image"} {
+		for _, name := range []string{"disable_selinux", "generic_image", "luks_rekey_on_first_boot", "composefs_backend"} {
 			attr, ok := resp.Schema.Attributes[name]
 			if !ok {
 				t.Errorf("missing attribute %q", name)
@@ -160,34 +160,256 @@ func TestImageResource_Schema(t *testing.T) {
 	})
 
 	t.Run("list_attributes", func(t *testing.T) {
-		attr, ok := resp.Schema.Attributes["kargs"]
-		if !ok {
-			t.Fatal("missing attribute kargs")
+		for _, name := range []string{"kargs", "kargs_remove", "packaging"} {
+			attr, ok := resp.Schema.Attributes[name]
+			if !ok {
+				t.Fatalf("missing attribute %q", name)
+			}
+
+			la, ok := attr.(schema.ListAttribute)
+			if !ok {
+				t.Fatalf("attribute %q is not ListAttribute", name)
+			}
+
+			if !la.Optional {
+				t.Errorf("%q should be optional", name)
+			}
 		}
+	})
 
-		la, ok := attr.(schema.ListAttribute)
+	t.Run("effective_kargs", func(t *testing.T) {
+		la, ok := resp.Schema.Attributes["effective_kargs"].(schema.ListAttribute)
 		if !ok {
-			t.Fatal("attribute kargs is not ListAttribute")
+			t.Fatal("attribute effective_kargs is not ListAttribute")
 		}
 
-		if !la.Optional {
-			t.Error("kargs should be optional")
+		if !la.Computed || la.Optional {
+			t.Error("effective_kargs should be computed only")
 		}
 	})
 
 	t.Run("computed_attributes", func(t *testing.T) {
-		attr, ok := resp.Schema.Attributes["image_path"]
+		for _, name := range []string{"image_path", "image_sha256", "install_config_toml", "root_filesystem_uuid", "ovmf_vars_path"} {
+			attr, ok := resp.Schema.Attributes[name]
+			if !ok {
+				t.Fatalf("missing computed attribute %q", name)
+			}
+
+			sa, ok := attr.(schema.StringAttribute)
+			if !ok {
+				t.Fatalf("attribute %q is not StringAttribute", name)
+			}
+
+			if !sa.Computed {
+				t.Errorf("%q should be computed", name)
+			}
+		}
+	})
+
+	t.Run("partitions", func(t *testing.T) {
+		la, ok := resp.Schema.Attributes["partitions"].(schema.ListNestedAttribute)
+		if !ok {
+			t.Fatal("attribute partitions is not ListNestedAttribute")
+		}
+
+		if !la.Computed || la.Optional {
+			t.Error("partitions should be computed only")
+		}
+
+		for name := range imagePartitionAttrTypes {
+			if _, ok := la.NestedObject.Attributes[name]; !ok {
+				t.Errorf("partitions missing nested attribute %q", name)
+			}
+		}
+	})
+
+	t.Run("artifacts", func(t *testing.T) {
+		ma, ok := resp.Schema.Attributes["artifacts"].(schema.MapNestedAttribute)
+		if !ok {
+			t.Fatal("attribute artifacts is not MapNestedAttribute")
+		}
+
+		if !ma.Computed || ma.Optional {
+			t.Error("artifacts should be computed only")
+		}
+
+		for name := range imageArtifactAttrTypes {
+			if _, ok := ma.NestedObject.Attributes[name]; !ok {
+				t.Errorf("artifacts missing nested attribute %q", name)
+			}
+		}
+	})
+
+	t.Run("reproducible_block", func(t *testing.T) {
+		block, ok := resp.Schema.Blocks["reproducible"].(schema.SingleNestedBlock)
+		if !ok {
+			t.Fatal("block reproducible is not SingleNestedBlock")
+		}
+
+		seed, ok := block.Attributes["seed"].(schema.StringAttribute)
+		if !ok || !seed.Required {
+			t.Error("reproducible.seed should be a required string")
+		}
+
+		epoch, ok := block.Attributes["source_date_epoch"].(schema.Int64Attribute)
+		if !ok || !epoch.Optional {
+			t.Error("reproducible.source_date_epoch should be an optional int64")
+		}
+	})
+
+	t.Run("files_block", func(t *testing.T) {
+		block, ok := resp.Schema.Blocks["files"].(schema.ListNestedBlock)
+		if !ok {
+			t.Fatal("block files is not ListNestedBlock")
+		}
+
+		for _, name := range []string{"path", "content", "source", "mode", "owner", "group"} {
+			if _, ok := block.NestedObject.Attributes[name]; !ok {
+				t.Errorf("files missing attribute %q", name)
+			}
+		}
+
+		if pa, ok := block.NestedObject.Attributes["path"].(schema.StringAttribute); !ok || !pa.Required {
+			t.Error("files.path should be a required string")
+		}
+	})
+
+	t.Run("systemd_units_block", func(t *testing.T) {
+		block, ok := resp.Schema.Blocks["systemd_units"].(schema.ListNestedBlock)
+		if !ok {
+			t.Fatal("block systemd_units is not ListNestedBlock")
+		}
+
+		for _, name := range []string{"name", "content", "dropins", "enabled", "wanted_by", "mask"} {
+			if _, ok := block.NestedObject.Attributes[name]; !ok {
+				t.Errorf("systemd_units missing attribute %q", name)
+			}
+		}
+	})
+
+	t.Run("network_block", func(t *testing.T) {
+		block, ok := resp.Schema.Blocks["network"].(schema.ListNestedBlock)
+		if !ok {
+			t.Fatal("block network is not ListNestedBlock")
+		}
+
+		for _, name := range []string{
+			"name", "type", "interface_name", "mac_address", "controller", "mtu",
+			"bond_mode", "bond_options", "vlan_id", "vlan_parent",
+			"ipv4_method", "ipv4_addresses", "ipv4_gateway", "ipv4_dns",
+			"ipv6_method", "ipv6_addresses", "ipv6_gateway", "ipv6_dns", "dns_search",
+		} {
+			if _, ok := block.NestedObject.Attributes[name]; !ok {
+				t.Errorf("network missing attribute %q", name)
+			}
+		}
+	})
+
+	t.Run("bound_images_block", func(t *testing.T) {
+		block, ok := resp.Schema.Blocks["bound_images"].(schema.ListNestedBlock)
+		if !ok {
+			t.Fatal("block bound_images is not ListNestedBlock")
+		}
+
+		for _, name := range []string{"image", "pull_policy", "auth_file"} {
+			if _, ok := block.NestedObject.Attributes[name]; !ok {
+				t.Errorf("bound_images missing attribute %q", name)
+			}
+		}
+
+		digest, ok := block.NestedObject.Attributes["digest"].(schema.StringAttribute)
+		if !ok || !digest.Computed || digest.Optional {
+			t.Error("bound_images.digest should be a computed-only string")
+		}
+	})
+
+	t.Run("ignition_block", func(t *testing.T) {
+		block, ok := resp.Schema.Blocks["ignition"].(schema.SingleNestedBlock)
+		if !ok {
+			t.Fatal("block ignition is not SingleNestedBlock")
+		}
+
+		for _, name := range []string{"config", "butane"} {
+			sa, ok := block.Attributes[name].(schema.StringAttribute)
+			if !ok || !sa.Sensitive {
+				t.Errorf("ignition.%s should be a sensitive string", name)
+			}
+		}
+
+		for _, name := range []string{"files_dir", "platform"} {
+			if _, ok := block.Attributes[name]; !ok {
+				t.Errorf("ignition missing attribute %q", name)
+			}
+		}
+	})
+
+	t.Run("install_config_block", func(t *testing.T) {
+		block, ok := resp.Schema.Blocks["install_config"].(schema.SingleNestedBlock)
+		if !ok {
+			t.Fatal("block install_config is not SingleNestedBlock")
+		}
+
+		for _, name := range []string{"root_fs_type", "block", "kargs", "match_architectures"} {
+			if _, ok := block.Attributes[name]; !ok {
+				t.Errorf("install_config missing attribute %q", name)
+			}
+		}
+	})
+
+	t.Run("partition_layout_block", func(t *testing.T) {
+		block, ok := resp.Schema.Blocks["partition_layout"].(schema.SingleNestedBlock)
+		if !ok {
+			t.Fatal("block partition_layout is not SingleNestedBlock")
+		}
+
+		for _, name := range []string{"esp_size", "boot_size", "boot_filesystem", "root_mkfs_options"} {
+			if _, ok := block.Attributes[name]; !ok {
+				t.Errorf("partition_layout missing attribute %q", name)
+			}
+		}
+
+		partition, ok := block.Blocks["partition"].(schema.ListNestedBlock)
+		if !ok {
+			t.Fatal("block partition_layout.partition is not ListNestedBlock")
+		}
+
+		for _, name := range []string{"mount_point", "size", "filesystem", "label", "mkfs_options"} {
+			if _, ok := partition.NestedObject.Attributes[name]; !ok {
+				t.Errorf("partition_layout.partition missing attribute %q", name)
+			}
+		}
+	})
+
+	t.Run("filesystem_options_block", func(t *testing.T) {
+		block, ok := resp.Schema.Blocks["filesystem_options"].(schema.SingleNestedBlock)
+		if !ok {
+			t.Fatal("block filesystem_options is not SingleNestedBlock")
+		}
+
+		for _, name := range []string{"mkfs_options", "mount_options"} {
+			if _, ok := block.Attributes[name]; !ok {
+				t.Errorf("filesystem_options missing attribute %q", name)
+			}
+		}
+
+		btrfs, ok := block.Blocks["btrfs"].(schema.SingleNestedBlock)
 		if !ok {
-			t.Fatal("missing computed attribute image_path")
+			t.Fatal("block filesystem_options.btrfs is not SingleNestedBlock")
 		}
 
-		sa, ok := attr.(schema.StringAttribute)
+		if _, ok := btrfs.Attributes["compression"]; !ok {
+			t.Error("filesystem_options.btrfs missing attribute \"compression\"")
+		}
+
+		subvolume, ok := btrfs.Blocks["subvolume"].(schema.ListNestedBlock)
 		if !ok {
-			t.Fatal("attribute image_path is not StringAttribute")
+			t.Fatal("block filesystem_options.btrfs.subvolume is not ListNestedBlock")
 		}
 
-		if !sa.Computed {
-			t.Error("image_path should be computed")
+		for _, name := range []string{"name", "mount_point", "snapshots"} {
+			if _, ok := subvolume.NestedObject.Attributes[name]; !ok {
+				t.Errorf("filesystem_options.btrfs.subvolume missing attribute %q", name)
+			}
 		}
 	})
 
@@ -209,8 +431,126 @@ func TestImageResource_Schema(t *testing.T) {
 		}
 	})
 
+	t.Run("requires_replace", func(t *testing.T) {
+		for _, name := range []string{
+			"kargs_remove", "host_registry_auth", "platform", "output_format", "packaging", "partition_table",
+			"bios_boot", "sector_size", "block_setup", "luks_passphrase_wo_version", "luks_rekey_on_first_boot",
+			"stateroot", "composefs_backend", "enforce_fs_verity",
+		} {
+			var modifiers int
+
+			switch attr := resp.Schema.Attributes[name].(type) {
+			case schema.StringAttribute:
+				modifiers = len(attr.PlanModifiers)
+			case schema.BoolAttribute:
+				modifiers = len(attr.PlanModifiers)
+			case schema.Int64Attribute:
+				modifiers = len(attr.PlanModifiers)
+			case schema.ListAttribute:
+				modifiers = len(attr.PlanModifiers)
+			default:
+				t.Fatalf("unexpected attribute %q: %T", name, attr)
+			}
+
+			if modifiers == 0 {
+				t.Errorf("attribute %q should require replacement", name)
+			}
+		}
+
+		for name, block := range resp.Schema.Blocks {
+			var modifiers int
+
+			switch block := block.(type) {
+			case schema.ListNestedBlock:
+				modifiers = len(block.PlanModifiers)
+			case schema.SingleNestedBlock:
+				modifiers = len(block.PlanModifiers)
+			}
+
+			if modifiers == 0 {
+				t.Errorf("block %q should require replacement", name)
+			}
+		}
+	})
+
+	t.Run("luks_passphrase_wo", func(t *testing.T) {
+		sa, ok := resp.Schema.Attributes["luks_passphrase_wo"].(schema.StringAttribute)
+		if !ok {
+			t.Fatal("attribute luks_passphrase_wo is not StringAttribute")
+		}
+
+		if !sa.WriteOnly || !sa.Sensitive || !sa.Optional {
+			t.Error("luks_passphrase_wo should be an optional, sensitive, write-only string")
+		}
+	})
+
+	t.Run("host_registry_auth", func(t *testing.T) {
+		sa, ok := resp.Schema.Attributes["host_registry_auth"].(schema.StringAttribute)
+		if !ok || !sa.Sensitive || !sa.Optional {
+			t.Error("host_registry_auth should be an optional, sensitive string")
+		}
+	})
+
+	t.Run("auto_update_block", func(t *testing.T) {
+		block, ok := resp.Schema.Blocks["auto_update"].(schema.SingleNestedBlock)
+		if !ok {
+			t.Fatal("block auto_update is not SingleNestedBlock")
+		}
+
+		for _, name := range []string{"enabled", "schedule", "mode"} {
+			if _, ok := block.Attributes[name]; !ok {
+				t.Errorf("auto_update missing attribute %q", name)
+			}
+		}
+	})
+
+	t.Run("virtual_hardware_block", func(t *testing.T) {
+		block, ok := resp.Schema.Blocks["virtual_hardware"].(schema.SingleNestedBlock)
+		if !ok {
+			t.Fatal("block virtual_hardware is not SingleNestedBlock")
+		}
+
+		for _, name := range []string{"cpus", "memory", "network_adapter", "firmware"} {
+			if _, ok := block.Attributes[name]; !ok {
+				t.Errorf("virtual_hardware missing attribute %q", name)
+			}
+		}
+	})
+
+	t.Run("installer_block", func(t *testing.T) {
+		block, ok := resp.Schema.Blocks["installer"].(schema.SingleNestedBlock)
+		if !ok {
+			t.Fatal("block installer is not SingleNestedBlock")
+		}
+
+		for _, name := range []string{"target_disk", "after_install", "kargs"} {
+			if _, ok := block.Attributes[name]; !ok {
+				t.Errorf("installer missing attribute %q", name)
+			}
+		}
+	})
+
+	t.Run("secure_boot_block", func(t *testing.T) {
+		block, ok := resp.Schema.Blocks["secure_boot"].(schema.SingleNestedBlock)
+		if !ok {
+			t.Fatal("block secure_boot is not SingleNestedBlock")
+		}
+
+		for _, name := range []string{"pk", "kek", "db"} {
+			key, ok := block.Blocks[name].(schema.SingleNestedBlock)
+			if !ok {
+				t.Fatalf("secure_boot block %q is not SingleNestedBlock", name)
+			}
+
+			privateKey, ok := key.Attributes["private_key"].(schema.StringAttribute)
+			if !ok || !privateKey.Sensitive {
+				t.Errorf("secure_boot %s private_key should be a sensitive string", name)
+			}
+		}
+	})
+
 	t.Run("attribute_count", func(t *testing.T) {
-		want := 13
+		want := 35
 		if got := len(resp.Schema.Attributes); got != want {
 			t.Errorf("attribute count = %d, want %d", got, want)
 		}
@@ -401,6 +741,70 @@ func TestStringOneOfValidator(t *testing.T) {
 	}
 }
 
+func TestStringPathPrefixValidator(t *testing.T) {
+	val := stringPathPrefix("/etc", "/var")
+
+	tests := []struct {
+		name    string
+		val     types.String
+		wantErr bool
+	}{
+		{"etc", types.StringValue("/etc/chrony.conf"), false},
+		{"var_nested", types.StringValue("/var/lib/app/config"), false},
+		{"relative", types.StringValue("etc/chrony.conf"), true},
+		{"usr", types.StringValue("/usr/lib/os-release"), true},
+		{"escape", types.StringValue("/etc/../usr/bin/sh"), true},
+		{"prefix_only", types.StringValue("/etc"), true},
+		{"similar_prefix", types.StringValue("/etcetera/file"), true},
+		{"null_skipped", types.StringNull(), false},
+		{"unknown_skipped", types.StringUnknown(), false},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			req := validator.StringRequest{ConfigValue: testCase.val}
+			resp := &validator.StringResponse{}
+			val.ValidateString(t.Context(), req, resp)
+
+			if testCase.wantErr != resp.Diagnostics.HasError() {
+				t.Errorf("HasError = %v, want %v", resp.Diagnostics.HasError(), testCase.wantErr)
+			}
+		})
+	}
+}
+
+func TestStringFileModeValidator(t *testing.T) {
+	val := stringFileMode()
+
+	tests := []struct {
+		name    string
+		val     types.String
+		wantErr bool
+	}{
+		{"leading_zero", types.StringValue("0644"), false},
+		{"short", types.StringValue("600"), false},
+		{"setuid", types.StringValue("4755"), false},
+		{"not_octal", types.StringValue("0999"), true},
+		{"symbolic", types.StringValue("u+rw"), true},
+		{"too_large", types.StringValue("17777"), true},
+		{"null_skipped", types.StringNull(), false},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			req := validator.StringRequest{ConfigValue: testCase.val}
+			resp := &validator.StringResponse{}
+			val.ValidateString(t.Context(), req, resp)
+
+			if testCase.wantErr != resp.Diagnostics.HasError() {
+				t.Errorf("HasError = %v, want %v", resp.Diagnostics.HasError(), testCase.wantErr)
+			}
+		})
+	}
+}
+
 func TestImageResource_BootcArgs(t *testing.T) {
 	tests := []struct {
 		name     string
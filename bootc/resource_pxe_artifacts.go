@@ -0,0 +1,284 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const defaultPXEPrefix = "bootc"
+
+var (
+	_ resource.Resource                   = &PXEArtifactsResource{}
+	_ resource.ResourceWithValidateConfig = &PXEArtifactsResource{}
+)
+
+// PXEArtifactsResource implements the bootc_pxe_artifacts Terraform
+// resource.
+type PXEArtifactsResource struct{}
+
+type PXEArtifactsResourceModel struct {
+	SourceImage    types.String `tfsdk:"source_image"`
+	OutputPath     types.String `tfsdk:"output_path"`
+	OutputPrefix   types.String `tfsdk:"output_prefix"`
+	BaseURL        types.String `tfsdk:"base_url"`
+	Kargs          types.List   `tfsdk:"kargs"`
+	KernelPath     types.String `tfsdk:"kernel_path"`
+	InitramfsPath  types.String `tfsdk:"initramfs_path"`
+	RootfsPath     types.String `tfsdk:"rootfs_path"`
+	IPXEScriptPath types.String `tfsdk:"ipxe_script_path"`
+	RootfsSHA256   types.String `tfsdk:"rootfs_sha256"`
+	LiveKargs      types.List   `tfsdk:"live_kargs"`
+}
+
+func NewPXEArtifactsResource() resource.Resource {
+	return &PXEArtifactsResource{}
+}
+
+func (*PXEArtifactsResource) Metadata(
+	_ context.Context,
+	req resource.MetadataRequest,
+	resp *resource.MetadataResponse,
+) {
+	resp.TypeName = req.ProviderTypeName + "_pxe_artifacts"
+}
+
+func (*PXEArtifactsResource) Schema(
+	_ context.Context,
+	_ resource.SchemaRequest,
+	resp *resource.SchemaResponse,
+) {
+	replace := []planmodifier.String{stringplanmodifier.RequiresReplace()}
+	computed := []planmodifier.String{stringplanmodifier.UseStateForUnknown()}
+
+	resp.Schema = schema.Schema{
+		Description: "Extracts the kernel, a live initramfs and the root filesystem of a bootc container image " +
+			"for a live network boot over PXE or iPXE.",
+		Attributes: map[string]schema.Attribute{
+			"source_image": schema.StringAttribute{
+				Description:   "Container image reference (e.g. quay.io/fedora/fedora-bootc:41).",
+				Required:      true,
+				PlanModifiers: replace,
+			},
+			"output_path": schema.StringAttribute{
+				Description:   "Directory where the artifacts will be written.",
+				Required:      true,
+				PlanModifiers: replace,
+			},
+			"output_prefix": schema.StringAttribute{
+				Description:   "File name prefix of the artifacts: <prefix>-vmlinuz, <prefix>-initrd.img, <prefix>-rootfs.img and <prefix>.ipxe.",
+				Optional:      true,
+				Computed:      true,
+				Default:       stringdefault.StaticString(defaultPXEPrefix),
+				PlanModifiers: replace,
+			},
+			"base_url": schema.StringAttribute{
+				Description:   "URL the provisioning server serves output_path at. The live kernel arguments download the rootfs from it.",
+				Required:      true,
+				PlanModifiers: replace,
+				Validators: []validator.String{
+					stringURL("http", "https", "ftp", "tftp"),
+				},
+			},
+			"kargs": schema.ListAttribute{
+				Description: "Extra kernel arguments of the live system (e.g. [\"console=ttyS0,115200n8\"]).",
+				Optional:    true,
+				ElementType: types.StringType,
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.RequiresReplace(),
+				},
+			},
+			"kernel_path": schema.StringAttribute{
+				Description:   "Full path to the kernel.",
+				Computed:      true,
+				PlanModifiers: computed,
+			},
+			"initramfs_path": schema.StringAttribute{
+				Description:   "Full path to the initramfs, generated with dracut's dmsquash-live and livenet modules.",
+				Computed:      true,
+				PlanModifiers: computed,
+			},
+			"rootfs_path": schema.StringAttribute{
+				Description:   "Full path to the squashfs root filesystem.",
+				Computed:      true,
+				PlanModifiers: computed,
+			},
+			"ipxe_script_path": schema.StringAttribute{
+				Description:   "Full path to the iPXE script booting the artifacts from base_url.",
+				Computed:      true,
+				PlanModifiers: computed,
+			},
+			"rootfs_sha256": schema.StringAttribute{
+				Description:   "SHA-256 digest of the root filesystem.",
+				Computed:      true,
+				PlanModifiers: computed,
+			},
+			"live_kargs": schema.ListAttribute{
+				Description: "Kernel command line of the live boot, without the initrd= argument of the boot loader.",
+				Computed:    true,
+				ElementType: types.StringType,
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+func (*PXEArtifactsResource) ValidateConfig(
+	ctx context.Context,
+	req resource.ValidateConfigRequest,
+	resp *resource.ValidateConfigResponse,
+) {
+	var data PXEArtifactsResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(validatePXEArtifacts(&data)...)
+}
+
+// validatePXEArtifacts checks the artifact file name prefix and the live
+// kernel arguments.
+func validatePXEArtifacts(data *PXEArtifactsResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if prefix := data.OutputPrefix; knownString(prefix) &&
+		(prefix.ValueString() == "" || prefix.ValueString() == "." || prefix.ValueString() == ".." ||
+			strings.ContainsAny(prefix.ValueString(), "/ \t\n'\"")) {
+		diags.AddAttributeError(path.Root("output_prefix"), "Invalid output prefix",
+			"Expected a file name prefix without slashes or whitespace, got: "+prefix.ValueString())
+	}
+
+	diags.Append(validateLiveKargs(data.Kargs, path.Root("kargs"))...)
+
+	return diags
+}
+
+func (*PXEArtifactsResource) Create(
+	ctx context.Context,
+	req resource.CreateRequest,
+	resp *resource.CreateResponse,
+) {
+	var data PXEArtifactsResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var kargs []string
+
+	resp.Diagnostics.Append(data.Kargs.ElementsAs(ctx, &kargs, false)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	outDir := data.OutputPath.ValueString()
+
+	mkdirErr := os.MkdirAll(outDir, 0o755)
+	if mkdirErr != nil {
+		resp.Diagnostics.AddError("Failed to create output directory", mkdirErr.Error())
+
+		return
+	}
+
+	artifacts := newPXEArtifacts(outDir, data.OutputPrefix.ValueString())
+	liveKargs := pxeKargs(data.BaseURL.ValueString(), artifacts, kargs)
+
+	writeErr := writePXEArtifacts(ctx, data.SourceImage.ValueString(), artifacts,
+		ipxeScript(data.BaseURL.ValueString(), artifacts, liveKargs))
+	if writeErr != nil {
+		removeFiles(artifacts.Paths())
+		resp.Diagnostics.AddError("Failed to extract PXE artifacts", writeErr.Error())
+
+		return
+	}
+
+	digest, digestErr := fileSHA256(artifacts.Rootfs)
+	if digestErr != nil {
+		removeFiles(artifacts.Paths())
+		resp.Diagnostics.AddError("Failed to hash PXE root filesystem", digestErr.Error())
+
+		return
+	}
+
+	liveKargsValue, diags := types.ListValueFrom(ctx, types.StringType, liveKargs)
+	resp.Diagnostics.Append(diags...)
+
+	data.KernelPath = types.StringValue(artifacts.Kernel)
+	data.InitramfsPath = types.StringValue(artifacts.Initramfs)
+	data.RootfsPath = types.StringValue(artifacts.Rootfs)
+	data.IPXEScriptPath = types.StringValue(artifacts.IPXEScript)
+	data.RootfsSHA256 = types.StringValue(digest)
+	data.LiveKargs = liveKargsValue
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// removeFiles removes paths, ignoring errors.
+func removeFiles(paths []string) {
+	for _, target := range paths {
+		_ = os.Remove(target)
+	}
+}
+
+func (*PXEArtifactsResource) Read(_ context.Context, _ resource.ReadRequest, _ *resource.ReadResponse) {
+}
+
+func (*PXEArtifactsResource) Update(
+	_ context.Context,
+	_ resource.UpdateRequest,
+	resp *resource.UpdateResponse,
+) {
+	resp.Diagnostics.AddError("Update not supported",
+		"bootc_pxe_artifacts is immutable. Changes require replacement.")
+}
+
+func (*PXEArtifactsResource) Delete(
+	ctx context.Context,
+	req resource.DeleteRequest,
+	resp *resource.DeleteResponse,
+) {
+	var data PXEArtifactsResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	for _, attr := range []types.String{data.KernelPath, data.InitramfsPath, data.RootfsPath, data.IPXEScriptPath} {
+		if !attr.IsNull() {
+			_ = os.Remove(attr.ValueString())
+		}
+	}
+}
@@ -0,0 +1,163 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestPXEArtifactsResource_Metadata(t *testing.T) {
+	resp := &resource.MetadataResponse{}
+	NewPXEArtifactsResource().Metadata(t.Context(), resource.MetadataRequest{ProviderTypeName: providerTypeName}, resp)
+
+	if resp.TypeName != "bootc_pxe_artifacts" {
+		t.Errorf("TypeName = %q, want %q", resp.TypeName, "bootc_pxe_artifacts")
+	}
+}
+
+func TestPXEArtifactsResource_Schema(t *testing.T) {
+	resp := &resource.SchemaResponse{}
+	NewPXEArtifactsResource().Schema(t.Context(), resource.SchemaRequest{}, resp)
+
+	tests := []struct {
+		name     string
+		required bool
+		computed bool
+	}{
+		{"source_image", true, false},
+		{"output_path", true, false},
+		{"output_prefix", false, true},
+		{"base_url", true, false},
+		{"kernel_path", false, true},
+		{"initramfs_path", false, true},
+		{"rootfs_path", false, true},
+		{"ipxe_script_path", false, true},
+		{"rootfs_sha256", false, true},
+	}
+
+	if want := len(tests) + 2; len(resp.Schema.Attributes) != want {
+		t.Errorf("attributes = %d, want %d", len(resp.Schema.Attributes), want)
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			sa, ok := resp.Schema.Attributes[testCase.name].(schema.StringAttribute)
+			if !ok {
+				t.Fatalf("attribute %q is not StringAttribute", testCase.name)
+			}
+
+			if sa.Required != testCase.required || sa.Computed != testCase.computed {
+				t.Errorf("required=%v computed=%v", sa.Required, sa.Computed)
+			}
+		})
+	}
+
+	t.Run("list_attributes", func(t *testing.T) {
+		kargs, ok := resp.Schema.Attributes["kargs"].(schema.ListAttribute)
+		if !ok || !kargs.Optional {
+			t.Error("kargs should be an optional list")
+		}
+
+		liveKargs, ok := resp.Schema.Attributes["live_kargs"].(schema.ListAttribute)
+		if !ok || !liveKargs.Computed {
+			t.Error("live_kargs should be a computed list")
+		}
+	})
+}
+
+func TestPXEArtifactsResource_Update(t *testing.T) {
+	resp := &resource.UpdateResponse{}
+	NewPXEArtifactsResource().Update(t.Context(), resource.UpdateRequest{}, resp)
+
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected error from Update")
+	}
+}
+
+func TestValidatePXEArtifacts(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    PXEArtifactsResourceModel
+		wantErr string
+	}{
+		{"defaults", PXEArtifactsResourceModel{OutputPrefix: types.StringValue(defaultPXEPrefix)}, ""},
+		{"unknown_prefix", PXEArtifactsResourceModel{OutputPrefix: types.StringUnknown()}, ""},
+		{"nested_prefix", PXEArtifactsResourceModel{OutputPrefix: types.StringValue("edge/node")}, "Invalid output prefix"},
+		{"dot_prefix", PXEArtifactsResourceModel{OutputPrefix: types.StringValue("..")}, "Invalid output prefix"},
+		{"kargs", PXEArtifactsResourceModel{
+			OutputPrefix: types.StringValue(defaultPXEPrefix),
+			Kargs:        packagingList("console=ttyS0", ""),
+		}, "Invalid kernel argument"},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			diags := validatePXEArtifacts(&testCase.data)
+
+			if testCase.wantErr == "" {
+				if diags.HasError() {
+					t.Errorf("unexpected diagnostics: %v", diags)
+				}
+
+				return
+			}
+
+			if !diags.HasError() || diags.Errors()[0].Summary() != testCase.wantErr {
+				t.Errorf("diagnostics = %v, want %q", diags, testCase.wantErr)
+			}
+		})
+	}
+}
+
+func TestStringURLValidator(t *testing.T) {
+	val := stringURL("http", "https", "tftp")
+
+	tests := []struct {
+		name    string
+		val     types.String
+		wantErr bool
+	}{
+		{"http", types.StringValue("http://10.0.0.1/pxe"), false},
+		{"https_port", types.StringValue("https://boot.example.com:8443/edge/"), false},
+		{"tftp", types.StringValue("tftp://10.0.0.1"), false},
+		{"relative", types.StringValue("/srv/pxe"), true},
+		{"scheme", types.StringValue("nfs://10.0.0.1/pxe"), true},
+		{"space", types.StringValue("http://10.0.0.1/my pxe"), true},
+		{"null_skipped", types.StringNull(), false},
+		{"unknown_skipped", types.StringUnknown(), false},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			req := validator.StringRequest{ConfigValue: testCase.val}
+			resp := &validator.StringResponse{}
+			val.ValidateString(t.Context(), req, resp)
+
+			if testCase.wantErr != resp.Diagnostics.HasError() {
+				t.Errorf("HasError = %v, want %v", resp.Diagnostics.HasError(), testCase.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,765 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	// autoEnrollKeys is the key set systemd-boot enrolls without user
+	// interaction, below /loader/keys of the ESP.
+	autoEnrollKeys   = "auto"
+	loaderConfPath   = "loader/loader.conf"
+	secureBootEnroll = "secure-boot-enroll"
+
+	enrollManual = "manual"
+	enrollIfSafe = "if-safe"
+	enrollForce  = "force"
+)
+
+var ErrInvalidKeyPair = errors.New("invalid Secure Boot key pair")
+
+// SecureBootModel is the secure_boot block of bootc_image.
+type SecureBootModel struct {
+	PK               *SecureBootKeyModel `tfsdk:"pk"`
+	KEK              *SecureBootKeyModel `tfsdk:"kek"`
+	DB               *SecureBootKeyModel `tfsdk:"db"`
+	OwnerGUID        types.String        `tfsdk:"owner_guid"`
+	Enroll           types.String        `tfsdk:"enroll"`
+	OVMFVarsTemplate types.String        `tfsdk:"ovmf_vars_template"`
+	UKI              types.Bool          `tfsdk:"uki"`
+}
+
+// SecureBootKeyModel is a certificate and private key of the secure_boot
+// block, each given inline or as a local PEM file.
+type SecureBootKeyModel struct {
+	Certificate     types.String `tfsdk:"certificate"`
+	CertificateFile types.String `tfsdk:"certificate_file"`
+	PrivateKey      types.String `tfsdk:"private_key"`
+	PrivateKeyFile  types.String `tfsdk:"private_key_file"`
+}
+
+// secureBootKey is a PEM certificate and its private key.
+type secureBootKey struct {
+	Certificate []byte
+	PrivateKey  []byte
+}
+
+// secureBootSetup is a resolved secure_boot block. PK and KEK are nil
+// when no keys are enrolled.
+type secureBootSetup struct {
+	PK        *secureBootKey
+	KEK       *secureBootKey
+	DB        *secureBootKey
+	OwnerGUID string
+	Enroll    string
+	UKI       bool
+}
+
+// bootEntry is the subset of a Boot Loader Specification type #1 entry
+// needed to build a UKI from it.
+type bootEntry struct {
+	Title   string
+	Version string
+	SortKey string
+	Linux   string
+	EFI     string
+	Options string
+	Initrd  []string
+}
+
+// secureBootKeyFromModel reads the certificate and private key of a key
+// block and checks that they belong together.
+func secureBootKeyFromModel(model SecureBootKeyModel) (*secureBootKey, error) {
+	var (
+		key secureBootKey
+		err error
+	)
+
+	key.Certificate, err = contentOrFile(model.Certificate, model.CertificateFile)
+	if err != nil {
+		return nil, err
+	}
+
+	key.PrivateKey, err = contentOrFile(model.PrivateKey, model.PrivateKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	err = key.Check()
+	if err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// contentOrFile returns content, or the content of the local file path.
+func contentOrFile(content, file types.String) ([]byte, error) {
+	if !content.IsNull() {
+		return []byte(content.ValueString()), nil
+	}
+
+	return os.ReadFile(file.ValueString())
+}
+
+// secureBootSetupFromModel resolves a secure_boot block. The owner GUID
+// defaults to one derived from the db certificate, so rebuilding with the
+// same keys enrolls identical signature lists.
+func secureBootSetupFromModel(model SecureBootModel) (*secureBootSetup, error) {
+	setup := secureBootSetup{
+		OwnerGUID: model.OwnerGUID.ValueString(),
+		Enroll:    model.Enroll.ValueString(),
+		UKI:       model.UKI.IsNull() || model.UKI.ValueBool(),
+	}
+
+	for _, key := range []struct {
+		name   string
+		model  *SecureBootKeyModel
+		target **secureBootKey
+	}{
+		{"pk", model.PK, &setup.PK},
+		{"kek", model.KEK, &setup.KEK},
+		{"db", model.DB, &setup.DB},
+	} {
+		if key.model == nil {
+			continue
+		}
+
+		resolved, err := secureBootKeyFromModel(*key.model)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key.name, err)
+		}
+
+		*key.target = resolved
+	}
+
+	if setup.Enroll == "" {
+		setup.Enroll = enrollIfSafe
+	}
+
+	if setup.DB == nil {
+		return nil, fmt.Errorf("%w: the db key is not set", ErrInvalidKeyPair)
+	}
+
+	if setup.OwnerGUID == "" {
+		setup.OwnerGUID = deriveUUID(string(setup.DB.Certificate), "secure-boot-owner")
+	}
+
+	return &setup, nil
+}
+
+// Check parses the certificate and private key and verifies that the key
+// matches the certificate's public key.
+func (k secureBootKey) Check() error {
+	certBlock, _ := pem.Decode(k.Certificate)
+	if certBlock == nil || certBlock.Type != "CERTIFICATE" {
+		return fmt.Errorf("%w: certificate is not a PEM CERTIFICATE", ErrInvalidKeyPair)
+	}
+
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidKeyPair, err)
+	}
+
+	keyBlock, _ := pem.Decode(k.PrivateKey)
+	if keyBlock == nil || !strings.HasSuffix(keyBlock.Type, "PRIVATE KEY") {
+		return fmt.Errorf("%w: private key is not a PEM PRIVATE KEY", ErrInvalidKeyPair)
+	}
+
+	signer, err := parsePrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidKeyPair, err)
+	}
+
+	public, ok := signer.Public().(interface{ Equal(x crypto.PublicKey) bool })
+	if !ok || !public.Equal(cert.PublicKey) {
+		return fmt.Errorf("%w: private key does not match the certificate of %s", ErrInvalidKeyPair, cert.Subject)
+	}
+
+	return nil
+}
+
+// DER returns the certificate in DER encoding, as signature lists hold it.
+func (k secureBootKey) DER() []byte {
+	block, _ := pem.Decode(k.Certificate)
+
+	return block.Bytes
+}
+
+// parsePrivateKey parses a PKCS #8, PKCS #1 or SEC 1 private key.
+func parsePrivateKey(der []byte) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("%w: unsupported key type %T", ErrInvalidKeyPair, key)
+		}
+
+		return signer, nil
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+
+	return x509.ParseECPrivateKey(der)
+}
+
+// Enrolls reports whether the setup enrolls its keys on first boot.
+func (s secureBootSetup) Enrolls() bool {
+	return s.PK != nil && s.KEK != nil
+}
+
+// parseBootEntry parses a type #1 boot loader entry.
+func parseBootEntry(content string) bootEntry {
+	var entry bootEntry
+
+	for line := range strings.SplitSeq(content, "\n") {
+		key, value, _ := strings.Cut(strings.TrimSpace(line), " ")
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "title":
+			entry.Title = value
+		case "version":
+			entry.Version = value
+		case "sort-key":
+			entry.SortKey = value
+		case "linux":
+			entry.Linux = value
+		case "efi":
+			entry.EFI = value
+		case "options":
+			entry.Options = strings.TrimSpace(entry.Options + " " + value)
+		case "initrd":
+			entry.Initrd = append(entry.Initrd, value)
+		}
+	}
+
+	return entry
+}
+
+// OSRelease returns the os-release embedded in the entry's UKI, which
+// systemd-boot uses to name and sort it.
+func (e bootEntry) OSRelease() string {
+	id := e.SortKey
+	if id == "" {
+		id = "linux"
+	}
+
+	return fmt.Sprintf("ID=%s\nPRETTY_NAME=%q\nVERSION_ID=%q\n", id, e.Title, e.Version)
+}
+
+// UkifyArgs returns the ukify arguments that build a UKI from the entry,
+// whose paths are relative to root, and sign it with the db key in keyDir.
+func (e bootEntry) UkifyArgs(root, osRelease, keyDir, output string) []string {
+	args := []string{
+		"build",
+		"--linux", filepath.Join(root, e.Linux),
+		"--cmdline", e.Options,
+		"--os-release", "@" + osRelease,
+		"--secureboot-private-key", filepath.Join(keyDir, "db.key"),
+		"--secureboot-certificate", filepath.Join(keyDir, "db.crt"),
+		"--output", output,
+	}
+
+	if e.Version != "" {
+		args = append(args, "--uname", e.Version)
+	}
+
+	for _, initrd := range e.Initrd {
+		args = append(args, "--initrd", filepath.Join(root, initrd))
+	}
+
+	return args
+}
+
+// writeKeys writes the certificates and private keys to keyDir as
+// <name>.crt, <name>.der and <name>.key.
+func (s secureBootSetup) writeKeys(keyDir string) error {
+	for name, key := range map[string]*secureBootKey{"PK": s.PK, "KEK": s.KEK, "db": s.DB} {
+		if key == nil {
+			continue
+		}
+
+		for suffix, content := range map[string][]byte{".crt": key.Certificate, ".der": key.DER(), ".key": key.PrivateKey} {
+			err := os.WriteFile(filepath.Join(keyDir, name+suffix), content, 0o600)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// signEFIBinaries signs every EFI binary below the EFI directory of the
+// ESP with the db key, replacing the vendor signature.
+func signEFIBinaries(ctx context.Context, espDir, keyDir string, mtime *time.Time) error {
+	return filepath.WalkDir(filepath.Join(espDir, "EFI"), func(binary string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || !strings.EqualFold(filepath.Ext(binary), ".efi") {
+			return err
+		}
+
+		return signEFIBinary(ctx, binary, keyDir, mtime)
+	})
+}
+
+// signEFIBinary signs binary in place with the db key.
+func signEFIBinary(ctx context.Context, binary, keyDir string, mtime *time.Time) error {
+	signed := binary + ".signed"
+
+	_, err := runCommand(ctx, "sbsign", "--key", filepath.Join(keyDir, "db.key"),
+		"--cert", filepath.Join(keyDir, "db.crt"), "--output", signed, binary)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(signed, binary)
+	if err == nil && mtime != nil {
+		err = lchtimes(binary, *mtime)
+	}
+
+	return err
+}
+
+// signKernels signs the kernel of each type #1 entry below entryDirs with
+// the db key, so systemd-boot can load the entries without UKIs once
+// Secure Boot is enforced. Kernels shared by several entries are signed
+// once.
+func signKernels(ctx context.Context, entryDirs []string, keyDir string, mtime *time.Time) error {
+	signed := make(map[string]bool)
+
+	for _, root := range entryDirs {
+		entries, err := filepath.Glob(filepath.Join(root, bootEntriesGlob))
+		if err != nil {
+			return err
+		}
+
+		for _, entryPath := range entries {
+			content, err := os.ReadFile(entryPath)
+			if err != nil {
+				return err
+			}
+
+			entry := parseBootEntry(string(content))
+			kernel := filepath.Join(root, entry.Linux)
+
+			if entry.Linux == "" || entry.EFI != "" || signed[kernel] {
+				continue
+			}
+
+			err = signEFIBinary(ctx, kernel, keyDir, mtime)
+			if err != nil {
+				return fmt.Errorf("%s: %w", filepath.Base(entryPath), err)
+			}
+
+			signed[kernel] = true
+		}
+	}
+
+	return nil
+}
+
+// buildUKIs builds a signed UKI from each type #1 entry below entryDirs
+// and rewrites the entry to boot it. The entries are kept, as ostree finds
+// its deployments through their ostree= argument. Each UKI is written next
+// to the entry's kernel instead of EFI/Linux, where systemd-boot would list
+// it a second time, and is pruned by ostree together with the kernel.
+// Entries that already boot an EFI binary are kept as they are.
+func buildUKIs(ctx context.Context, entryDirs []string, keyDir string, mtime *time.Time) error {
+	for _, root := range entryDirs {
+		entries, err := filepath.Glob(filepath.Join(root, bootEntriesGlob))
+		if err != nil {
+			return err
+		}
+
+		for _, entryPath := range entries {
+			content, err := os.ReadFile(entryPath)
+			if err != nil {
+				return err
+			}
+
+			entry := parseBootEntry(string(content))
+			if entry.Linux == "" || entry.EFI != "" {
+				continue
+			}
+
+			osRelease := filepath.Join(keyDir, "os-release")
+
+			err = os.WriteFile(osRelease, []byte(entry.OSRelease()), 0o600)
+			if err != nil {
+				return err
+			}
+
+			uki := filepath.Join(filepath.Dir(entry.Linux), strings.TrimSuffix(filepath.Base(entryPath), ".conf")+".efi")
+			output := filepath.Join(root, uki)
+
+			_, err = runCommand(ctx, "ukify", entry.UkifyArgs(root, osRelease, keyDir, output)...)
+			if err != nil {
+				return fmt.Errorf("%s: %w", filepath.Base(entryPath), err)
+			}
+
+			err = writeUKIEntry(entryPath, string(content), uki, mtime)
+			if err == nil && mtime != nil {
+				err = lchtimes(output, *mtime)
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// writeUKIEntry rewrites a type #1 entry to boot the UKI at efi, relative to
+// the entry's partition.
+func writeUKIEntry(entryPath, content, efi string, mtime *time.Time) error {
+	info, err := os.Stat(entryPath)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(entryPath, []byte(ukiBootEntry(content, efi)), info.Mode().Perm())
+	if err == nil && mtime != nil {
+		err = lchtimes(entryPath, *mtime)
+	}
+
+	return err
+}
+
+// ukiBootEntry replaces the linux and initrd lines of a type #1 entry with
+// an efi line, keeping its title, version, sort key and options.
+func ukiBootEntry(content, efi string) string {
+	var lines []string
+
+	for line := range strings.SplitSeq(content, "\n") {
+		key, _, _ := strings.Cut(strings.TrimSpace(line), " ")
+
+		switch key {
+		case "linux":
+			lines = append(lines, "efi "+efi)
+		case "initrd":
+			// The UKI embeds the initramfs.
+		default:
+			lines = append(lines, line)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// writeEnrollment writes the signed PK, KEK and db variables systemd-boot
+// enrolls from /loader/keys/auto and sets its enrollment mode. PK signs
+// itself and KEK; KEK signs db.
+func (s secureBootSetup) writeEnrollment(ctx context.Context, espDir, keyDir string, mtime *time.Time) error {
+	target := filepath.Join(espDir, "loader", "keys", autoEnrollKeys)
+
+	err := mkdirAllLabeled(target)
+	if err != nil {
+		return err
+	}
+
+	for _, variable := range []struct{ name, signer string }{
+		{"PK", "PK"}, {"KEK", "PK"}, {"db", "KEK"},
+	} {
+		esl := filepath.Join(keyDir, variable.name+".esl")
+
+		_, err = runCommand(ctx, "sbsiglist", "--owner", s.OwnerGUID, "--type", "x509",
+			"--output", esl, filepath.Join(keyDir, variable.name+".der"))
+		if err != nil {
+			return err
+		}
+
+		auth := filepath.Join(target, variable.name+".auth")
+
+		_, err = runCommand(ctx, "sbvarsign", "--key", filepath.Join(keyDir, variable.signer+".key"),
+			"--cert", filepath.Join(keyDir, variable.signer+".crt"), "--output", auth, variable.name, esl)
+		if err != nil {
+			return err
+		}
+
+		if mtime != nil {
+			err = lchtimes(auth, *mtime)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	loaderConf := filepath.Join(espDir, loaderConfPath)
+
+	content, err := os.ReadFile(loaderConf)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	err = writeFileMode(loaderConf, []byte(setLoaderOption(string(content), secureBootEnroll, s.Enroll)), defaultFileMode)
+	if err == nil && mtime != nil {
+		err = lchtimes(loaderConf, *mtime)
+	}
+
+	return err
+}
+
+// setLoaderOption sets key in a loader.conf, replacing an existing line.
+func setLoaderOption(content, key, value string) string {
+	var lines []string
+
+	for line := range strings.SplitSeq(strings.TrimSuffix(content, "\n"), "\n") {
+		name, _, _ := strings.Cut(strings.TrimSpace(line), " ")
+		if line == "" || name == key {
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	return strings.Join(append(lines, key+" "+value), "\n") + "\n"
+}
+
+// espPartition returns the EFI system partition.
+func espPartition(partitions []installedPartition) (installedPartition, bool) {
+	for _, part := range partitions {
+		if part.TypeGUID == espPartitionType || part.TypeGUID == mbrESPType || part.Label == "EFI-SYSTEM" {
+			return part, true
+		}
+	}
+
+	return installedPartition{}, false
+}
+
+// secureBootImage signs the EFI binaries of a raw disk, builds signed UKIs
+// from its boot loader entries and prepares key enrollment.
+func secureBootImage(ctx context.Context, rawPath string, setup secureBootSetup, mtime *time.Time) error {
+	partitions, err := readInstalledPartitions(ctx, rawPath)
+	if err != nil {
+		return err
+	}
+
+	esp, found := espPartition(partitions)
+	if !found {
+		return fmt.Errorf("%w: no EFI system partition", ErrDeploymentNotFound)
+	}
+
+	keyDir, err := os.MkdirTemp("", "bootc-secureboot-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(keyDir)
+
+	err = setup.writeKeys(keyDir)
+	if err != nil {
+		return err
+	}
+
+	mounts, err := newDiskMounts(rawPath)
+	if err != nil {
+		return err
+	}
+
+	err = func() error {
+		espDir, err := mounts.Mount(ctx, esp)
+		if err != nil {
+			return err
+		}
+
+		entryDirs := []string{espDir}
+
+		if boot, subdir, ok := bootFilesystem(partitions); ok && boot.Number != esp.Number {
+			bootDir, err := mounts.Mount(ctx, boot)
+			if err != nil {
+				return err
+			}
+
+			entryDirs = append(entryDirs, filepath.Join(bootDir, subdir))
+		}
+
+		// Sign first, so the UKIs ukify signs are not signed twice.
+		err = signEFIBinaries(ctx, espDir, keyDir, mtime)
+		if err != nil {
+			return err
+		}
+
+		if setup.UKI {
+			err = buildUKIs(ctx, entryDirs, keyDir, mtime)
+		} else {
+			err = signKernels(ctx, entryDirs, keyDir, mtime)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if !setup.Enrolls() {
+			return nil
+		}
+
+		return setup.writeEnrollment(ctx, espDir, keyDir, mtime)
+	}()
+
+	return errors.Join(err, mounts.Close(ctx))
+}
+
+// writeOVMFVars writes an OVMF variable store with the setup's keys
+// enrolled and Secure Boot enabled, for booting the image under QEMU.
+func writeOVMFVars(ctx context.Context, template, output string, setup secureBootSetup) error {
+	keyDir, err := os.MkdirTemp("", "bootc-ovmf-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(keyDir)
+
+	err = setup.writeKeys(keyDir)
+	if err != nil {
+		return err
+	}
+
+	_, err = runCommand(ctx, "virt-fw-vars", "--input", template, "--output", output, "--secure-boot",
+		"--set-pk", setup.OwnerGUID, filepath.Join(keyDir, "PK.crt"),
+		"--add-kek", setup.OwnerGUID, filepath.Join(keyDir, "KEK.crt"),
+		"--add-db", setup.OwnerGUID, filepath.Join(keyDir, "db.crt"))
+
+	return err
+}
+
+// ovmfVarsFilename returns the variable store name next to the image
+// (disk.qcow2 → disk_VARS.fd).
+func ovmfVarsFilename(outputFilename string) string {
+	return strings.TrimSuffix(outputFilename, filepath.Ext(outputFilename)) + "_VARS.fd"
+}
+
+// validateSecureBoot checks the secure_boot block against the bootloader
+// and the keys each feature needs.
+func validateSecureBoot(data *ImageResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	model := data.SecureBoot
+	if model == nil {
+		return diags
+	}
+
+	blockPath := path.Root("secure_boot")
+
+	if !data.Bootloader.IsUnknown() && data.Bootloader.ValueString() != "systemd" {
+		diags.AddAttributeError(blockPath, "Invalid Secure Boot options",
+			"secure_boot requires bootloader = \"systemd\".")
+	}
+
+	if model.DB == nil {
+		diags.AddAttributeError(blockPath.AtName("db"), "Missing Secure Boot key",
+			"The db block with the signing key must be set.")
+	}
+
+	if (model.PK == nil) != (model.KEK == nil) {
+		diags.AddAttributeError(blockPath, "Missing Secure Boot key",
+			"pk and kek must be set together to enroll keys.")
+	}
+
+	if !model.OVMFVarsTemplate.IsNull() && (model.PK == nil || model.KEK == nil) {
+		diags.AddAttributeError(blockPath.AtName("ovmf_vars_template"), "Missing Secure Boot key",
+			"ovmf_vars_template requires the pk and kek blocks.")
+	}
+
+	if !model.Enroll.IsNull() && model.PK == nil {
+		diags.AddAttributeError(blockPath.AtName("enroll"), "Invalid Secure Boot options",
+			"enroll requires the pk and kek blocks.")
+	}
+
+	if knownString(model.OwnerGUID) {
+		if _, err := parseGUID(model.OwnerGUID.ValueString()); err != nil {
+			diags.AddAttributeError(blockPath.AtName("owner_guid"), "Invalid owner GUID", err.Error())
+		}
+	}
+
+	if data.AutoUpdate != nil {
+		diags.AddAttributeError(path.Root("auto_update"), "Conflicting Secure Boot options",
+			"bootc upgrade writes unsigned boot loader entries and bootupd replaces the signed boot loader, "+
+				"so secure_boot images cannot be upgraded in place. Rebuild the image for each release instead.")
+	}
+
+	if data.Reproducible != nil {
+		diags.AddAttributeError(blockPath, "Conflicting Secure Boot options",
+			"The UKIs seal the root filesystem UUID, so signing runs after the reproducible identifiers are set and "+
+				"writes to the ESP through a read-write mount; signed key enrollment variables also carry the signing "+
+				"time. secure_boot cannot be reproducible.")
+	}
+
+	for _, key := range []struct {
+		name  string
+		model *SecureBootKeyModel
+	}{
+		{"pk", model.PK}, {"kek", model.KEK}, {"db", model.DB},
+	} {
+		if key.model != nil {
+			diags.Append(validateSecureBootKey(blockPath.AtName(key.name), key.model)...)
+		}
+	}
+
+	return diags
+}
+
+// validateSecureBootKey checks that a key block sets each of its
+// certificate and private key exactly once, and that inline PEM content
+// forms a key pair. Files are read at apply time.
+func validateSecureBootKey(keyPath path.Path, model *SecureBootKeyModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	for _, pair := range []struct{ name, file types.String }{
+		{model.Certificate, model.CertificateFile},
+		{model.PrivateKey, model.PrivateKeyFile},
+	} {
+		if pair.name.IsUnknown() || pair.file.IsUnknown() {
+			return diags
+		}
+
+		if pair.name.IsNull() == pair.file.IsNull() {
+			diags.AddAttributeError(keyPath, "Invalid Secure Boot key",
+				"Exactly one of certificate or certificate_file, and of private_key or private_key_file, must be set.")
+
+			return diags
+		}
+	}
+
+	if model.Certificate.IsNull() || model.PrivateKey.IsNull() {
+		return diags
+	}
+
+	key := secureBootKey{Certificate: []byte(model.Certificate.ValueString()), PrivateKey: []byte(model.PrivateKey.ValueString())}
+	if err := key.Check(); err != nil {
+		diags.AddAttributeError(keyPath, "Invalid Secure Boot key", err.Error())
+	}
+
+	return diags
+}
@@ -0,0 +1,348 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// testKeyPair returns a self-signed PEM certificate and its PKCS #8 key.
+func testKeyPair(t *testing.T, name string) secureBootKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Unix(0, 0),
+		NotAfter:     time.Unix(0, 0).AddDate(100, 0, 0),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey() error = %v", err)
+	}
+
+	return secureBootKey{
+		Certificate: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		PrivateKey:  pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+// testKeyModel returns an inline key block of key.
+func testKeyModel(key secureBootKey) *SecureBootKeyModel {
+	return &SecureBootKeyModel{
+		Certificate:     types.StringValue(string(key.Certificate)),
+		CertificateFile: types.StringNull(),
+		PrivateKey:      types.StringValue(string(key.PrivateKey)),
+		PrivateKeyFile:  types.StringNull(),
+	}
+}
+
+func TestSecureBootKey_Check(t *testing.T) {
+	db := testKeyPair(t, "db")
+	other := testKeyPair(t, "other")
+
+	tests := []struct {
+		name    string
+		key     secureBootKey
+		wantErr bool
+	}{
+		{"pair", db, false},
+		{"mismatch", secureBootKey{Certificate: db.Certificate, PrivateKey: other.PrivateKey}, true},
+		{"not_pem", secureBootKey{Certificate: []byte("db"), PrivateKey: db.PrivateKey}, true},
+		{"key_as_certificate", secureBootKey{Certificate: db.PrivateKey, PrivateKey: db.PrivateKey}, true},
+		{"certificate_as_key", secureBootKey{Certificate: db.Certificate, PrivateKey: db.Certificate}, true},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.key.Check()
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("Check() error = %v, wantErr %v", err, testCase.wantErr)
+			}
+
+			if err != nil && !errors.Is(err, ErrInvalidKeyPair) {
+				t.Errorf("Check() error = %v, want ErrInvalidKeyPair", err)
+			}
+		})
+	}
+}
+
+func TestSecureBootSetupFromModel(t *testing.T) {
+	db := testKeyPair(t, "db")
+
+	t.Run("defaults", func(t *testing.T) {
+		setup, err := secureBootSetupFromModel(SecureBootModel{DB: testKeyModel(db)})
+		if err != nil {
+			t.Fatalf("secureBootSetupFromModel() error = %v", err)
+		}
+
+		if setup.Enroll != enrollIfSafe || !setup.UKI || setup.Enrolls() {
+			t.Errorf("setup = %+v, want if-safe UKIs without enrollment", setup)
+		}
+
+		if _, err := parseGUID(setup.OwnerGUID); err != nil {
+			t.Errorf("OwnerGUID = %q: %v", setup.OwnerGUID, err)
+		}
+
+		again, err := secureBootSetupFromModel(SecureBootModel{DB: testKeyModel(db)})
+		if err != nil || again.OwnerGUID != setup.OwnerGUID {
+			t.Errorf("OwnerGUID = %q, want stable %q", again.OwnerGUID, setup.OwnerGUID)
+		}
+	})
+
+	t.Run("key_file", func(t *testing.T) {
+		keyPath := filepath.Join(t.TempDir(), "db.key")
+
+		err := os.WriteFile(keyPath, db.PrivateKey, testSecureFilePerms)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		model := testKeyModel(db)
+		model.PrivateKey = types.StringNull()
+		model.PrivateKeyFile = types.StringValue(keyPath)
+
+		setup, err := secureBootSetupFromModel(SecureBootModel{
+			DB:        model,
+			OwnerGUID: types.StringValue("8be4df61-93ca-11d2-aa0d-00e098032b8c"),
+			UKI:       types.BoolValue(false),
+		})
+		if err != nil {
+			t.Fatalf("secureBootSetupFromModel() error = %v", err)
+		}
+
+		if setup.OwnerGUID != "8be4df61-93ca-11d2-aa0d-00e098032b8c" || setup.UKI {
+			t.Errorf("setup = %+v", setup)
+		}
+	})
+
+	t.Run("missing_db", func(t *testing.T) {
+		_, err := secureBootSetupFromModel(SecureBootModel{})
+		if !errors.Is(err, ErrInvalidKeyPair) {
+			t.Errorf("secureBootSetupFromModel() error = %v, want ErrInvalidKeyPair", err)
+		}
+	})
+}
+
+func TestParseBootEntry(t *testing.T) {
+	entry := parseBootEntry(`title Fedora Linux 42 (ostree:0)
+version 1
+sort-key fedora
+options root=UUID=abcd rw
+options console=ttyS0
+linux /boot/ostree/default-abcd/vmlinuz-6.14.0
+initrd /boot/ostree/default-abcd/initramfs-6.14.0.img
+`)
+
+	if entry.Title != "Fedora Linux 42 (ostree:0)" || entry.Version != "1" || entry.SortKey != "fedora" {
+		t.Errorf("entry = %+v", entry)
+	}
+
+	if entry.Options != "root=UUID=abcd rw console=ttyS0" {
+		t.Errorf("Options = %q", entry.Options)
+	}
+
+	args := entry.UkifyArgs("/mnt/boot", "/tmp/os-release", "/tmp/keys", "/mnt/esp/EFI/Linux/ostree-1.efi")
+
+	for _, want := range [][]string{
+		{"--linux", "/mnt/boot/boot/ostree/default-abcd/vmlinuz-6.14.0"},
+		{"--initrd", "/mnt/boot/boot/ostree/default-abcd/initramfs-6.14.0.img"},
+		{"--cmdline", "root=UUID=abcd rw console=ttyS0"},
+		{"--os-release", "@/tmp/os-release"},
+		{"--secureboot-private-key", "/tmp/keys/db.key"},
+		{"--output", "/mnt/esp/EFI/Linux/ostree-1.efi"},
+	} {
+		idx := slices.Index(args, want[0])
+		if idx < 0 || idx+1 >= len(args) || args[idx+1] != want[1] {
+			t.Errorf("UkifyArgs() = %v, want %s %s", args, want[0], want[1])
+		}
+	}
+
+	if got, want := entry.OSRelease(), "ID=fedora\nPRETTY_NAME=\"Fedora Linux 42 (ostree:0)\"\nVERSION_ID=\"1\"\n"; got != want {
+		t.Errorf("OSRelease() = %q, want %q", got, want)
+	}
+}
+
+func TestUKIBootEntry(t *testing.T) {
+	content := `title Fedora Linux 42 (ostree:0)
+version 1
+options root=UUID=abcd rw ostree=/ostree/boot.1/default/abcd/0
+linux /ostree/default-abcd/vmlinuz-6.14.0
+initrd /ostree/default-abcd/initramfs-6.14.0.img
+`
+	want := `title Fedora Linux 42 (ostree:0)
+version 1
+options root=UUID=abcd rw ostree=/ostree/boot.1/default/abcd/0
+efi /ostree/default-abcd/ostree-1-default.efi
+`
+
+	if got := ukiBootEntry(content, "/ostree/default-abcd/ostree-1-default.efi"); got != want {
+		t.Errorf("ukiBootEntry() = %q, want %q", got, want)
+	}
+
+	if entry := parseBootEntry(want); entry.EFI == "" || entry.Linux != "" {
+		t.Errorf("rewritten entry = %+v, want it to boot the UKI", entry)
+	}
+}
+
+func TestSetLoaderOption(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"empty", "", "secure-boot-enroll force\n"},
+		{"append", "timeout 3\n", "timeout 3\nsecure-boot-enroll force\n"},
+		{"replace", "secure-boot-enroll manual\ntimeout 3\n", "timeout 3\nsecure-boot-enroll force\n"},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := setLoaderOption(testCase.content, secureBootEnroll, enrollForce); got != testCase.want {
+				t.Errorf("setLoaderOption() = %q, want %q", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestEspPartition(t *testing.T) {
+	partitions := []installedPartition{
+		{Label: "BIOS-BOOT", Number: 1},
+		{TypeGUID: espPartitionType, Number: 2},
+		{Label: "root", Number: 3},
+	}
+
+	esp, found := espPartition(partitions)
+	if !found || esp.Number != 2 {
+		t.Errorf("espPartition() = %+v, %v, want partition 2", esp, found)
+	}
+
+	if _, found := espPartition(partitions[2:]); found {
+		t.Error("espPartition() found an ESP in a layout without one")
+	}
+}
+
+func TestOVMFVarsFilename(t *testing.T) {
+	for input, want := range map[string]string{
+		"disk.qcow2": "disk_VARS.fd",
+		"vm.img":     "vm_VARS.fd",
+		"disk":       "disk_VARS.fd",
+	} {
+		if got := ovmfVarsFilename(input); got != want {
+			t.Errorf("ovmfVarsFilename(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestValidateSecureBoot(t *testing.T) {
+	pk := testKeyModel(testKeyPair(t, "pk"))
+	kek := testKeyModel(testKeyPair(t, "kek"))
+	db := testKeyModel(testKeyPair(t, "db"))
+
+	systemd := types.StringValue("systemd")
+
+	tests := []struct {
+		name    string
+		data    ImageResourceModel
+		wantErr string
+	}{
+		{"none", ImageResourceModel{}, ""},
+		{"db_only", ImageResourceModel{Bootloader: systemd, SecureBoot: &SecureBootModel{DB: db}}, ""},
+		{"enroll", ImageResourceModel{Bootloader: systemd, SecureBoot: &SecureBootModel{
+			PK: pk, KEK: kek, DB: db,
+			Enroll:           types.StringValue(enrollForce),
+			OVMFVarsTemplate: types.StringValue("/usr/share/edk2/ovmf/OVMF_VARS.fd"),
+		}}, ""},
+		{"grub", ImageResourceModel{Bootloader: types.StringValue("grub"), SecureBoot: &SecureBootModel{DB: db}}, "Invalid Secure Boot options"},
+		{"missing_db", ImageResourceModel{Bootloader: systemd, SecureBoot: &SecureBootModel{}}, "Missing Secure Boot key"},
+		{"pk_without_kek", ImageResourceModel{Bootloader: systemd, SecureBoot: &SecureBootModel{PK: pk, DB: db}}, "Missing Secure Boot key"},
+		{"template_without_pk", ImageResourceModel{Bootloader: systemd, SecureBoot: &SecureBootModel{
+			DB:               db,
+			OVMFVarsTemplate: types.StringValue("/usr/share/edk2/ovmf/OVMF_VARS.fd"),
+		}}, "Missing Secure Boot key"},
+		{"enroll_without_pk", ImageResourceModel{Bootloader: systemd, SecureBoot: &SecureBootModel{
+			DB: db, Enroll: types.StringValue(enrollForce),
+		}}, "Invalid Secure Boot options"},
+		{"owner_guid", ImageResourceModel{Bootloader: systemd, SecureBoot: &SecureBootModel{
+			DB: db, OwnerGUID: types.StringValue("owner"),
+		}}, "Invalid owner GUID"},
+		{"reproducible", ImageResourceModel{Bootloader: systemd, Reproducible: &ReproducibleModel{}, SecureBoot: &SecureBootModel{
+			DB: db,
+		}}, "Conflicting Secure Boot options"},
+		{"auto_update", ImageResourceModel{Bootloader: systemd, AutoUpdate: &AutoUpdateModel{}, SecureBoot: &SecureBootModel{
+			DB: db,
+		}}, "Conflicting Secure Boot options"},
+		{"certificate_twice", ImageResourceModel{Bootloader: systemd, SecureBoot: &SecureBootModel{DB: &SecureBootKeyModel{
+			Certificate:     db.Certificate,
+			CertificateFile: types.StringValue("/etc/pki/db.crt"),
+			PrivateKey:      db.PrivateKey,
+			PrivateKeyFile:  types.StringNull(),
+		}}}, "Invalid Secure Boot key"},
+		{"mismatched_key", ImageResourceModel{Bootloader: systemd, SecureBoot: &SecureBootModel{DB: &SecureBootKeyModel{
+			Certificate:     db.Certificate,
+			CertificateFile: types.StringNull(),
+			PrivateKey:      pk.PrivateKey,
+			PrivateKeyFile:  types.StringNull(),
+		}}}, "Invalid Secure Boot key"},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			diags := validateSecureBoot(&testCase.data)
+
+			if testCase.wantErr == "" {
+				if diags.HasError() {
+					t.Errorf("unexpected diagnostics: %v", diags)
+				}
+
+				return
+			}
+
+			if !diags.HasError() || diags.Errors()[0].Summary() != testCase.wantErr {
+				t.Errorf("diagnostics = %v, want %q", diags, testCase.wantErr)
+			}
+		})
+	}
+}
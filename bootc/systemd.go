@@ -0,0 +1,311 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	systemdAdminDir  = "/etc/systemd/system"
+	systemdVendorDir = "/usr/lib/systemd/system"
+)
+
+var ErrUnitNotFound = errors.New("unit file not found")
+
+// unitSuffixes are the unit types that can be installed as files.
+var unitSuffixes = []string{
+	".service", ".socket", ".device", ".mount", ".automount", ".swap",
+	".target", ".path", ".timer", ".slice", ".scope",
+}
+
+// SystemdUnitModel is an entry of the systemd_units block of bootc_image.
+type SystemdUnitModel struct {
+	Name     types.String `tfsdk:"name"`
+	Content  types.String `tfsdk:"content"`
+	Dropins  types.Map    `tfsdk:"dropins"`
+	WantedBy types.List   `tfsdk:"wanted_by"`
+	Enabled  types.Bool   `tfsdk:"enabled"`
+	Mask     types.Bool   `tfsdk:"mask"`
+}
+
+// systemdUnit is a resolved systemd_units entry.
+type systemdUnit struct {
+	Dropins  map[string]string
+	Name     string
+	Content  string
+	WantedBy []string
+	Enabled  bool
+	Mask     bool
+}
+
+// unitInstall holds the [Install] section directives that create symlinks.
+type unitInstall struct {
+	WantedBy   []string
+	RequiredBy []string
+	Alias      []string
+}
+
+// systemdUnits converts the systemd_units block.
+func systemdUnits(ctx context.Context, models []SystemdUnitModel) ([]systemdUnit, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	units := make([]systemdUnit, 0, len(models))
+
+	for _, model := range models {
+		unit := systemdUnit{
+			Name:    model.Name.ValueString(),
+			Content: model.Content.ValueString(),
+			Enabled: model.Enabled.ValueBool(),
+			Mask:    model.Mask.ValueBool(),
+		}
+
+		if !model.Dropins.IsNull() {
+			diags.Append(model.Dropins.ElementsAs(ctx, &unit.Dropins, false)...)
+		}
+
+		if !model.WantedBy.IsNull() {
+			diags.Append(model.WantedBy.ElementsAs(ctx, &unit.WantedBy, false)...)
+		}
+
+		units = append(units, unit)
+	}
+
+	return units, diags
+}
+
+// parseUnitInstall extracts WantedBy=, RequiredBy= and Alias= from the
+// [Install] section of a unit file.
+func parseUnitInstall(content string) unitInstall {
+	var (
+		install   unitInstall
+		inInstall bool
+	)
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+			continue
+		case strings.HasPrefix(line, "["):
+			inInstall = line == "[Install]"
+
+			continue
+		case !inInstall:
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+
+		values := strings.Fields(value)
+
+		switch strings.TrimSpace(key) {
+		case "WantedBy":
+			install.WantedBy = append(install.WantedBy, values...)
+		case "RequiredBy":
+			install.RequiredBy = append(install.RequiredBy, values...)
+		case "Alias":
+			install.Alias = append(install.Alias, values...)
+		}
+	}
+
+	return install
+}
+
+// unitSuffix returns the type suffix of a unit name, including the dot.
+func unitSuffix(name string) string {
+	dot := strings.LastIndex(name, ".")
+	if dot <= 0 {
+		return ""
+	}
+
+	return name[dot:]
+}
+
+// unitTemplate returns the template name of an instance unit
+// (getty@tty1.service → getty@.service), or name itself.
+func unitTemplate(name string) string {
+	prefix, rest, ok := strings.Cut(name, "@")
+	if !ok {
+		return name
+	}
+
+	if dot := strings.LastIndex(rest, "."); dot >= 0 {
+		return prefix + "@" + rest[dot:]
+	}
+
+	return name
+}
+
+// InstallUnit writes a unit's file and drop-ins into the deployment and
+// masks or enables it the way systemctl would, using the unit's [Install]
+// section unless explicit targets are given.
+func (d installedDeployment) InstallUnit(unit systemdUnit, mtime *time.Time) error {
+	adminPath := filepath.Join(systemdAdminDir, unit.Name)
+
+	var files []injectedFile
+
+	if unit.Mask {
+		files = append(files, injectedFile{Path: adminPath, Symlink: "/dev/null"})
+	} else if unit.Content != "" {
+		files = append(files, injectedFile{Path: adminPath, Content: []byte(unit.Content), Mode: defaultFileMode})
+	}
+
+	dropinNames := make([]string, 0, len(unit.Dropins))
+	for name := range unit.Dropins {
+		dropinNames = append(dropinNames, name)
+	}
+
+	slices.Sort(dropinNames)
+
+	for _, name := range dropinNames {
+		files = append(files, injectedFile{
+			Path:    filepath.Join(systemdAdminDir, unit.Name+".d", name),
+			Content: []byte(unit.Dropins[name]),
+			Mode:    defaultFileMode,
+		})
+	}
+
+	if unit.Enabled && !unit.Mask {
+		links, err := d.enablementLinks(unit)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, links...)
+	}
+
+	for _, file := range files {
+		if err := d.WriteFile(file, mtime); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// enablementLinks returns the .wants/.requires and alias symlinks that
+// enable a unit.
+func (d installedDeployment) enablementLinks(unit systemdUnit) ([]injectedFile, error) {
+	unitPath, content, err := d.findUnit(unit)
+	if err != nil {
+		return nil, err
+	}
+
+	install := unitInstall{WantedBy: unit.WantedBy}
+	if len(install.WantedBy) == 0 {
+		install = parseUnitInstall(content)
+	}
+
+	var links []injectedFile
+
+	for _, target := range install.WantedBy {
+		links = append(links, injectedFile{Path: filepath.Join(systemdAdminDir, target+".wants", unit.Name), Symlink: unitPath})
+	}
+
+	for _, target := range install.RequiredBy {
+		links = append(links, injectedFile{Path: filepath.Join(systemdAdminDir, target+".requires", unit.Name), Symlink: unitPath})
+	}
+
+	for _, alias := range install.Alias {
+		links = append(links, injectedFile{Path: filepath.Join(systemdAdminDir, alias), Symlink: unitPath})
+	}
+
+	return links, nil
+}
+
+// findUnit returns the path of a unit in the booted system and its
+// content, preferring the admin directory over the vendor directory.
+func (d installedDeployment) findUnit(unit systemdUnit) (string, string, error) {
+	if unit.Content != "" {
+		return filepath.Join(systemdAdminDir, unit.Name), unit.Content, nil
+	}
+
+	template := unitTemplate(unit.Name)
+
+	for _, dir := range []string{systemdAdminDir, systemdVendorDir} {
+		for _, name := range slices.Compact([]string{unit.Name, template}) {
+			unitPath := filepath.Join(dir, name)
+
+			content, err := os.ReadFile(d.DeployPath(unitPath))
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+
+			if err != nil {
+				return "", "", err
+			}
+
+			return unitPath, string(content), nil
+		}
+	}
+
+	return "", "", fmt.Errorf("%w: %s", ErrUnitNotFound, unit.Name)
+}
+
+// validateSystemdUnit checks a systemd_units entry for conflicting options.
+func validateSystemdUnit(entryPath path.Path, unit SystemdUnitModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if !unit.Name.IsUnknown() {
+		name := unit.Name.ValueString()
+		if strings.Contains(name, "/") || !slices.Contains(unitSuffixes, unitSuffix(name)) {
+			diags.AddAttributeError(entryPath.AtName("name"), "Invalid unit name",
+				"Expected a unit file name such as agent.service, got: "+name)
+		}
+	}
+
+	if unit.Mask.ValueBool() && (!unit.Content.IsNull() || unit.Enabled.ValueBool()) {
+		diags.AddAttributeError(entryPath.AtName("mask"), "Conflicting unit options",
+			"A masked unit cannot have content or be enabled.")
+	}
+
+	if !unit.WantedBy.IsNull() && !unit.Enabled.ValueBool() {
+		diags.AddAttributeError(entryPath.AtName("wanted_by"), "Conflicting unit options",
+			"wanted_by requires enabled = true.")
+	}
+
+	if unit.Dropins.IsNull() || unit.Dropins.IsUnknown() {
+		return diags
+	}
+
+	for name := range unit.Dropins.Elements() {
+		if strings.Contains(name, "/") || !strings.HasSuffix(name, ".conf") {
+			diags.AddAttributeError(entryPath.AtName("dropins").AtMapKey(name), "Invalid drop-in name",
+				"Drop-in file names must end in .conf and contain no slashes, got: "+name)
+		}
+	}
+
+	return diags
+}
@@ -0,0 +1,204 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const testAgentUnit = `[Unit]
+Description=Site agent
+
+[Service]
+ExecStart=/usr/bin/agent
+# WantedBy=ignored.target
+
+[Install]
+WantedBy=multi-user.target
+RequiredBy=network-online.target
+Alias=site-agent.service
+`
+
+func TestParseUnitInstall(t *testing.T) {
+	install := parseUnitInstall(testAgentUnit + "WantedBy=graphical.target timers.target\n")
+
+	if !slices.Equal(install.WantedBy, []string{"multi-user.target", "graphical.target", "timers.target"}) {
+		t.Errorf("WantedBy = %v", install.WantedBy)
+	}
+
+	if !slices.Equal(install.RequiredBy, []string{"network-online.target"}) {
+		t.Errorf("RequiredBy = %v", install.RequiredBy)
+	}
+
+	if !slices.Equal(install.Alias, []string{"site-agent.service"}) {
+		t.Errorf("Alias = %v", install.Alias)
+	}
+
+	if empty := parseUnitInstall("[Service]\nWantedBy=multi-user.target\n"); len(empty.WantedBy) != 0 {
+		t.Errorf("WantedBy outside [Install] = %v", empty.WantedBy)
+	}
+}
+
+func TestUnitTemplate(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"getty@tty1.service", "getty@.service"},
+		{"container@web.prod.service", "container@.service"},
+		{"getty@.service", "getty@.service"},
+		{"sshd.service", "sshd.service"},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := unitTemplate(testCase.name); got != testCase.want {
+				t.Errorf("unitTemplate = %q, want %q", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestInstalledDeployment_InstallUnit(t *testing.T) {
+	requireRoot(t)
+
+	deployment, err := findDeployment(buildTestDeployment(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vendorDir := deployment.DeployPath(systemdVendorDir)
+	if err := os.MkdirAll(vendorDir, testDefaultDirPerms); err != nil {
+		t.Fatal(err)
+	}
+
+	gettyUnit := "[Service]\nExecStart=/sbin/agetty %I\n\n[Install]\nWantedBy=getty.target\n"
+	if err := os.WriteFile(filepath.Join(vendorDir, "getty@.service"), []byte(gettyUnit), testSecureFilePerms); err != nil {
+		t.Fatal(err)
+	}
+
+	units := []systemdUnit{
+		{Name: "agent.service", Content: testAgentUnit, Enabled: true, Dropins: map[string]string{"10-env.conf": "[Service]\nEnvironment=SITE=eu1\n"}},
+		{Name: "getty@ttyS0.service", Enabled: true},
+		{Name: "getty@tty2.service", Enabled: true, WantedBy: []string{"multi-user.target"}},
+		{Name: "dnf-makecache.timer", Mask: true},
+	}
+
+	for _, unit := range units {
+		if err := deployment.InstallUnit(unit, nil); err != nil {
+			t.Fatalf("%s: %v", unit.Name, err)
+		}
+	}
+
+	adminDir := deployment.DeployPath(systemdAdminDir)
+
+	wantLinks := map[string]string{
+		"multi-user.target.wants/agent.service":        "/etc/systemd/system/agent.service",
+		"network-online.target.requires/agent.service": "/etc/systemd/system/agent.service",
+		"site-agent.service":                           "/etc/systemd/system/agent.service",
+		"getty.target.wants/getty@ttyS0.service":       "/usr/lib/systemd/system/getty@.service",
+		"multi-user.target.wants/getty@tty2.service":   "/usr/lib/systemd/system/getty@.service",
+		"dnf-makecache.timer":                          "/dev/null",
+	}
+
+	for link, want := range wantLinks {
+		got, err := os.Readlink(filepath.Join(adminDir, link))
+		if err != nil || got != want {
+			t.Errorf("%s -> %q, %v, want %q", link, got, err, want)
+		}
+	}
+
+	if _, err := os.Lstat(filepath.Join(adminDir, "getty.target.wants", "getty@tty2.service")); !errors.Is(err, os.ErrNotExist) {
+		t.Error("explicit wanted_by should replace the [Install] section")
+	}
+
+	dropin, err := os.ReadFile(filepath.Join(adminDir, "agent.service.d", "10-env.conf"))
+	if err != nil || string(dropin) != "[Service]\nEnvironment=SITE=eu1\n" {
+		t.Errorf("drop-in = %q, %v", dropin, err)
+	}
+
+	err = deployment.InstallUnit(systemdUnit{Name: "missing.service", Enabled: true}, nil)
+	if !errors.Is(err, ErrUnitNotFound) {
+		t.Errorf("expected ErrUnitNotFound, got %v", err)
+	}
+}
+
+func TestValidateSystemdUnit(t *testing.T) {
+	dropins := func(names ...string) types.Map {
+		elems := map[string]attr.Value{}
+		for _, name := range names {
+			elems[name] = types.StringValue("")
+		}
+
+		return types.MapValueMust(types.StringType, elems)
+	}
+
+	base := SystemdUnitModel{
+		Name:     types.StringValue("agent.service"),
+		Content:  types.StringNull(),
+		Dropins:  types.MapNull(types.StringType),
+		WantedBy: types.ListNull(types.StringType),
+		Enabled:  types.BoolNull(),
+		Mask:     types.BoolNull(),
+	}
+
+	tests := []struct {
+		modify  func(*SystemdUnitModel)
+		name    string
+		wantErr bool
+	}{
+		{func(*SystemdUnitModel) {}, "minimal", false},
+		{func(u *SystemdUnitModel) { u.Name = types.StringValue("agent") }, "no_suffix", true},
+		{func(u *SystemdUnitModel) { u.Name = types.StringValue("../agent.service") }, "slash", true},
+		{func(u *SystemdUnitModel) { u.Mask = types.BoolValue(true) }, "mask", false},
+		{func(u *SystemdUnitModel) {
+			u.Mask = types.BoolValue(true)
+			u.Content = types.StringValue("[Unit]\n")
+		}, "mask_with_content", true},
+		{func(u *SystemdUnitModel) {
+			u.Mask = types.BoolValue(true)
+			u.Enabled = types.BoolValue(true)
+		}, "mask_enabled", true},
+		{func(u *SystemdUnitModel) {
+			u.WantedBy = types.ListValueMust(types.StringType, []attr.Value{types.StringValue("multi-user.target")})
+		}, "wanted_by_disabled", true},
+		{func(u *SystemdUnitModel) { u.Dropins = dropins("10-env.conf") }, "dropin", false},
+		{func(u *SystemdUnitModel) { u.Dropins = dropins("override") }, "dropin_suffix", true},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			unit := base
+			testCase.modify(&unit)
+
+			diags := validateSystemdUnit(path.Root("systemd_units").AtListIndex(0), unit)
+			if diags.HasError() != testCase.wantErr {
+				t.Errorf("HasError = %v, want %v: %v", diags.HasError(), testCase.wantErr, diags)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	// ostreeAuthPath is where bootc reads registry credentials for
+	// upgrades of the booted system.
+	ostreeAuthPath = "/etc/ostree/auth.json"
+
+	updateTimer   = "bootc-fetch-apply-updates.timer"
+	updateService = "bootc-fetch-apply-updates.service"
+	updateDropin  = "50-auto-update.conf"
+
+	updateModeApply = "apply"
+	updateModeStage = "stage"
+)
+
+// AutoUpdateModel is the auto_update block of bootc_image.
+type AutoUpdateModel struct {
+	Schedule types.String `tfsdk:"schedule"`
+	Mode     types.String `tfsdk:"mode"`
+	Enabled  types.Bool   `tfsdk:"enabled"`
+}
+
+// hostRegistryAuthFile returns the /etc/ostree/auth.json of the installed
+// system. It is readable by root only, as it holds credentials.
+func hostRegistryAuthFile(auth string) injectedFile {
+	return injectedFile{Path: ostreeAuthPath, Content: []byte(auth), Mode: 0o600}
+}
+
+// autoUpdateUnits returns the drop-ins configuring the update timer and
+// service shipped by bootc. A disabled timer is masked, as bootc images
+// enable it through presets.
+func autoUpdateUnits(model AutoUpdateModel) []systemdUnit {
+	if !model.Enabled.IsNull() && !model.Enabled.ValueBool() {
+		return []systemdUnit{{Name: updateTimer, Mask: true}}
+	}
+
+	timer := systemdUnit{Name: updateTimer, Enabled: true}
+
+	// Clearing OnBootSec and OnUnitInactiveSec replaces the default
+	// interval with the calendar schedule.
+	if schedule := model.Schedule.ValueString(); schedule != "" {
+		timer.Dropins = map[string]string{
+			updateDropin: "[Timer]\nOnBootSec=\nOnUnitInactiveSec=\nOnCalendar=" + schedule + "\nPersistent=true\n",
+		}
+	}
+
+	units := []systemdUnit{timer}
+
+	if model.Mode.ValueString() == updateModeStage {
+		units = append(units, systemdUnit{
+			Name: updateService,
+			Dropins: map[string]string{
+				updateDropin: "[Service]\nExecStart=\nExecStart=/usr/bin/bootc upgrade --quiet\n",
+			},
+		})
+	}
+
+	return units
+}
+
+// validateHostRegistryAuth checks that host_registry_auth is a
+// containers-auth.json(5) document with at least one registry.
+func validateHostRegistryAuth(auth types.String) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if !knownString(auth) {
+		return diags
+	}
+
+	var file registryAuthFile
+
+	err := json.Unmarshal([]byte(auth.ValueString()), &file)
+	if err != nil {
+		diags.AddAttributeError(path.Root("host_registry_auth"), "Invalid registry auth", err.Error())
+
+		return diags
+	}
+
+	if len(file.Auths) == 0 {
+		diags.AddAttributeError(path.Root("host_registry_auth"), "Invalid registry auth",
+			"Expected a containers auth.json document with at least one entry in auths.")
+	}
+
+	return diags
+}
+
+// validateAutoUpdate checks the auto_update block for options that have no
+// effect on a disabled timer.
+func validateAutoUpdate(model *AutoUpdateModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if model == nil {
+		return diags
+	}
+
+	blockPath := path.Root("auto_update")
+
+	if !model.Enabled.IsNull() && !model.Enabled.ValueBool() && (!model.Schedule.IsNull() || !model.Mode.IsNull()) {
+		diags.AddAttributeError(blockPath.AtName("enabled"), "Conflicting update options",
+			"schedule and mode cannot be set when enabled = false.")
+	}
+
+	if knownString(model.Schedule) {
+		schedule := model.Schedule.ValueString()
+		if strings.TrimSpace(schedule) == "" || strings.ContainsAny(schedule, "\n\r") {
+			diags.AddAttributeError(blockPath.AtName("schedule"), "Invalid schedule",
+				"Expected a systemd calendar expression such as daily or Sun *-*-* 03:00:00, got: "+schedule)
+		}
+	}
+
+	return diags
+}
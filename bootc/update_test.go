@@ -0,0 +1,194 @@
+/*
+   Copyright 2026 Sumicare
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bootc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const testHostAuth = `{"auths": {"registry.example.com": {"auth": "dXNlcjpwYXNz"}}}`
+
+func TestAutoUpdateUnits(t *testing.T) {
+	tests := []struct {
+		name       string
+		model      AutoUpdateModel
+		wantUnits  []string
+		wantMasked bool
+		wantDropin string
+		wantStage  bool
+	}{
+		{
+			"defaults",
+			AutoUpdateModel{Enabled: types.BoolNull(), Schedule: types.StringNull(), Mode: types.StringNull()},
+			[]string{updateTimer}, false, "", false,
+		},
+		{
+			"schedule",
+			AutoUpdateModel{Enabled: types.BoolValue(true), Schedule: types.StringValue("Sun *-*-* 03:00:00")},
+			[]string{updateTimer}, false, "OnCalendar=Sun *-*-* 03:00:00\n", false,
+		},
+		{
+			"stage",
+			AutoUpdateModel{Mode: types.StringValue(updateModeStage)},
+			[]string{updateTimer, updateService}, false, "", true,
+		},
+		{
+			"disabled",
+			AutoUpdateModel{Enabled: types.BoolValue(false)},
+			[]string{updateTimer}, true, "", false,
+		},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			units := autoUpdateUnits(testCase.model)
+
+			if len(units) != len(testCase.wantUnits) {
+				t.Fatalf("units = %+v, want %q", units, testCase.wantUnits)
+			}
+
+			for unitIdx, unit := range units {
+				if unit.Name != testCase.wantUnits[unitIdx] {
+					t.Errorf("unit %d = %s, want %s", unitIdx, unit.Name, testCase.wantUnits[unitIdx])
+				}
+			}
+
+			timer := units[0]
+			if timer.Mask != testCase.wantMasked || timer.Enabled == testCase.wantMasked {
+				t.Errorf("timer = %+v", timer)
+			}
+
+			if !strings.Contains(timer.Dropins[updateDropin], testCase.wantDropin) {
+				t.Errorf("timer drop-in = %q, want %q", timer.Dropins[updateDropin], testCase.wantDropin)
+			}
+
+			if testCase.wantStage && !strings.Contains(units[1].Dropins[updateDropin], "ExecStart=/usr/bin/bootc upgrade --quiet\n") {
+				t.Errorf("service drop-in = %q", units[1].Dropins[updateDropin])
+			}
+		})
+	}
+}
+
+func TestInstallAutoUpdate(t *testing.T) {
+	dir := t.TempDir()
+	deployment := installedDeployment{Dir: dir, Var: filepath.Join(dir, "var")}
+
+	timerPath := deployment.DeployPath(filepath.Join(systemdVendorDir, updateTimer))
+
+	err := os.MkdirAll(filepath.Dir(timerPath), testDefaultDirPerms)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(timerPath, []byte("[Timer]\nOnBootSec=1h\n\n[Install]\nWantedBy=timers.target\n"), testSecureFilePerms)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, unit := range autoUpdateUnits(AutoUpdateModel{Schedule: types.StringValue("daily")}) {
+		err = deployment.InstallUnit(unit, nil)
+		if err != nil {
+			t.Fatalf("InstallUnit: %v", err)
+		}
+	}
+
+	link, err := os.Readlink(deployment.DeployPath("/etc/systemd/system/timers.target.wants/" + updateTimer))
+	if err != nil || link != filepath.Join(systemdVendorDir, updateTimer) {
+		t.Errorf("enablement link = %q, %v", link, err)
+	}
+
+	_, err = os.Stat(deployment.DeployPath("/etc/systemd/system/" + updateTimer + ".d/" + updateDropin))
+	if err != nil {
+		t.Errorf("timer drop-in: %v", err)
+	}
+
+	err = deployment.WriteFile(hostRegistryAuthFile(testHostAuth), nil)
+	if err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	info, err := os.Stat(deployment.DeployPath(ostreeAuthPath))
+	if err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("auth.json = %v, %v", info, err)
+	}
+}
+
+func TestValidateHostRegistryAuth(t *testing.T) {
+	tests := []struct {
+		name    string
+		auth    types.String
+		wantErr bool
+	}{
+		{"null", types.StringNull(), false},
+		{"unknown", types.StringUnknown(), false},
+		{"valid", types.StringValue(testHostAuth), false},
+		{"invalid_json", types.StringValue("{"), true},
+		{"no_auths", types.StringValue(`{"credHelpers": {}}`), true},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			if diags := validateHostRegistryAuth(testCase.auth); diags.HasError() != testCase.wantErr {
+				t.Errorf("diagnostics = %v, wantErr %v", diags, testCase.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateAutoUpdate(t *testing.T) {
+	tests := []struct {
+		name    string
+		model   *AutoUpdateModel
+		wantErr string
+	}{
+		{"none", nil, ""},
+		{"schedule", &AutoUpdateModel{Schedule: types.StringValue("daily")}, ""},
+		{"disabled", &AutoUpdateModel{Enabled: types.BoolValue(false)}, ""},
+		{"disabled_with_mode", &AutoUpdateModel{
+			Enabled: types.BoolValue(false),
+			Mode:    types.StringValue(updateModeStage),
+		}, "Conflicting update options"},
+		{"empty_schedule", &AutoUpdateModel{Schedule: types.StringValue(" ")}, "Invalid schedule"},
+		{"multiline_schedule", &AutoUpdateModel{Schedule: types.StringValue("daily\nExecStart=/bin/sh")}, "Invalid schedule"},
+	}
+
+	for idx := range tests {
+		testCase := tests[idx]
+		t.Run(testCase.name, func(t *testing.T) {
+			diags := validateAutoUpdate(testCase.model)
+
+			if testCase.wantErr == "" {
+				if diags.HasError() {
+					t.Errorf("unexpected diagnostics: %v", diags)
+				}
+
+				return
+			}
+
+			if !diags.HasError() || diags.Errors()[0].Summary() != testCase.wantErr {
+				t.Errorf("diagnostics = %v, want %q", diags, testCase.wantErr)
+			}
+		})
+	}
+}
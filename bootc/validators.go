@@ -19,7 +19,10 @@ package bootc
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"path"
 	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
@@ -61,3 +64,118 @@ func (v stringOneOfValidator) ValidateString(
 func stringOneOf(values ...string) validator.String {
 	return stringOneOfValidator{values: values}
 }
+
+type stringPathPrefixValidator struct {
+	prefixes []string
+}
+
+func (v stringPathPrefixValidator) Description(_ context.Context) string {
+	return "value must be an absolute path below one of: " + strings.Join(v.prefixes, ", ")
+}
+
+func (v stringPathPrefixValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v stringPathPrefixValidator) ValidateString(
+	_ context.Context,
+	req validator.StringRequest,
+	resp *validator.StringResponse,
+) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	val := req.ConfigValue.ValueString()
+	if path.IsAbs(val) {
+		clean := path.Clean(val)
+		for _, prefix := range v.prefixes {
+			if strings.HasPrefix(clean, strings.TrimSuffix(prefix, "/")+"/") {
+				return
+			}
+		}
+	}
+
+	resp.Diagnostics.AddAttributeError(
+		req.Path,
+		"Invalid path",
+		fmt.Sprintf("Expected an absolute path below %s, got: %s", strings.Join(v.prefixes, " or "), val),
+	)
+}
+
+func stringPathPrefix(prefixes ...string) validator.String {
+	return stringPathPrefixValidator{prefixes: prefixes}
+}
+
+type stringFileModeValidator struct{}
+
+func (stringFileModeValidator) Description(_ context.Context) string {
+	return "value must be an octal file mode such as 0644"
+}
+
+func (v stringFileModeValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (stringFileModeValidator) ValidateString(
+	_ context.Context,
+	req validator.StringRequest,
+	resp *validator.StringResponse,
+) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	val := req.ConfigValue.ValueString()
+	if mode, err := strconv.ParseUint(val, 8, 32); err == nil && mode <= 0o7777 {
+		return
+	}
+
+	resp.Diagnostics.AddAttributeError(
+		req.Path,
+		"Invalid file mode",
+		"Expected an octal file mode such as 0644, got: "+val,
+	)
+}
+
+func stringFileMode() validator.String {
+	return stringFileModeValidator{}
+}
+
+type stringURLValidator struct {
+	schemes []string
+}
+
+func (v stringURLValidator) Description(_ context.Context) string {
+	return "value must be an absolute URL with one of the schemes: " + strings.Join(v.schemes, ", ")
+}
+
+func (v stringURLValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v stringURLValidator) ValidateString(
+	_ context.Context,
+	req validator.StringRequest,
+	resp *validator.StringResponse,
+) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	val := req.ConfigValue.ValueString()
+	if parsed, err := url.Parse(val); err == nil && parsed.Host != "" && slices.Contains(v.schemes, parsed.Scheme) &&
+		!strings.ContainsAny(val, " \t\n'\"") {
+		return
+	}
+
+	resp.Diagnostics.AddAttributeError(
+		req.Path,
+		"Invalid URL",
+		fmt.Sprintf("Expected an absolute %s URL, got: %s", strings.Join(v.schemes, ", "), val),
+	)
+}
+
+func stringURL(schemes ...string) validator.String {
+	return stringURLValidator{schemes: schemes}
+}
@@ -29,6 +29,8 @@ import (
 var version = "dev"
 
 func main() {
+	provider.BridgeReexec()
+
 	var debug bool
 
 	flag.BoolVar(